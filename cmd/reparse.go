@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"goldie/internal/repository/archive"
+	"goldie/internal/repository/prices"
+	"goldie/internal/storage"
+	"goldie/internal/usecases"
+)
+
+var reparseCmd = &cobra.Command{
+	Use:   "reparse",
+	Short: "Parse the archived NBKR pages again and upsert the prices",
+	Run: func(cmd *cobra.Command, _ []string) {
+		// Initialize database connection
+		postgresConnection := storage.MustNewPostgresConnection(logger, cnf.Database.ConnString(), cnf.Logger.ParsedGORMLevel)
+		defer postgresConnection.MustClose()
+
+		postgresConnection.MustMigration()
+
+		// Initialize repository
+		pricesRepository := prices.NewRepository(postgresConnection.DB)
+		archiveRepository := archive.NewRepository(postgresConnection.DB)
+
+		// The admins review the quarantined prices with /quarantine once the bot is running
+		priceSaver := usecases.NewPriceSaver(logger, pricesRepository, nil, usecases.PriceValidationRules{
+			MaxDailyMove:        cnf.Validation.MaxDailyMove,
+			MaxPerGramDeviation: cnf.Validation.MaxPerGramDeviation,
+		})
+
+		reparseUC := usecases.NewReparseUseCase(logger, archiveRepository, priceSaver)
+		cobra.CheckErr(reparseUC.Run(cmd.Context()))
+	},
+}
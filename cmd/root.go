@@ -24,6 +24,7 @@ func Execute() {
 	initLogger()
 
 	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(reparseCmd)
 	if err := rootCmd.Execute(); err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"time"
 
@@ -10,7 +11,13 @@ import (
 
 	"goldie/internal/interaction/nbkr"
 	"goldie/internal/interaction/telegram"
+	"goldie/internal/model"
+	"goldie/internal/providers"
+	"goldie/internal/repository/archive"
+	"goldie/internal/repository/backfill"
 	"goldie/internal/repository/chats"
+	"goldie/internal/repository/deliveries"
+	"goldie/internal/repository/gaps"
 	"goldie/internal/repository/prices"
 	"goldie/internal/scheduler"
 	"goldie/internal/storage"
@@ -26,14 +33,21 @@ var serveCmd = &cobra.Command{
 		log := logger.With("package", "cmd")
 		ctx := cmd.Context()
 
+		// The circuit breaker state is reported on the health endpoint
+		nbkrBreaker := nbkr.NewCircuitBreaker(logger, cnf.NBKR.BreakerFailureThreshold, cnf.NBKR.BreakerOpenTimeout)
+
 		go func() {
 			http.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+
 				if !isReady.Load() {
 					w.WriteHeader(http.StatusServiceUnavailable)
-					return
+				} else {
+					w.WriteHeader(http.StatusOK)
 				}
 
-				w.WriteHeader(http.StatusOK)
+				// The open circuit doesn't make the bot unhealthy, it's reported for the operators
+				_ = json.NewEncoder(w).Encode(map[string]any{"ready": isReady.Load(), "nbkr_circuit": nbkrBreaker.State()})
 			})
 			_ = http.ListenAndServe(":8080", nil)
 		}()
@@ -58,27 +72,123 @@ var serveCmd = &cobra.Command{
 		nbkrClient := &http.Client{Timeout: time.Minute}
 
 		// Initialize interactions
-		telegramInteractor := telegram.NewInteraction(logger, cnf.Telegram.Token, telegramClient, bundle, pricesRepository, chatsRepository)
-		nbkrInteractor := nbkr.NewInteraction(logger, nbkrClient)
+		telegramInteractor := telegram.NewInteraction(logger, cnf.Telegram.Token, telegramClient, bundle, pricesRepository, chatsRepository,
+			telegram.WithAdmins(cnf.Telegram.AdminIDs),
+			telegram.WithQuarantine(pricesRepository),
+			telegram.WithOutbox(telegram.NewOutbox(logger, telegram.OutboxOptions{
+				GlobalRate:    cnf.Telegram.RateLimit,
+				ChatInterval:  cnf.Telegram.ChatInterval,
+				GroupInterval: cnf.Telegram.GroupInterval,
+				MaxRetries:    cnf.Telegram.MaxRetries,
+			})),
+		)
+		nbkrOptions := []nbkr.Option{
+			nbkr.WithRetryPolicy(nbkr.RetryPolicy{
+				MaxAttempts:    cnf.NBKR.RetryMaxAttempts,
+				InitialBackoff: cnf.NBKR.RetryInitialBackoff,
+				MaxBackoff:     cnf.NBKR.RetryMaxBackoff,
+			}),
+			nbkr.WithCircuitBreaker(nbkrBreaker),
+		}
+
+		if cnf.NBKR.ArchivePages {
+			nbkrOptions = append(nbkrOptions, nbkr.WithArchive(archive.NewRepository(postgresConnection.DB)))
+		}
+
+		nbkrInteractor := nbkr.NewInteraction(logger, nbkrClient, nbkrOptions...)
+
+		adminNotifier := telegram.NewAdminNotifier(logger, telegramInteractor, cnf.Telegram.AdminIDs)
+
+		// Initialize price providers
+		priceProviders, err := providers.NewRegistryFromConfig([]providers.Provider{nbkrInteractor}, cnf.Providers.Enabled)
+		cobra.CheckErr(err)
 
 		// Initialize usecases
-		updatePriceUC := usecases.NewUpdatePricesUseCase(logger, pricesRepository, nbkrInteractor, loc)
-		alertUC := usecases.NewAlertUseCase(logger, bundle, loc, pricesRepository, chatsRepository, telegramInteractor)
-
-		// We need to run the first import to fetch the old data
-		go updatePriceUC.FirstImport(ctx)
+		deliveriesRepository := deliveries.NewRepository(postgresConnection.DB)
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(logger, deliveriesRepository, telegramInteractor, usecases.AlertDeliveryOptions{
+			MaxAttempts:  cnf.Alerts.MaxAttempts,
+			RetryBackoff: cnf.Alerts.RetryBackoff,
+		})
+		alertUC := usecases.NewAlertUseCase(logger, bundle, loc, pricesRepository, chatsRepository, telegramInteractor, alertDeliveryUC)
+		digestUC := usecases.NewDigestUseCase(logger, pricesRepository, chatsRepository, telegramInteractor, alertDeliveryUC)
+		pauseUC := usecases.NewPauseUseCase(logger, pricesRepository, chatsRepository, telegramInteractor, alertDeliveryUC)
+		priceAlertUC := usecases.NewPriceAlertUseCase(logger, pricesRepository, chatsRepository, telegramInteractor, alertDeliveryUC)
+		signalUC := usecases.NewSignalUseCase(logger, pricesRepository, chatsRepository, telegramInteractor, alertDeliveryUC)
+		alertDispatchUC := usecases.NewAlertDispatchUseCase(logger, deliveriesRepository, usecases.DeliveryWindow{
+			From: cnf.Alerts.DeliveryFrom,
+			To:   cnf.Alerts.DeliveryTo,
+		}, loc, priceAlertUC)
+		// Every import path saves through it, so the suspicious prices are quarantined wherever they come from
+		priceSaver := usecases.NewPriceSaver(logger, pricesRepository, adminNotifier, usecases.PriceValidationRules{
+			MaxDailyMove:        cnf.Validation.MaxDailyMove,
+			MaxPerGramDeviation: cnf.Validation.MaxPerGramDeviation,
+		})
+		updatePriceUC := usecases.NewUpdatePricesUseCase(logger, pricesRepository, priceSaver, priceProviders, nbkrInteractor, adminNotifier, usecases.UpdatePricesOptions{
+			NotifyRevisions: cnf.Providers.NotifyRevisions,
+		}, loc)
+		backfillUC := usecases.NewBackfillUseCase(logger, pricesRepository, priceSaver, backfill.NewRepository(postgresConnection.DB), priceProviders, adminNotifier, usecases.BackfillOptions{
+			Workers:      cnf.Backfill.Workers,
+			ChunkMonths:  cnf.Backfill.ChunkMonths,
+			MaxAttempts:  cnf.Backfill.MaxAttempts,
+			RetryBackoff: cnf.Backfill.RetryBackoff,
+		}, loc)
+		reconcileUC := usecases.NewReconcileUseCase(logger, pricesRepository, priceSaver, gaps.NewRepository(postgresConnection.DB), priceProviders, adminNotifier, cnf.Reconcile.MaxAttempts)
+
+		// We need to run the backfill to fetch the old data, it continues from the last finished chunk
+		go backfillUC.Run(ctx)
 
 		// Initialize scheduler
 		sched := scheduler.New(ctx, loc)
 
-		sched.Add("15 9 * * 1-5", func(ctx context.Context) {
+		// The price alerts are dispatched once the prices of a new date are saved
+		sched.Add("* * * * *", func(ctx context.Context) {
+			alertDispatchUC.Dispatch(ctx)
+		})
+
+		// The daily alerts are sent to every chat at its delivery time in its timezone
+		sched.Add("* * * * *", func(ctx context.Context) {
+			alertUC.Run(ctx)
+		})
+
+		// The signals are checked against the latest prices at the delivery time of every chat
+		sched.Add("* * * * *", func(ctx context.Context) {
+			signalUC.Run(ctx, time.Now())
+		})
+
+		// The paused alerts are resumed with the summary of the missed prices at the delivery time of every chat
+		sched.Add("* * * * *", func(ctx context.Context) {
+			pauseUC.Run(ctx, time.Now())
+		})
+
+		// The digests are sent on the first day of the next period at the delivery time of every chat
+		sched.Add("*/15 * * * *", func(ctx context.Context) {
+			digestUC.Run(ctx, model.DigestWeekly, time.Now())
+		})
+
+		sched.Add("*/15 * * * *", func(ctx context.Context) {
+			digestUC.Run(ctx, model.DigestMonthly, time.Now())
+		})
+
+		// The failed alerts are retried with backoff
+		sched.Add("*/5 * * * *", func(ctx context.Context) {
+			alertDeliveryUC.RetryFailed(ctx)
+		})
+
+		// NBKR may publish late, so the prices are polled until the evening
+		sched.Add("*/30 9-18 * * 1-5", func(ctx context.Context) {
 			log.Info("running NBKR update")
 			updatePriceUC.UpdatePrices(ctx)
 		})
 
-		sched.Add("0 10 * * 1-5", func(ctx context.Context) {
-			log.Info("running alert")
-			alertUC.Run(ctx)
+		// The finished chunks are skipped, so it only retries the failed ones and fetches the current one
+		sched.Add("0 3 * * *", func(ctx context.Context) {
+			log.Info("running backfill")
+			backfillUC.Run(ctx)
+		})
+
+		sched.Add("0 5 * * 6", func(ctx context.Context) {
+			log.Info("running price reconciliation")
+			reconcileUC.Run(ctx)
 		})
 
 		// Start scheduler
@@ -3,18 +3,30 @@ package config
 import (
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 const DefaultLanguageCode = "en"
 
+const (
+	DefaultTimezone     = "Asia/Bishkek"
+	DefaultDeliveryTime = "10:00" // in the chat timezone
+)
+
 var BuildVersion = "dev"
 
 type Config struct {
-	Database Database `yaml:"database"`
-	Telegram Telegram `yaml:"telegram"`
-	Logger   Logger   `yaml:"logger"`
+	Database   Database   `yaml:"database"`
+	Telegram   Telegram   `yaml:"telegram"`
+	Logger     Logger     `yaml:"logger"`
+	Providers  Providers  `yaml:"providers"`
+	NBKR       NBKR       `yaml:"nbkr"`
+	Backfill   Backfill   `yaml:"backfill"`
+	Reconcile  Reconcile  `yaml:"reconcile"`
+	Validation Validation `yaml:"validation"`
+	Alerts     Alerts     `yaml:"alerts"`
 }
 
 type Database struct {
@@ -35,7 +47,49 @@ func (d *Database) DSN() string {
 }
 
 type Telegram struct {
-	Token string `env-default:"" yaml:"token"`
+	Token         string        `env-default:"" yaml:"token"`
+	AdminIDs      []int64       `yaml:"admin_ids"`
+	RateLimit     int           `env-default:"25" yaml:"rate_limit"`
+	ChatInterval  time.Duration `env-default:"1s" yaml:"chat_interval"`
+	GroupInterval time.Duration `env-default:"3s" yaml:"group_interval"`
+	MaxRetries    int           `env-default:"3" yaml:"max_retries"`
+}
+
+type Providers struct {
+	Enabled         []string `env-default:"nbkr" yaml:"enabled"`
+	NotifyRevisions bool     `env-default:"false" yaml:"notify_revisions"`
+}
+
+type NBKR struct {
+	RetryMaxAttempts        int           `env-default:"4" yaml:"retry_max_attempts"`
+	RetryInitialBackoff     time.Duration `env-default:"2s" yaml:"retry_initial_backoff"`
+	RetryMaxBackoff         time.Duration `env-default:"1m" yaml:"retry_max_backoff"`
+	BreakerFailureThreshold int           `env-default:"3" yaml:"breaker_failure_threshold"`
+	BreakerOpenTimeout      time.Duration `env-default:"15m" yaml:"breaker_open_timeout"`
+	ArchivePages            bool          `env-default:"false" yaml:"archive_pages"`
+}
+
+type Backfill struct {
+	Workers      int           `env-default:"3" yaml:"workers"`
+	ChunkMonths  int           `env-default:"12" yaml:"chunk_months"`
+	MaxAttempts  int           `env-default:"3" yaml:"max_attempts"`
+	RetryBackoff time.Duration `env-default:"30s" yaml:"retry_backoff"`
+}
+
+type Reconcile struct {
+	MaxAttempts int `env-default:"3" yaml:"max_attempts"`
+}
+
+type Validation struct {
+	MaxDailyMove        float64 `env-default:"0.1" yaml:"max_daily_move"`
+	MaxPerGramDeviation float64 `env-default:"0.5" yaml:"max_per_gram_deviation"`
+}
+
+type Alerts struct {
+	DeliveryFrom time.Duration `env-default:"9h" yaml:"delivery_from"` // of the price alerts, the daily ones use the chat delivery time
+	DeliveryTo   time.Duration `env-default:"21h" yaml:"delivery_to"`
+	MaxAttempts  int           `env-default:"5" yaml:"max_attempts"`
+	RetryBackoff time.Duration `env-default:"1m" yaml:"retry_backoff"`
 }
 
 type Logger struct {
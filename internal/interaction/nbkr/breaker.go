@@ -0,0 +1,107 @@
+package nbkr
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the NBKR while it's considered down.
+var ErrCircuitOpen = errors.New("nbkr circuit breaker is open")
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// CircuitBreaker stops the requests to the NBKR after several consecutive failures.
+// After the open timeout a single probe request is allowed: its success closes the circuit, its failure opens it again.
+type CircuitBreaker struct {
+	logger           *slog.Logger
+	failureThreshold int
+	openTimeout      time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker creates a new closed circuit breaker.
+func NewCircuitBreaker(logger *slog.Logger, failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		logger:           logger.With("component", "nbkr_circuit_breaker"),
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		state:            CircuitClosed,
+	}
+}
+
+// Allow returns ErrCircuitOpen if the request shouldn't be made.
+func (that *CircuitBreaker) Allow() error {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	switch that.state {
+	case CircuitOpen:
+		if time.Since(that.openedAt) < that.openTimeout {
+			return ErrCircuitOpen
+		}
+
+		that.setState(CircuitHalfOpen)
+		return nil
+	case CircuitHalfOpen:
+		// The probe request is in progress
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+// Success records the successful call.
+func (that *CircuitBreaker) Success() {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	that.failures = 0
+	if that.state != CircuitClosed {
+		that.setState(CircuitClosed)
+	}
+}
+
+// Failure records the call which failed after all its retries.
+func (that *CircuitBreaker) Failure() {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	that.failures++
+	if that.state == CircuitHalfOpen || (that.state == CircuitClosed && that.failures >= that.failureThreshold) {
+		that.openedAt = time.Now()
+		that.setState(CircuitOpen)
+	}
+}
+
+// Cancel releases the probe request which was canceled before the NBKR responded.
+func (that *CircuitBreaker) Cancel() {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	if that.state == CircuitHalfOpen {
+		that.setState(CircuitOpen)
+	}
+}
+
+// State returns the current state: closed, open or half-open.
+func (that *CircuitBreaker) State() string {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	return that.state
+}
+
+func (that *CircuitBreaker) setState(state string) {
+	that.logger.Warn("nbkr circuit breaker state changed", "from", that.state, "to", state, "failures", that.failures)
+	that.state = state
+}
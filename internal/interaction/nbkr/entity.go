@@ -14,3 +14,10 @@ type GoldPrice struct {
 	PurchasePrice float64   // ex: 12526.00
 	SellPrice     float64   // ex: 12588.50
 }
+
+// CurrencyRate describes an official NBKR exchange rate.
+type CurrencyRate struct {
+	Date     time.Time // ex: 2025-11-07
+	Currency string    // ex: USD
+	Rate     float64   // ex: 87.45 (KGS for one unit of the currency)
+}
@@ -0,0 +1,19 @@
+package nbkr
+
+import "fmt"
+
+// ParseError describes where and why the NBKR page can't be parsed.
+type ParseError struct {
+	Err    error    // providers.ErrLayoutChanged, providers.ErrNoRows or providers.ErrInvalidRow
+	Row    int      // the table row number, 0 is the header
+	Cells  []string // the raw text of the row cells
+	Reason string
+}
+
+func (that *ParseError) Error() string {
+	return fmt.Sprintf("%s: row %d: %s: %q", that.Err, that.Row, that.Reason, that.Cells)
+}
+
+func (that *ParseError) Unwrap() error {
+	return that.Err
+}
@@ -7,18 +7,59 @@ import (
 	"log/slog"
 	"net/http"
 	"time"
+
+	"goldie/internal/model"
 )
 
+type Option func(that *Interaction)
+
+// PageArchive stores the raw gold prices pages to parse them again later.
+type PageArchive interface {
+	SavePage(ctx context.Context, page *model.ArchivedPage) error
+}
+
 type Interaction struct {
-	logger *slog.Logger
-	client *http.Client
+	logger  *slog.Logger
+	client  *http.Client
+	retry   RetryPolicy
+	breaker *CircuitBreaker
+	archive PageArchive
 }
 
 // NewInteraction creates a new instance of Interaction with NBKR.
-func NewInteraction(logger *slog.Logger, client *http.Client) *Interaction {
-	return &Interaction{
+// By default, the failed requests are not retried.
+func NewInteraction(logger *slog.Logger, client *http.Client, opts ...Option) *Interaction {
+	cnt := &Interaction{
 		logger: logger.With("component", "nbkr"),
 		client: client,
+		retry:  RetryPolicy{MaxAttempts: 1},
+	}
+
+	for _, opt := range opts {
+		opt(cnt)
+	}
+
+	return cnt
+}
+
+// WithRetryPolicy retries the failed requests according to the policy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(that *Interaction) {
+		that.retry = policy
+	}
+}
+
+// WithCircuitBreaker stops the requests while the NBKR is down.
+func WithCircuitBreaker(breaker *CircuitBreaker) Option {
+	return func(that *Interaction) {
+		that.breaker = breaker
+	}
+}
+
+// WithArchive stores every fetched gold prices page in the archive.
+func WithArchive(archive PageArchive) Option {
+	return func(that *Interaction) {
+		that.archive = archive
 	}
 }
 
@@ -30,25 +71,139 @@ func (that *Interaction) GetGoldPrices(ctx context.Context, beginDate time.Time,
 		target += fmt.Sprintf("&begin_day=%02d&begin_month=%02d&begin_year=%d&end_day=%02d&end_month=%02d&end_year=%d", beginDate.Day(), beginDate.Month(), beginDate.Year(), endDate.Day(), endDate.Month(), endDate.Year())
 	}
 
+	body, err := that.fetch(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if that.archive != nil {
+		page := &model.ArchivedPage{URL: target, BeginDate: beginDate, EndDate: endDate, FetchedAt: time.Now(), Body: body}
+		if err = that.archive.SavePage(ctx, page); err != nil {
+			that.logger.Error("failed to archive gold prices page", "error", err, "url", target)
+		}
+	}
+
+	return ParseGoldPrice(body)
+}
+
+// GetCurrencyRates returns the official exchange rates for the current day.
+func (that *Interaction) GetCurrencyRates(ctx context.Context) ([]CurrencyRate, error) {
+	body, err := that.fetch(ctx, "https://www.nbkr.kg/XML/daily.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCurrencyRates(body)
+}
+
+// fetch returns the response body of the given NBKR page.
+// The temporary failures are retried with backoff. The circuit breaker is consulted once before the first attempt
+// and gets the outcome of the whole call, so the retries of one call don't open it.
+func (that *Interaction) fetch(ctx context.Context, target string) (string, error) {
+	if that.breaker != nil {
+		if err := that.breaker.Allow(); err != nil {
+			return "", err
+		}
+	}
+
+	body, err := that.fetchWithRetries(ctx, target)
+
+	if that.breaker != nil {
+		switch {
+		case err == nil:
+			that.breaker.Success()
+		case ctx.Err() != nil:
+			that.breaker.Cancel()
+		default:
+			that.breaker.Failure()
+		}
+	}
+
+	return body, err
+}
+
+// fetchWithRetries requests the NBKR until it succeeds, the error isn't temporary or the attempts run out.
+func (that *Interaction) fetchWithRetries(ctx context.Context, target string) (string, error) {
+	log := that.logger.With("method", "fetchWithRetries", "url", target)
+
+	for attempt := 1; ; attempt++ {
+		body, err := that.doRequest(ctx, target)
+		if err == nil {
+			return body, nil
+		}
+
+		if ctx.Err() != nil || !isRetryable(err) || attempt >= that.retry.MaxAttempts {
+			return "", err
+		}
+
+		delay, ok := that.retry.backoff(attempt, err)
+		if !ok {
+			log.Warn("server asks to wait longer than the max backoff, giving up", "error", err, "attempt", attempt)
+			return "", err
+		}
+
+		log.Warn("retrying failed request", "error", err, "attempt", attempt, "delay", delay)
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (that *Interaction) doRequest(ctx context.Context, target string) (string, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return "", fmt.Errorf("create request: %w", err)
 	}
 
 	resp, err := that.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("do request: %w", err)
+		return "", fmt.Errorf("do request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad status code: %d", resp.StatusCode)
+		return "", &StatusError{StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
+		return "", fmt.Errorf("read response body: %w", err)
+	}
+
+	return string(body), nil
+}
+
+// Source returns the name of the NBKR price source.
+func (that *Interaction) Source() string {
+	return model.PriceSourceNBKR
+}
+
+// GetPrices returns a list of gold prices for the given period ready to be stored in the database.
+// The valid prices are returned along with the providers.ErrInvalidRow errors.
+func (that *Interaction) GetPrices(ctx context.Context, beginDate time.Time, endDate time.Time) ([]*model.GoldPrice, error) {
+	prices, err := that.GetGoldPrices(ctx, beginDate, endDate)
+	if err != nil && len(prices) == 0 {
+		return nil, err
+	}
+
+	return ToModelPrices(prices), err
+}
+
+// ToModelPrices converts the NBKR prices into the prices ready to be stored in the database.
+func ToModelPrices(prices []GoldPrice) []*model.GoldPrice {
+	dbPrices := make([]*model.GoldPrice, len(prices))
+	for i, price := range prices {
+		dbPrices[i] = &model.GoldPrice{
+			Date:          price.Date,
+			Weight:        price.Weight,
+			Source:        model.PriceSourceNBKR,
+			PurchasePrice: price.PurchasePrice,
+			SellPrice:     price.SellPrice,
+		}
 	}
 
-	return ParseGoldPrice(string(body))
+	return dbPrices
 }
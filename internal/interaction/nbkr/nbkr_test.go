@@ -3,14 +3,19 @@ package nbkr_test
 import (
 	"context"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"gopkg.in/dnaeon/go-vcr.v4/pkg/recorder"
 
 	"goldie/internal/interaction/nbkr"
+	"goldie/internal/providers"
 	"goldie/testing/suite"
 )
 
@@ -52,3 +57,220 @@ func Test_GetGoldPrices(t *testing.T) {
 
 	require.Equal(t, expectedPrices, prices)
 }
+
+func Test_ParseCurrencyRates(t *testing.T) {
+	document := `<?xml version="1.0" encoding="windows-1251"?>
+<CurrencyRates Name="Daily Exchange Rates" Date="07.11.2025">
+<Currency ISOCode="USD"><Nominal>1</Nominal><Value>87,4500</Value></Currency>
+<Currency ISOCode="EUR"><Nominal>1</Nominal><Value>100,8720</Value></Currency>
+<Currency ISOCode="RUB"><Nominal>1</Nominal><Value>1,0766</Value></Currency>
+<Currency ISOCode="KZT"><Nominal>100</Nominal><Value>16,5000</Value></Currency>
+</CurrencyRates>`
+
+	rates, err := nbkr.ParseCurrencyRates(document)
+	require.NoError(t, err)
+
+	date := suite.GetDateTime(t, "2025-11-07")
+	expectedRates := []nbkr.CurrencyRate{
+		{Date: date, Currency: "USD", Rate: 87.45},
+		{Date: date, Currency: "EUR", Rate: 100.872},
+		{Date: date, Currency: "RUB", Rate: 1.0766},
+		{Date: date, Currency: "KZT", Rate: 0.165},
+	}
+
+	require.Equal(t, expectedRates, rates)
+}
+
+const testGoldPricesPage = `<table><tr><td>Дата</td><td>Мерные золотые слитки, г.</td><td>Цена обратного выкупа, сом</td><td>Цена продажи, сом</td></tr>
+<tr><td>07.11.2025</td><td>1.00</td><td>12 577.00</td><td>12 640.00</td></tr></table>`
+
+func Test_GetGoldPrices_Retry(t *testing.T) {
+	t.Run("should retry temporary failures and honor Retry-After", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if calls.Add(1) < 3 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			_, _ = w.Write([]byte(testGoldPricesPage))
+		}))
+		t.Cleanup(server.Close)
+
+		interaction := nbkr.NewInteraction(slog.Default(), newRedirectClient(server),
+			nbkr.WithRetryPolicy(nbkr.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Second}),
+		)
+
+		startedAt := time.Now()
+		prices, err := interaction.GetGoldPrices(context.Background(), time.Time{}, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, prices, 1)
+		require.EqualValues(t, 3, calls.Load())
+
+		// Every retry waits for the whole Retry-After
+		require.GreaterOrEqual(t, time.Since(startedAt), 2*time.Second)
+	})
+
+	t.Run("should give up when Retry-After is longer than the max backoff", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		t.Cleanup(server.Close)
+
+		interaction := nbkr.NewInteraction(slog.Default(), newRedirectClient(server),
+			nbkr.WithRetryPolicy(nbkr.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Minute}),
+		)
+
+		startedAt := time.Now()
+		_, err := interaction.GetGoldPrices(context.Background(), time.Time{}, time.Time{})
+		require.EqualError(t, err, "bad status code: 429")
+
+		// The request shouldn't be repeated before the server allows it
+		require.EqualValues(t, 1, calls.Load())
+		require.Less(t, time.Since(startedAt), time.Second)
+	})
+
+	t.Run("shouldn't retry client errors", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		t.Cleanup(server.Close)
+
+		interaction := nbkr.NewInteraction(slog.Default(), newRedirectClient(server),
+			nbkr.WithRetryPolicy(nbkr.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}),
+		)
+
+		_, err := interaction.GetGoldPrices(context.Background(), time.Time{}, time.Time{})
+		require.EqualError(t, err, "bad status code: 404")
+		require.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("should open the circuit after consecutive failures", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		t.Cleanup(server.Close)
+
+		breaker := nbkr.NewCircuitBreaker(slog.Default(), 2, time.Hour)
+		interaction := nbkr.NewInteraction(slog.Default(), newRedirectClient(server), nbkr.WithCircuitBreaker(breaker))
+
+		for range 2 {
+			_, err := interaction.GetGoldPrices(context.Background(), time.Time{}, time.Time{})
+			require.EqualError(t, err, "bad status code: 502")
+		}
+
+		require.Equal(t, nbkr.CircuitOpen, breaker.State())
+
+		_, err := interaction.GetGoldPrices(context.Background(), time.Time{}, time.Time{})
+		require.ErrorIs(t, err, nbkr.ErrCircuitOpen)
+		require.EqualValues(t, 2, calls.Load())
+	})
+
+	t.Run("should count the retried call as one failure", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		t.Cleanup(server.Close)
+
+		// Given: The breaker opens after 2 failures and every call is tried 4 times
+		breaker := nbkr.NewCircuitBreaker(slog.Default(), 2, time.Hour)
+		interaction := nbkr.NewInteraction(slog.Default(), newRedirectClient(server),
+			nbkr.WithCircuitBreaker(breaker),
+			nbkr.WithRetryPolicy(nbkr.RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}),
+		)
+
+		// When: The first call fails after all its attempts
+		_, err := interaction.GetGoldPrices(context.Background(), time.Time{}, time.Time{})
+		require.EqualError(t, err, "bad status code: 502")
+
+		// Then: All the attempts should be made and the circuit should stay closed
+		require.EqualValues(t, 4, calls.Load())
+		require.Equal(t, nbkr.CircuitClosed, breaker.State())
+
+		// When: The second call fails too
+		_, err = interaction.GetGoldPrices(context.Background(), time.Time{}, time.Time{})
+		require.EqualError(t, err, "bad status code: 502")
+
+		// Then: The circuit should be opened
+		require.EqualValues(t, 8, calls.Load())
+		require.Equal(t, nbkr.CircuitOpen, breaker.State())
+	})
+}
+
+// newRedirectClient returns a client sending all the requests to the test server.
+func newRedirectClient(server *httptest.Server) *http.Client {
+	client := server.Client()
+	client.Transport = roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		request.URL.Scheme = "http"
+		request.URL.Host = server.Listener.Addr().String()
+		return http.DefaultTransport.RoundTrip(request)
+	})
+
+	return client
+}
+
+type roundTripFunc func(request *http.Request) (*http.Response, error)
+
+func (that roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return that(request)
+}
+
+func Test_ParseGoldPrice_SchemaDrift(t *testing.T) {
+	t.Run("should fail if the header is changed", func(t *testing.T) {
+		page := `<table><tr><td>Дата</td><td>Вес, г.</td><td>Покупка</td><td>Продажа</td></tr>
+<tr><td>07.11.2025</td><td>1.00</td><td>12 577.00</td><td>12 640.00</td></tr></table>`
+
+		prices, err := nbkr.ParseGoldPrice(page)
+		require.Nil(t, prices)
+		require.ErrorIs(t, err, providers.ErrLayoutChanged)
+
+		var parseErr *nbkr.ParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, 0, parseErr.Row)
+		require.Equal(t, []string{"Дата", "Вес, г.", "Покупка", "Продажа"}, parseErr.Cells)
+	})
+
+	t.Run("should fail if there are no rows", func(t *testing.T) {
+		page := `<table><tr><td>Дата</td><td>Мерные золотые слитки, г.</td><td>Цена обратного выкупа, сом</td><td>Цена продажи, сом</td></tr></table>`
+
+		prices, err := nbkr.ParseGoldPrice(page)
+		require.Nil(t, prices)
+		require.ErrorIs(t, err, providers.ErrNoRows)
+	})
+
+	t.Run("should return valid prices along with the invalid rows", func(t *testing.T) {
+		page := `<table><tr><td>Дата</td><td>Мерные золотые слитки, г.</td><td>Цена обратного выкупа, сом</td><td>Цена продажи, сом</td></tr>
+<tr><td>07.11.2025</td><td>1.00</td><td>12 577.00</td><td>12 640.00</td></tr>
+<tr><td>07.11.2025</td><td>2.00</td><td>-</td><td>23 973.00</td></tr></table>`
+
+		prices, err := nbkr.ParseGoldPrice(page)
+		require.Equal(t, []nbkr.GoldPrice{{Date: suite.GetDateTime(t, "2025-11-07"), Weight: 1, PurchasePrice: 12577, SellPrice: 12640}}, prices)
+		require.ErrorIs(t, err, providers.ErrInvalidRow)
+		require.NotErrorIs(t, err, providers.ErrLayoutChanged)
+
+		var parseErr *nbkr.ParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, 2, parseErr.Row)
+		require.Equal(t, []string{"07.11.2025", "2.00", "-", "23 973.00"}, parseErr.Cells)
+	})
+
+	t.Run("should fail if none of the rows looks like a price", func(t *testing.T) {
+		page := `<table><tr><td>Дата</td><td>Мерные золотые слитки, г.</td><td>Цена обратного выкупа, сом</td><td>Цена продажи, сом</td></tr>
+<tr><td>07.11.2025</td><td>1.00</td><td>0</td></tr></table>`
+
+		prices, err := nbkr.ParseGoldPrice(page)
+		require.Nil(t, prices)
+		require.ErrorIs(t, err, providers.ErrLayoutChanged)
+		require.ErrorIs(t, err, providers.ErrInvalidRow)
+	})
+}
@@ -1,53 +1,70 @@
 package nbkr
 
 import (
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/text/encoding/charmap"
+
+	"goldie/internal/providers"
 )
 
+// dailyRates describes the XML document with the daily exchange rates.
+type dailyRates struct {
+	Date       string `xml:"Date,attr"`
+	Currencies []struct {
+		ISOCode string `xml:"ISOCode,attr"`
+		Nominal string `xml:"Nominal"`
+		Value   string `xml:"Value"`
+	} `xml:"Currency"`
+}
+
+// goldPriceHeader contains the expected parts of the prices table header labels in the column order.
+var goldPriceHeader = []string{"дата", "слитки", "выкуп", "продаж"}
+
+// ParseGoldPrice parses the NBKR gold prices page.
+// The returned error wraps providers.ErrLayoutChanged if the prices table isn't found or none of its rows look like a price,
+// providers.ErrNoRows if the table is empty, and providers.ErrInvalidRow for every row that can't be parsed.
+// In the last case the valid prices are returned along with the error.
 func ParseGoldPrice(html string) ([]GoldPrice, error) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
 		return nil, fmt.Errorf("parse html: %w", err)
 	}
 
-	var prices []GoldPrice
-	var errParse error
-
-	doc.Find("table tbody tr").Each(func(_ int, tr *goquery.Selection) {
-		tds := tr.Find("td")
-		if tds.Length() < 4 {
-			return
-		}
-
-		dateStr := strings.TrimSpace(tds.Eq(0).Text())
-		weightStr := cleanNumber(tds.Eq(1).Text())
-		buyStr := cleanNumber(tds.Eq(2).Text())
-		sellStr := cleanNumber(tds.Eq(3).Text())
+	rows, err := findGoldPriceRows(doc)
+	if err != nil {
+		return nil, err
+	}
 
-		weight, _ := strconv.ParseFloat(weightStr, 64)
-		buy, _ := strconv.ParseFloat(buyStr, 64)
-		sell, _ := strconv.ParseFloat(sellStr, 64)
+	if len(rows) == 0 {
+		return nil, &ParseError{Err: providers.ErrNoRows, Reason: "no rows after the header"}
+	}
 
-		if weight == 0 || buy == 0 || sell == 0 {
-			return
-		}
+	var prices []GoldPrice
+	var rowErrors []error
 
-		date, err := time.Parse(DateLayout, dateStr)
+	for i, cells := range rows {
+		price, err := parseGoldPriceRow(i+1, cells)
 		if err != nil {
-			if errParse == nil {
-				errParse = fmt.Errorf("parse date: %w", err)
-			}
-			return
+			rowErrors = append(rowErrors, err)
+			continue
 		}
 
-		prices = append(prices, GoldPrice{Date: date, Weight: weight, PurchasePrice: buy, SellPrice: sell})
-	})
+		prices = append(prices, price)
+	}
+
+	if len(prices) == 0 {
+		layoutErr := &ParseError{Err: providers.ErrLayoutChanged, Row: 1, Cells: rows[0], Reason: "none of the rows looks like a price"}
+		return nil, errors.Join(append([]error{layoutErr}, rowErrors...)...)
+	}
 
 	sort.SliceStable(prices, func(i, j int) bool {
 		di := prices[i].Date
@@ -58,7 +75,128 @@ func ParseGoldPrice(html string) ([]GoldPrice, error) {
 		return di.Before(dj)
 	})
 
-	return prices, errParse
+	return prices, errors.Join(rowErrors...)
+}
+
+// findGoldPriceRows finds the prices table by its header and returns the raw text of its data rows.
+func findGoldPriceRows(doc *goquery.Document) ([][]string, error) {
+	var rows [][]string
+	var candidate []string
+	found := false
+
+	doc.Find("table").EachWithBreak(func(_ int, table *goquery.Selection) bool {
+		trs := table.Find("tr")
+		if trs.Length() == 0 {
+			return true
+		}
+
+		header := rowCells(trs.First())
+		if !matchGoldPriceHeader(header) {
+			if candidate == nil && strings.Contains(normalizeLabel(strings.Join(header, " ")), goldPriceHeader[0]) {
+				candidate = header
+			}
+			return true
+		}
+
+		found = true
+		trs.Slice(1, trs.Length()).Each(func(_ int, tr *goquery.Selection) {
+			rows = append(rows, rowCells(tr))
+		})
+		return false
+	})
+
+	if !found {
+		return nil, &ParseError{Err: providers.ErrLayoutChanged, Row: 0, Cells: candidate, Reason: "prices table header not found"}
+	}
+
+	return rows, nil
+}
+
+func parseGoldPriceRow(row int, cells []string) (GoldPrice, error) {
+	if len(cells) != len(goldPriceHeader) {
+		return GoldPrice{}, &ParseError{Err: providers.ErrInvalidRow, Row: row, Cells: cells, Reason: fmt.Sprintf("expected %d cells, got %d", len(goldPriceHeader), len(cells))}
+	}
+
+	date, err := time.Parse(DateLayout, cells[0])
+	if err != nil {
+		return GoldPrice{}, &ParseError{Err: providers.ErrInvalidRow, Row: row, Cells: cells, Reason: fmt.Sprintf("parse date: %s", err)}
+	}
+
+	values := make([]float64, 3)
+	for i := range values {
+		value, err := strconv.ParseFloat(cleanNumber(cells[i+1]), 64)
+		if err != nil || value <= 0 {
+			return GoldPrice{}, &ParseError{Err: providers.ErrInvalidRow, Row: row, Cells: cells, Reason: fmt.Sprintf("column %d isn't a positive number", i+2)}
+		}
+
+		values[i] = value
+	}
+
+	return GoldPrice{Date: date, Weight: values[0], PurchasePrice: values[1], SellPrice: values[2]}, nil
+}
+
+func matchGoldPriceHeader(header []string) bool {
+	if len(header) != len(goldPriceHeader) {
+		return false
+	}
+
+	for i, label := range header {
+		if !strings.Contains(normalizeLabel(label), goldPriceHeader[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func rowCells(tr *goquery.Selection) []string {
+	var cells []string
+	tr.Find("td, th").Each(func(_ int, td *goquery.Selection) {
+		cells = append(cells, strings.TrimSpace(td.Text()))
+	})
+
+	return cells
+}
+
+// normalizeLabel lowercases the label and collapses its whitespaces.
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// ParseCurrencyRates parses the NBKR daily exchange rates XML document.
+func ParseCurrencyRates(document string) ([]CurrencyRate, error) {
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		if strings.EqualFold(charset, "windows-1251") {
+			return charmap.Windows1251.NewDecoder().Reader(input), nil
+		}
+
+		return nil, fmt.Errorf("unsupported charset: %s", charset)
+	}
+
+	var rates dailyRates
+	if err := decoder.Decode(&rates); err != nil {
+		return nil, fmt.Errorf("parse xml: %w", err)
+	}
+
+	date, err := time.Parse(DateLayout, strings.TrimSpace(rates.Date))
+	if err != nil {
+		return nil, fmt.Errorf("parse date: %w", err)
+	}
+
+	result := make([]CurrencyRate, 0, len(rates.Currencies))
+	for _, currency := range rates.Currencies {
+		nominal, _ := strconv.ParseFloat(cleanNumber(currency.Nominal), 64)
+		value, _ := strconv.ParseFloat(cleanNumber(currency.Value), 64)
+
+		if nominal == 0 || value == 0 {
+			continue
+		}
+
+		result = append(result, CurrencyRate{Date: date, Currency: strings.TrimSpace(currency.ISOCode), Rate: value / nominal})
+	}
+
+	return result, nil
 }
 
 func cleanNumber(s string) string {
@@ -0,0 +1,87 @@
+package nbkr
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy describes how the failed NBKR requests are retried.
+type RetryPolicy struct {
+	MaxAttempts    int           // 1 means no retries
+	InitialBackoff time.Duration // the delay before the first retry
+	MaxBackoff     time.Duration // the delay upper bound, the longer Retry-After header stops the retries
+}
+
+// StatusError is returned when the NBKR responds with a non-200 status code.
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // parsed Retry-After header, zero if absent
+}
+
+func (that *StatusError) Error() string {
+	return fmt.Sprintf("bad status code: %d", that.StatusCode)
+}
+
+// Retryable reports whether the request can succeed if repeated.
+func (that *StatusError) Retryable() bool {
+	return that.StatusCode == http.StatusTooManyRequests || that.StatusCode >= http.StatusInternalServerError
+}
+
+// isRetryable reports whether the error of the request attempt is temporary.
+// Network errors are considered temporary.
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable()
+	}
+
+	return true
+}
+
+// backoff returns the delay before the next attempt: exponential with equal jitter.
+// The Retry-After value of the failed response is honored if it's longer. It reports false if the server asks
+// to wait longer than the max backoff, the request isn't retried before the server allows it then.
+func (that RetryPolicy) backoff(attempt int, err error) (time.Duration, bool) {
+	delay := that.InitialBackoff << (attempt - 1)
+	if delay <= 0 || delay > that.MaxBackoff {
+		delay = that.MaxBackoff
+	}
+
+	if delay > 1 {
+		delay = delay/2 + rand.N(delay/2)
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+		if statusErr.RetryAfter > that.MaxBackoff {
+			return 0, false
+		}
+
+		delay = statusErr.RetryAfter
+	}
+
+	return delay, true
+}
+
+// parseRetryAfter parses the Retry-After header in the seconds or HTTP date format.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
@@ -0,0 +1,35 @@
+package telegram
+
+import (
+	"context"
+	"html"
+	"log/slog"
+)
+
+// AdminNotifier sends operational messages to the bot administrators.
+type AdminNotifier struct {
+	logger      *slog.Logger
+	interaction *Interaction
+	adminIDs    []int64
+}
+
+// NewAdminNotifier creates a new notifier for the given admin chat IDs.
+func NewAdminNotifier(logger *slog.Logger, interaction *Interaction, adminIDs []int64) *AdminNotifier {
+	return &AdminNotifier{logger: logger.With("component", "admin_notifier"), interaction: interaction, adminIDs: adminIDs}
+}
+
+// NotifyAdmins sends the plain text message to all the admins.
+func (that *AdminNotifier) NotifyAdmins(ctx context.Context, text string) {
+	log := that.logger.With("method", "NotifyAdmins")
+
+	if len(that.adminIDs) == 0 {
+		log.Warn("no admins to notify", "text", text)
+		return
+	}
+
+	for _, adminID := range that.adminIDs {
+		if err := that.interaction.SendMessage(ctx, adminID, html.EscapeString(text)); err != nil {
+			log.Error("failed to notify admin", "error", err, "chat_id", adminID)
+		}
+	}
+}
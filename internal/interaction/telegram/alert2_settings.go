@@ -0,0 +1,199 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tg "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"goldie/internal/model"
+)
+
+// alert2LevelDraft waits for the target gain or the stop-loss of the alert2 subscription typed by the user.
+type alert2LevelDraft struct {
+	subscriptionID int64
+	crossing       string // target, stop
+	page           int    // the settings page to return to
+}
+
+// handlerAlert2SettingsCallback handles the notification settings of the alert2 subscription, it returns the callback answer.
+// The data is a2:<action>:<subscription id>:<settings page>.
+func (that *Interaction) handlerAlert2SettingsCallback(ctx context.Context, bot *tg.Bot, chatID int64, messageID int, languageCode string, data string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(data, "a2:"), ":")
+	if len(parts) != 3 {
+		return "", nil
+	}
+
+	subscriptionID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", nil
+	}
+
+	page, err := strconv.Atoi(parts[2])
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	alert, err := that.chatsRepository.GetAlert2Subscription(ctx, chatID, subscriptionID)
+	if err != nil {
+		return "", fmt.Errorf("get alert2 subscription: %w", err)
+	}
+
+	if alert == nil {
+		return "", that.sendSettingsPage(ctx, bot, chatID, languageCode, page, messageID)
+	}
+
+	switch parts[0] {
+	case "show":
+		return "", that.sendAlert2Settings(ctx, bot, chatID, languageCode, messageID, alert, page)
+	case "target", "stop":
+		crossing := model.Alert2CrossingTarget
+		if parts[0] == "stop" {
+			crossing = model.Alert2CrossingStop
+		}
+
+		that.drafts.set(chatID, &alert2LevelDraft{subscriptionID: alert.ID, crossing: crossing, page: page})
+
+		text, renderErr := that.renderLocaledMessage(languageCode, "settingsAlert2Enter."+parts[0])
+		if renderErr != nil {
+			return "", renderErr
+		}
+
+		return "", that.editSettingsMessage(ctx, bot, chatID, messageID, text, nil)
+	case "mode":
+		mode := model.Alert2ModeCrossing
+		if alert.GetMode() == model.Alert2ModeCrossing {
+			mode = model.Alert2ModeDaily
+		}
+
+		if err = that.chatsRepository.SetAlert2Mode(ctx, chatID, alert.ID, mode); err != nil {
+			return "", fmt.Errorf("set alert2 mode: %w", err)
+		}
+
+		alert.Mode = mode
+	case "clear":
+		if err = that.chatsRepository.SetAlert2Thresholds(ctx, chatID, alert.ID, nil, nil); err != nil {
+			return "", fmt.Errorf("clear alert2 thresholds: %w", err)
+		}
+
+		alert.TargetGain, alert.StopLoss = nil, nil
+	default:
+		return "", nil
+	}
+
+	if err = that.sendAlert2Settings(ctx, bot, chatID, languageCode, messageID, alert, page); err != nil {
+		return "", err
+	}
+
+	callbackText, _ := that.renderLocaledMessage(languageCode, "settingsSaved")
+	return callbackText, nil
+}
+
+// matchAlert2Level matches the plain text messages of the chats which are setting the level of the alert2 subscription.
+func (that *Interaction) matchAlert2Level(update *models.Update) bool {
+	if update.Message == nil || update.Message.Text == "" || strings.HasPrefix(update.Message.Text, "/") {
+		return false
+	}
+
+	_, ok := that.drafts.get(update.Message.Chat.ID).(*alert2LevelDraft)
+	return ok
+}
+
+func (that *Interaction) handlerAlert2Level(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerAlert2Level", "user_id", update.Message.From.ID)
+
+	chatID := update.Message.Chat.ID
+	draft, ok := that.drafts.get(chatID).(*alert2LevelDraft)
+	if !ok {
+		return
+	}
+
+	// The stop-loss can't be more than the whole price of the bars
+	percent, err := parsePositiveNumber(strings.TrimSuffix(strings.TrimSpace(update.Message.Text), "%"))
+	if err != nil || (draft.crossing == model.Alert2CrossingStop && percent >= 100) {
+		if _, err = that.sendLocaledMessage(ctx, bot, update, "settingsAlert2InvalidPercent"); err != nil {
+			log.Error("failed to send invalid percent message", "error", err)
+		}
+		return
+	}
+
+	alert, err := that.chatsRepository.GetAlert2Subscription(ctx, chatID, draft.subscriptionID)
+	if err != nil {
+		log.Error("failed to get alert2 subscription", "error", err)
+		return
+	}
+
+	that.drafts.delete(chatID)
+
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+	if alert == nil {
+		if err = that.sendSettingsPage(ctx, bot, chatID, languageCode, draft.page, 0); err != nil {
+			log.Error("failed to send settings page", "error", err)
+		}
+		return
+	}
+
+	if draft.crossing == model.Alert2CrossingTarget {
+		alert.TargetGain = &percent
+	} else {
+		alert.StopLoss = &percent
+	}
+
+	if err = that.chatsRepository.SetAlert2Thresholds(ctx, chatID, alert.ID, alert.TargetGain, alert.StopLoss); err != nil {
+		log.Error("failed to set alert2 thresholds", "error", err)
+		return
+	}
+
+	if err = that.sendAlert2Settings(ctx, bot, chatID, languageCode, 0, alert, draft.page); err != nil {
+		log.Error("failed to send alert2 settings", "error", err)
+	}
+}
+
+// sendAlert2Settings shows the notification settings of the alert2 subscription.
+func (that *Interaction) sendAlert2Settings(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, alert *model.TgChatAlert2, page int) error {
+	notSet, _ := that.renderLocaledMessage(languageCode, "settingsNotSet")
+	target, stop := notSet, notSet
+	if alert.TargetGain != nil {
+		target = "+" + strconv.FormatFloat(*alert.TargetGain, 'f', -1, 64) + "%"
+	}
+	if alert.StopLoss != nil {
+		stop = "-" + strconv.FormatFloat(*alert.StopLoss, 'f', -1, 64) + "%"
+	}
+
+	mode, _ := that.renderLocaledMessage(languageCode, "alert2Mode."+alert.GetMode())
+
+	text, err := that.renderLocaledMessage(languageCode, "settingsAlert2Details",
+		"Date", alert.PurchaseDate.Format("2006-01-02"),
+		"Mode", mode,
+		"Target", target,
+		"Stop", stop)
+	if err != nil {
+		return err
+	}
+
+	callbackData := func(action string) string {
+		return fmt.Sprintf("%sa2:%s:%d:%d", settingsCallbackPrefix, action, alert.ID, page)
+	}
+
+	modeLabel, _ := that.renderLocaledMessage(languageCode, "settingsAlert2ModeButton", "Mode", mode)
+	targetLabel, _ := that.renderLocaledMessage(languageCode, "settingsAlert2TargetButton")
+	stopLabel, _ := that.renderLocaledMessage(languageCode, "settingsAlert2StopButton")
+	backLabel, _ := that.renderLocaledMessage(languageCode, "settingsBackButton")
+
+	rows := [][]models.InlineKeyboardButton{
+		{{Text: modeLabel, CallbackData: callbackData("mode")}},
+		{{Text: targetLabel, CallbackData: callbackData("target")}, {Text: stopLabel, CallbackData: callbackData("stop")}},
+	}
+
+	if alert.TargetGain != nil || alert.StopLoss != nil {
+		clearLabel, _ := that.renderLocaledMessage(languageCode, "settingsAlert2ClearButton")
+		rows = append(rows, []models.InlineKeyboardButton{{Text: clearLabel, CallbackData: callbackData("clear")}})
+	}
+
+	rows = append(rows, []models.InlineKeyboardButton{{Text: backLabel, CallbackData: fmt.Sprintf("%spage:%d", settingsCallbackPrefix, page)}})
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
@@ -18,18 +18,59 @@ var ErrWrongNumberOfLocalizedArguments = fmt.Errorf("wrong number of localized a
 
 type SelectedDateCallback func(ctx context.Context, b *tg.Bot, languageCode string, callBackQueryID string, chatID int64, messageID int, date time.Time)
 
+// SendMessageFunc sends the message of the calendar, e.g. through the throttled outbox.
+type SendMessageFunc func(ctx context.Context, b *tg.Bot, params *tg.SendMessageParams) (*models.Message, error)
+
+// EditMessageTextFunc edits the message of the calendar, e.g. through the throttled outbox.
+type EditMessageTextFunc func(ctx context.Context, b *tg.Bot, params *tg.EditMessageTextParams) (*models.Message, error)
+
+// Option configures the calendar.
+type Option func(*Calendar)
+
+// WithPrefix sets the prefix of the callback data, so several calendars can be handled separately.
+// The prefix must end with a colon.
+func WithPrefix(prefix string) Option {
+	return func(c *Calendar) {
+		c.prefix = prefix
+	}
+}
+
+// WithSender sets the functions the calendar messages are sent and edited by, the bot is called directly without it.
+func WithSender(sendMessage SendMessageFunc, editMessageText EditMessageTextFunc) Option {
+	return func(c *Calendar) {
+		c.sendMessage = sendMessage
+		c.editMessageText = editMessageText
+	}
+}
+
 type Calendar struct {
+	prefix               string
 	disableDays          []time.Weekday
 	selectedDateCallback SelectedDateCallback
 	bundle               *i18n.Bundle
+	sendMessage          SendMessageFunc
+	editMessageText      EditMessageTextFunc
 }
 
-func New(disableDays []time.Weekday, selectedDateHandler SelectedDateCallback, bundle *i18n.Bundle) *Calendar {
-	return &Calendar{
+func New(disableDays []time.Weekday, selectedDateHandler SelectedDateCallback, bundle *i18n.Bundle, opts ...Option) *Calendar {
+	c := &Calendar{
+		prefix:               Prefix,
 		disableDays:          disableDays,
 		selectedDateCallback: selectedDateHandler,
 		bundle:               bundle,
+		sendMessage: func(ctx context.Context, b *tg.Bot, params *tg.SendMessageParams) (*models.Message, error) {
+			return b.SendMessage(ctx, params)
+		},
+		editMessageText: func(ctx context.Context, b *tg.Bot, params *tg.EditMessageTextParams) (*models.Message, error) {
+			return b.EditMessageText(ctx, params)
+		},
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 func (c *Calendar) SendCalendar(ctx context.Context, b *tg.Bot, languageCode string, chatID int64, dateStart time.Time, dateEnd time.Time) error {
@@ -48,20 +89,20 @@ func (c *Calendar) HandleCallback(ctx context.Context, b *tg.Bot, languageCode s
 	}()
 
 	data := update.CallbackQuery.Data
-	if !strings.HasPrefix(data, Prefix) {
+	if !strings.HasPrefix(data, c.prefix) {
 		return fmt.Errorf("invalid callback data")
 	}
 
-	parts := strings.Split(data, ":")
-	if len(parts) < 3 {
+	parts := strings.Split(strings.TrimPrefix(data, c.prefix), ":")
+	if len(parts) < 2 {
 		return nil
 	}
 
 	chatID := update.CallbackQuery.Message.Message.Chat.ID
 	messageID := update.CallbackQuery.Message.Message.ID
 
-	action := parts[1]
-	arg := parts[2]
+	action := parts[0]
+	arg := parts[1]
 
 	switch action {
 	case "back":
@@ -113,7 +154,7 @@ func (c *Calendar) sendYearPicker(ctx context.Context, b *tg.Bot, languageCode s
 	for _, y := range years {
 		row = append(row, models.InlineKeyboardButton{
 			Text:         strconv.Itoa(y),
-			CallbackData: fmt.Sprintf("cal:year:%d", y),
+			CallbackData: fmt.Sprintf("%syear:%d", c.prefix, y),
 		})
 
 		if len(row) == maxPerRow {
@@ -132,7 +173,7 @@ func (c *Calendar) sendYearPicker(ctx context.Context, b *tg.Bot, languageCode s
 		return c.editMessage(ctx, b, chatID, messageID, text, markup)
 	}
 
-	if _, err = b.SendMessage(ctx, &tg.SendMessageParams{ChatID: chatID, Text: text, ReplyMarkup: markup}); err != nil {
+	if _, err = c.sendMessage(ctx, b, &tg.SendMessageParams{ChatID: chatID, Text: text, ReplyMarkup: markup}); err != nil {
 		return fmt.Errorf("send message: %w", err)
 	}
 
@@ -162,11 +203,11 @@ func (c *Calendar) sendMonthPicker(ctx context.Context, b *tg.Bot, languageCode
 	localizer := i18n.NewLocalizer(c.bundle, languageCode)
 
 	for i, name := range months {
-		btn := models.InlineKeyboardButton{Text: "⛔", CallbackData: "cal:noop"}
+		btn := models.InlineKeyboardButton{Text: "⛔", CallbackData: c.prefix + "noop"}
 
 		if i+1 >= startMonth && i+1 <= endMonth {
 			btn.Text, _ = localizer.Localize(&i18n.LocalizeConfig{MessageID: "month." + name})
-			btn.CallbackData = fmt.Sprintf("cal:month:%04d-%02d", selectedYear, i+1)
+			btn.CallbackData = fmt.Sprintf("%smonth:%04d-%02d", c.prefix, selectedYear, i+1)
 		}
 
 		row = append(row, btn)
@@ -182,7 +223,7 @@ func (c *Calendar) sendMonthPicker(ctx context.Context, b *tg.Bot, languageCode
 	// add "back to years" button
 	backTxt, _ := i18n.NewLocalizer(c.bundle, languageCode).Localize(&i18n.LocalizeConfig{MessageID: "chooseMonth.prev"})
 	rows = append(rows, []models.InlineKeyboardButton{
-		{Text: backTxt, CallbackData: fmt.Sprintf("cal:back:year")},
+		{Text: backTxt, CallbackData: c.prefix + "back:year"},
 	})
 
 	markup := &models.InlineKeyboardMarkup{InlineKeyboard: rows}
@@ -209,7 +250,7 @@ func (c *Calendar) sendDayPicker(ctx context.Context, b *tg.Bot, languageCode st
 	var header []models.InlineKeyboardButton
 	for _, d := range daysOfWeek {
 		txt, _ := i18n.NewLocalizer(c.bundle, languageCode).Localize(&i18n.LocalizeConfig{MessageID: "day." + d})
-		header = append(header, models.InlineKeyboardButton{Text: txt, CallbackData: "cal:noop"})
+		header = append(header, models.InlineKeyboardButton{Text: txt, CallbackData: c.prefix + "noop"})
 	}
 	rows = append(rows, header)
 
@@ -223,7 +264,7 @@ func (c *Calendar) sendDayPicker(ctx context.Context, b *tg.Bot, languageCode st
 	// fill empty slots until Monday
 	var row []models.InlineKeyboardButton
 	for i := 1; i < startWeekday; i++ {
-		row = append(row, models.InlineKeyboardButton{Text: " ", CallbackData: "cal:noop"})
+		row = append(row, models.InlineKeyboardButton{Text: " ", CallbackData: c.prefix + "noop"})
 	}
 
 	// add days of the month
@@ -232,12 +273,12 @@ func (c *Calendar) sendDayPicker(ctx context.Context, b *tg.Bot, languageCode st
 		weekday := date.Weekday()
 
 		btnText := fmt.Sprintf("%2d", d)
-		callbackData := fmt.Sprintf("cal:day:%04d-%02d-%02d", selectedYear, selectedMonth, d)
+		callbackData := fmt.Sprintf("%sday:%04d-%02d-%02d", c.prefix, selectedYear, selectedMonth, d)
 
 		for _, disabledDay := range c.disableDays {
 			if disabledDay == weekday {
 				btnText = "🚫"
-				callbackData = "cal:noop"
+				callbackData = c.prefix + "noop"
 				break
 			}
 		}
@@ -245,7 +286,7 @@ func (c *Calendar) sendDayPicker(ctx context.Context, b *tg.Bot, languageCode st
 		// disable days outside the date range
 		if date.Before(dateStart) || date.After(dateEnd) {
 			btnText = "⛔"
-			callbackData = "cal:noop"
+			callbackData = c.prefix + "noop"
 		}
 
 		row = append(row, models.InlineKeyboardButton{Text: btnText, CallbackData: callbackData})
@@ -258,7 +299,7 @@ func (c *Calendar) sendDayPicker(ctx context.Context, b *tg.Bot, languageCode st
 	// if there are less than 7 days, fill empty slots
 	if len(row) > 0 {
 		for len(row) < 7 {
-			row = append(row, models.InlineKeyboardButton{Text: " ", CallbackData: "cal:noop"})
+			row = append(row, models.InlineKeyboardButton{Text: " ", CallbackData: c.prefix + "noop"})
 		}
 		rows = append(rows, row)
 	}
@@ -266,7 +307,7 @@ func (c *Calendar) sendDayPicker(ctx context.Context, b *tg.Bot, languageCode st
 	// add "back to months" button
 	txt, _ := i18n.NewLocalizer(c.bundle, languageCode).Localize(&i18n.LocalizeConfig{MessageID: "chooseDay.prev"})
 	rows = append(rows, []models.InlineKeyboardButton{
-		{Text: txt, CallbackData: fmt.Sprintf("cal:year:%d", selectedYear)},
+		{Text: txt, CallbackData: fmt.Sprintf("%syear:%d", c.prefix, selectedYear)},
 	})
 
 	markup := &models.InlineKeyboardMarkup{InlineKeyboard: rows}
@@ -278,7 +319,7 @@ func (c *Calendar) sendDayPicker(ctx context.Context, b *tg.Bot, languageCode st
 }
 
 func (c *Calendar) editMessage(ctx context.Context, b *tg.Bot, chatID int64, messageID int, text string, markup *models.InlineKeyboardMarkup) error {
-	_, err := b.EditMessageText(ctx, &tg.EditMessageTextParams{ChatID: chatID, MessageID: messageID, Text: text, ParseMode: models.ParseModeHTML, ReplyMarkup: markup})
+	_, err := c.editMessageText(ctx, b, &tg.EditMessageTextParams{ChatID: chatID, MessageID: messageID, Text: text, ParseMode: models.ParseModeHTML, ReplyMarkup: markup})
 	if err != nil {
 		return fmt.Errorf("edit message: %w", err)
 	}
@@ -0,0 +1,58 @@
+package chart
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Cache keeps the panels of the latest price date and revision, so the repeated charts are only stacked and encoded.
+type Cache struct {
+	mu       sync.Mutex
+	date     time.Time
+	revision time.Time
+	panels   map[string]*Panel
+}
+
+func NewCache() *Cache {
+	return &Cache{panels: make(map[string]*Panel)}
+}
+
+// Panel returns the cached panel of the weight over the period which ends with the date or the drawn one.
+// The revision is the time the prices were changed last, so the revised prices of the date are drawn again.
+// The nil panel of the weight without prices is cached too. The panels are dropped once the later date or
+// revision is requested, the earlier ones aren't cached.
+func (that *Cache) Panel(weight float64, period string, date, revision time.Time, draw func() (*Panel, error)) (*Panel, error) {
+	key := fmt.Sprintf("%g:%s", weight, period)
+
+	that.mu.Lock()
+	if date.After(that.date) || revision.After(that.revision) {
+		that.date = date
+		that.revision = revision
+		that.panels = make(map[string]*Panel)
+	}
+
+	if panel, ok := that.panels[key]; ok && that.isCurrent(date, revision) {
+		that.mu.Unlock()
+		return panel, nil
+	}
+	that.mu.Unlock()
+
+	panel, err := draw()
+	if err != nil {
+		return nil, err
+	}
+
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	if that.isCurrent(date, revision) {
+		that.panels[key] = panel
+	}
+
+	return panel, nil
+}
+
+func (that *Cache) isCurrent(date, revision time.Time) bool {
+	return date.Equal(that.date) && revision.Equal(that.revision)
+}
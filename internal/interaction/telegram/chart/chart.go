@@ -0,0 +1,213 @@
+// Package chart draws the price charts as PNG images without any external services.
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"time"
+
+	"goldie/internal/model"
+)
+
+const (
+	Width       = 800
+	PanelHeight = 280
+
+	marginLeft   = 110 // the price labels
+	marginRight  = 20
+	marginTop    = 36 // the weight label
+	marginBottom = 34 // the date labels
+
+	// gridLines is the number of the intervals between the horizontal grid lines.
+	gridLines = 4
+	// markDash is the length of the dashes and the gaps of the date marks in pixels.
+	markDash = 6
+)
+
+var (
+	backgroundColor = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	gridColor       = color.RGBA{R: 228, G: 228, B: 228, A: 255}
+	axisColor       = color.RGBA{R: 110, G: 110, B: 110, A: 255}
+	SellColor       = color.RGBA{R: 212, G: 160, B: 23, A: 255}
+	PurchaseColor   = color.RGBA{R: 70, G: 130, B: 180, A: 255}
+	MarkColor       = color.RGBA{R: 220, G: 50, B: 47, A: 255}
+)
+
+// Panel is the chart of the sell and the buyback prices of one weight over the date range.
+type Panel struct {
+	image *image.RGBA
+	plot  image.Rectangle // the area of the lines
+	begin time.Time
+	end   time.Time
+}
+
+// NewPanel draws the prices of one weight ordered by date over the date range, both dates are included.
+func NewPanel(weight float64, prices []*model.GoldPrice, begin, end time.Time) *Panel {
+	panel := &Panel{
+		image: image.NewRGBA(image.Rect(0, 0, Width, PanelHeight)),
+		plot:  image.Rect(marginLeft, marginTop, Width-marginRight, PanelHeight-marginBottom),
+		begin: begin,
+		end:   end,
+	}
+
+	draw.Draw(panel.image, panel.image.Bounds(), &image.Uniform{C: backgroundColor}, image.Point{}, draw.Src)
+	drawText(panel.image, marginLeft, (marginTop-glyphHeight*glyphScale)/2, strconv.FormatFloat(weight, 'g', -1, 64)+"g", axisColor)
+
+	low, high := priceRange(prices)
+	y := func(value float64) int {
+		return panel.plot.Max.Y - int((value-low)/(high-low)*float64(panel.plot.Dy()))
+	}
+
+	for i := 0; i <= gridLines; i++ {
+		value := low + (high-low)*float64(i)/gridLines
+		lineY := y(value)
+
+		for x := panel.plot.Min.X; x < panel.plot.Max.X; x++ {
+			panel.image.Set(x, lineY, gridColor)
+		}
+
+		label := strconv.FormatFloat(value, 'f', 0, 64)
+		drawText(panel.image, marginLeft-8-textWidth(label), lineY-glyphHeight*glyphScale/2, label, axisColor)
+	}
+
+	for x := panel.plot.Min.X; x < panel.plot.Max.X; x++ {
+		panel.image.Set(x, panel.plot.Max.Y, axisColor)
+	}
+	for lineY := panel.plot.Min.Y; lineY <= panel.plot.Max.Y; lineY++ {
+		panel.image.Set(panel.plot.Min.X, lineY, axisColor)
+	}
+
+	dateY := panel.plot.Max.Y + (marginBottom-glyphHeight*glyphScale)/2
+	beginLabel, endLabel := begin.Format("2006-01-02"), end.Format("2006-01-02")
+	drawText(panel.image, panel.plot.Min.X, dateY, beginLabel, axisColor)
+	drawText(panel.image, panel.plot.Max.X-textWidth(endLabel), dateY, endLabel, axisColor)
+
+	// The sell price is drawn over the buyback one
+	for _, line := range []struct {
+		value func(price *model.GoldPrice) float64
+		color color.Color
+	}{
+		{func(price *model.GoldPrice) float64 { return price.PurchasePrice }, PurchaseColor},
+		{func(price *model.GoldPrice) float64 { return price.SellPrice }, SellColor},
+	} {
+		for i, price := range prices {
+			from := image.Pt(panel.x(price.Date), y(line.value(price)))
+			to := from
+			if i > 0 {
+				to = image.Pt(panel.x(prices[i-1].Date), y(line.value(prices[i-1])))
+			}
+
+			drawLine(panel.image, from, to, line.color)
+		}
+	}
+
+	return panel
+}
+
+// x returns the horizontal position of the date.
+func (that *Panel) x(date time.Time) int {
+	if !that.end.After(that.begin) {
+		return that.plot.Min.X + that.plot.Dx()/2
+	}
+
+	return that.plot.Min.X + int(float64(that.plot.Dx())*float64(date.Sub(that.begin))/float64(that.end.Sub(that.begin)))
+}
+
+// Render stacks the panels and marks the dates within their ranges with the dashed lines, it returns the PNG image.
+func Render(panels []*Panel, marks []time.Time) ([]byte, error) {
+	if len(panels) == 0 {
+		return nil, errors.New("no panels to render")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, Width, PanelHeight*len(panels)))
+
+	for i, panel := range panels {
+		offset := PanelHeight * i
+		draw.Draw(img, image.Rect(0, offset, Width, offset+PanelHeight), panel.image, image.Point{}, draw.Src)
+
+		for _, mark := range marks {
+			if mark.Before(panel.begin) || mark.After(panel.end) {
+				continue
+			}
+
+			x := panel.x(mark)
+			for y := panel.plot.Min.Y; y < panel.plot.Max.Y; y++ {
+				if (y-panel.plot.Min.Y)/markDash%2 == 0 {
+					img.Set(x, offset+y, MarkColor)
+					img.Set(x+1, offset+y, MarkColor)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// priceRange returns the lowest and the highest of the buyback and the sell prices with a margin around them.
+func priceRange(prices []*model.GoldPrice) (float64, float64) {
+	if len(prices) == 0 {
+		return 0, 1
+	}
+
+	low, high := prices[0].PurchasePrice, prices[0].SellPrice
+	for _, price := range prices {
+		low = min(low, price.PurchasePrice, price.SellPrice)
+		high = max(high, price.PurchasePrice, price.SellPrice)
+	}
+
+	margin := (high - low) * 0.05
+	if margin == 0 {
+		margin = 1
+	}
+
+	return low - margin, high + margin
+}
+
+// drawLine draws the line between the points two pixels thick.
+func drawLine(img *image.RGBA, from, to image.Point, c color.Color) {
+	dx, dy := abs(to.X-from.X), -abs(to.Y-from.Y)
+	sx, sy := 1, 1
+	if from.X > to.X {
+		sx = -1
+	}
+	if from.Y > to.Y {
+		sy = -1
+	}
+
+	x, y, e := from.X, from.Y, dx+dy
+	for {
+		img.Set(x, y, c)
+		img.Set(x, y+1, c)
+
+		if x == to.X && y == to.Y {
+			return
+		}
+
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x += sx
+		} else {
+			e += dx
+			y += sy
+		}
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
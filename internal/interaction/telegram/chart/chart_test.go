@@ -0,0 +1,95 @@
+package chart_test
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/interaction/telegram/chart"
+	"goldie/internal/model"
+)
+
+func Test_Render(t *testing.T) {
+	begin := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := begin.AddDate(0, 0, 2)
+	prices := []*model.GoldPrice{
+		{Date: begin, Weight: 1, PurchasePrice: 9000, SellPrice: 10000},
+		{Date: begin.AddDate(0, 0, 1), Weight: 1, PurchasePrice: 9500, SellPrice: 10500},
+		{Date: end, Weight: 1, PurchasePrice: 9200, SellPrice: 10200},
+	}
+
+	t.Run("should stack the panels and draw the lines and the marks", func(t *testing.T) {
+		panels := []*chart.Panel{chart.NewPanel(1, prices, begin, end), chart.NewPanel(5, prices, begin, end)}
+
+		data, err := chart.Render(panels, []time.Time{begin.AddDate(0, 0, 1), begin.AddDate(-1, 0, 0)})
+		require.NoError(t, err)
+
+		img, err := png.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		require.Equal(t, chart.Width, img.Bounds().Dx())
+		require.Equal(t, chart.PanelHeight*2, img.Bounds().Dy())
+
+		colors := map[any]bool{}
+		for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+			for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+				colors[img.At(x, y)] = true
+			}
+		}
+
+		require.True(t, colors[chart.SellColor])
+		require.True(t, colors[chart.PurchaseColor])
+		require.True(t, colors[chart.MarkColor])
+	})
+
+	t.Run("should fail without panels", func(t *testing.T) {
+		_, err := chart.Render(nil, nil)
+		require.Error(t, err)
+	})
+}
+
+func Test_Cache_Panel(t *testing.T) {
+	cache := chart.NewCache()
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	draws := 0
+	draw := func() (*chart.Panel, error) {
+		draws++
+		return chart.NewPanel(1, nil, date, date), nil
+	}
+
+	revision := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	first, err := cache.Panel(1, model.HistoryPeriodMonth, date, revision, draw)
+	require.NoError(t, err)
+
+	second, err := cache.Panel(1, model.HistoryPeriodMonth, date, revision, draw)
+	require.NoError(t, err)
+	require.Same(t, first, second)
+	require.Equal(t, 1, draws)
+
+	// The other period and the later date are drawn again, the earlier date isn't cached
+	_, err = cache.Panel(1, model.HistoryPeriodYear, date, revision, draw)
+	require.NoError(t, err)
+	require.Equal(t, 2, draws)
+
+	_, err = cache.Panel(1, model.HistoryPeriodMonth, date.AddDate(0, 0, 1), revision, draw)
+	require.NoError(t, err)
+	require.Equal(t, 3, draws)
+
+	_, err = cache.Panel(1, model.HistoryPeriodMonth, date, revision, draw)
+	require.NoError(t, err)
+	_, err = cache.Panel(1, model.HistoryPeriodMonth, date, revision, draw)
+	require.NoError(t, err)
+	require.Equal(t, 5, draws)
+
+	// The revised prices of the date are drawn again and cached
+	revised := revision.Add(time.Hour)
+	_, err = cache.Panel(1, model.HistoryPeriodMonth, date.AddDate(0, 0, 1), revised, draw)
+	require.NoError(t, err)
+	_, err = cache.Panel(1, model.HistoryPeriodMonth, date.AddDate(0, 0, 1), revised, draw)
+	require.NoError(t, err)
+	require.Equal(t, 6, draws)
+}
@@ -0,0 +1,60 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+)
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+	// glyphScale enlarges the glyphs to be readable on the phone screens.
+	glyphScale = 2
+	// glyphAdvance is the distance between the starts of two characters in pixels.
+	glyphAdvance = (glyphWidth + 1) * glyphScale
+)
+
+// glyphs are the bitmaps of the characters of the axis labels, every row is the 5 bits from the left to the right.
+// The other characters are drawn as spaces.
+var glyphs = map[rune][glyphHeight]uint8{
+	'0': {0b01110, 0b10001, 0b10011, 0b10101, 0b11001, 0b10001, 0b01110},
+	'1': {0b00100, 0b01100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'2': {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0b01000, 0b11111},
+	'3': {0b11111, 0b00010, 0b00100, 0b00010, 0b00001, 0b10001, 0b01110},
+	'4': {0b00010, 0b00110, 0b01010, 0b10010, 0b11111, 0b00010, 0b00010},
+	'5': {0b11111, 0b10000, 0b11110, 0b00001, 0b00001, 0b10001, 0b01110},
+	'6': {0b00110, 0b01000, 0b10000, 0b11110, 0b10001, 0b10001, 0b01110},
+	'7': {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b01000, 0b01000},
+	'8': {0b01110, 0b10001, 0b10001, 0b01110, 0b10001, 0b10001, 0b01110},
+	'9': {0b01110, 0b10001, 0b10001, 0b01111, 0b00001, 0b00010, 0b01100},
+	'-': {0b00000, 0b00000, 0b00000, 0b11111, 0b00000, 0b00000, 0b00000},
+	'.': {0b00000, 0b00000, 0b00000, 0b00000, 0b00000, 0b01100, 0b01100},
+	'g': {0b00000, 0b00000, 0b01111, 0b10001, 0b01111, 0b00001, 0b01110},
+}
+
+// drawText draws the text with its top left corner at the point.
+func drawText(img *image.RGBA, x, y int, text string, c color.Color) {
+	for _, char := range text {
+		glyph := glyphs[char]
+		for row := 0; row < glyphHeight; row++ {
+			for col := 0; col < glyphWidth; col++ {
+				if glyph[row]&(1<<(glyphWidth-1-col)) == 0 {
+					continue
+				}
+
+				for dy := 0; dy < glyphScale; dy++ {
+					for dx := 0; dx < glyphScale; dx++ {
+						img.Set(x+col*glyphScale+dx, y+row*glyphScale+dy, c)
+					}
+				}
+			}
+		}
+
+		x += glyphAdvance
+	}
+}
+
+// textWidth returns the width of the text in pixels.
+func textWidth(text string) int {
+	return len([]rune(text))*glyphAdvance - glyphScale
+}
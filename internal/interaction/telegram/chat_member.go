@@ -0,0 +1,100 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tg "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"goldie/internal/model"
+)
+
+func matchMyChatMember(update *models.Update) bool {
+	return update.MyChatMember != nil
+}
+
+// handlerMyChatMember deactivates the chat as soon as the bot is blocked or removed from the group
+// and reactivates it when the bot is added back.
+func (that *Interaction) handlerMyChatMember(ctx context.Context, _ *tg.Bot, update *models.Update) {
+	member := update.MyChatMember
+	log := that.logger.With("method", "handlerMyChatMember", "chat_id", member.Chat.ID, "status", member.NewChatMember.Type)
+
+	var err error
+
+	switch member.NewChatMember.Type {
+	case models.ChatMemberTypeBanned:
+		reason := model.ChatDeactivatedKicked
+		if member.Chat.Type == models.ChatTypePrivate {
+			reason = model.ChatDeactivatedBlocked
+		}
+
+		log.Info("bot is removed from the chat")
+		err = that.chatsRepository.DeactivateChat(ctx, member.Chat.ID, reason)
+	case models.ChatMemberTypeLeft:
+		log.Info("bot left the chat")
+		err = that.chatsRepository.DeactivateChat(ctx, member.Chat.ID, model.ChatDeactivatedLeft)
+	case models.ChatMemberTypeMember, models.ChatMemberTypeAdministrator:
+		err = that.chatsRepository.ReactivateChat(ctx, member.Chat.ID)
+	}
+
+	if err != nil {
+		log.Error("failed to update chat state", "error", err)
+	}
+}
+
+func matchChatMigration(update *models.Update) bool {
+	return update.Message != nil && (update.Message.MigrateToChatID != 0 || update.Message.MigrateFromChatID != 0)
+}
+
+// handlerChatMigration moves the chat to the new ID when the group is upgraded to a supergroup.
+// Both the old group and the new supergroup get the service message, the second one finds nothing to move.
+func (that *Interaction) handlerChatMigration(ctx context.Context, _ *tg.Bot, update *models.Update) {
+	fromChatID, toChatID := update.Message.Chat.ID, update.Message.MigrateToChatID
+	if update.Message.MigrateFromChatID != 0 {
+		fromChatID, toChatID = update.Message.MigrateFromChatID, update.Message.Chat.ID
+	}
+
+	_ = that.migrateChat(ctx, fromChatID, toChatID)
+}
+
+// migrateChat moves the settings and the alerts of the upgraded group to its supergroup.
+func (that *Interaction) migrateChat(ctx context.Context, fromChatID, toChatID int64) error {
+	log := that.logger.With("method", "migrateChat", "from_chat_id", fromChatID, "to_chat_id", toChatID)
+
+	if err := that.chatsRepository.MigrateChat(ctx, fromChatID, toChatID); err != nil {
+		log.Error("failed to migrate chat", "error", err)
+		return fmt.Errorf("migrate chat: %w", err)
+	}
+
+	log.Info("chat is migrated to the supergroup")
+	return nil
+}
+
+// unavailableReason returns the reason to deactivate the chat if the error means the messages can't be delivered
+// to it anymore, otherwise it returns an empty string.
+func unavailableReason(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	description := strings.ToLower(err.Error())
+
+	switch {
+	case errors.Is(err, tg.ErrorForbidden):
+		switch {
+		case strings.Contains(description, "user is deactivated"):
+			return model.ChatDeactivatedDeleted
+		case strings.Contains(description, "kicked"), strings.Contains(description, "not a member"):
+			return model.ChatDeactivatedKicked
+		default:
+			return model.ChatDeactivatedBlocked
+		}
+	case errors.Is(err, tg.ErrorBadRequest) && strings.Contains(description, "chat not found"):
+		return model.ChatDeactivatedNotFound
+	default:
+		return ""
+	}
+}
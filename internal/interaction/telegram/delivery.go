@@ -0,0 +1,196 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tg "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"goldie/internal/model"
+)
+
+const deliveryTimeButtonsPerRow = 6
+
+// deliveryHours are the hours offered for the daily alerts.
+var deliveryHours = []int{6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23}
+
+// deliveryTimezones are the time zones offered in the settings, they cover the users we know about.
+var deliveryTimezones = []string{
+	"Asia/Bishkek", "Asia/Almaty", "Asia/Tashkent", "Asia/Dubai",
+	"Europe/Moscow", "Europe/Istanbul", "Europe/Berlin", "Europe/London",
+	"America/New_York", "America/Chicago", "America/Los_Angeles", "Asia/Seoul",
+}
+
+// getChatPreferences returns the chat to show its preferences, the unknown chat has the default ones.
+func (that *Interaction) getChatPreferences(ctx context.Context, chatID int64) *model.TgChat {
+	chat, err := that.chatsRepository.GetChat(ctx, chatID)
+	if err != nil {
+		that.logger.Error("failed to get chat", "error", err, "chat_id", chatID)
+	}
+
+	if chat == nil {
+		return &model.TgChat{}
+	}
+
+	return chat
+}
+
+// deliveryTimeArgs returns the template data with the delivery time and the timezone of the chat.
+func (that *Interaction) deliveryTimeArgs(ctx context.Context, chatID int64) []string {
+	chat := that.getChatPreferences(ctx, chatID)
+	return []string{"Time", chat.GetDeliveryTime(), "Timezone", chat.GetTimezone()}
+}
+
+// handlerPreferencesCallback handles the preferences part of the settings, it returns the callback answer.
+func (that *Interaction) handlerPreferencesCallback(ctx context.Context, bot *tg.Bot, chatID int64, messageID int, languageCode string, data string) (string, error) {
+	switch {
+	case data == "prefs":
+		return "", that.sendPreferences(ctx, bot, chatID, messageID, languageCode)
+	case data == "time":
+		return "", that.sendDeliveryTimeOptions(ctx, bot, chatID, messageID, languageCode)
+	case data == "tz":
+		return "", that.sendTimezoneOptions(ctx, bot, chatID, messageID, languageCode)
+	case strings.HasPrefix(data, "time:"):
+		deliveryTime, err := time.Parse("15:04", strings.TrimPrefix(data, "time:"))
+		if err != nil {
+			return "", nil
+		}
+
+		if err = that.chatsRepository.SetDeliveryTime(ctx, chatID, deliveryTime.Format("15:04")); err != nil {
+			return "", fmt.Errorf("set delivery time: %w", err)
+		}
+	case strings.HasPrefix(data, "tz:"):
+		timezone := strings.TrimPrefix(data, "tz:")
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return "", nil
+		}
+
+		if err := that.chatsRepository.SetTimezone(ctx, chatID, timezone); err != nil {
+			return "", fmt.Errorf("set timezone: %w", err)
+		}
+	case data == "digest:"+model.DigestWeekly || data == "digest:"+model.DigestMonthly:
+		kind := strings.TrimPrefix(data, "digest:")
+
+		chat := that.getChatPreferences(ctx, chatID)
+		enabled := chat.WeeklyDigest
+		if kind == model.DigestMonthly {
+			enabled = chat.MonthlyDigest
+		}
+
+		if err := that.chatsRepository.SetDigest(ctx, chatID, kind, !enabled); err != nil {
+			return "", fmt.Errorf("set digest: %w", err)
+		}
+	case data == "digestchart":
+		if err := that.chatsRepository.SetDigestChart(ctx, chatID, !that.getChatPreferences(ctx, chatID).DigestChart); err != nil {
+			return "", fmt.Errorf("set digest chart: %w", err)
+		}
+	case data == "gain":
+		view := model.GainViewMarket
+		if that.getChatPreferences(ctx, chatID).GetGainView() == model.GainViewMarket {
+			view = model.GainViewRealizable
+		}
+
+		if err := that.chatsRepository.SetGainView(ctx, chatID, view); err != nil {
+			return "", fmt.Errorf("set gain view: %w", err)
+		}
+	default:
+		return "", nil
+	}
+
+	if err := that.sendPreferences(ctx, bot, chatID, messageID, languageCode); err != nil {
+		return "", err
+	}
+
+	callbackText, _ := that.renderLocaledMessage(languageCode, "settingsSaved")
+	return callbackText, nil
+}
+
+// sendPreferences shows the alert preferences of the chat in place of the settings message.
+func (that *Interaction) sendPreferences(ctx context.Context, bot *tg.Bot, chatID int64, messageID int, languageCode string) error {
+	chat := that.getChatPreferences(ctx, chatID)
+
+	text, err := that.renderLocaledMessage(languageCode, "settingsPreferencesTitle", "Time", chat.GetDeliveryTime(), "Timezone", chat.GetTimezone())
+	if err != nil {
+		return err
+	}
+
+	timeLabel, _ := that.renderLocaledMessage(languageCode, "settingsDeliveryTimeButton")
+	timezoneLabel, _ := that.renderLocaledMessage(languageCode, "settingsTimezoneButton")
+	weeklyLabel, _ := that.renderLocaledMessage(languageCode, "settingsWeeklyDigestButton", "State", that.renderToggleState(languageCode, chat.WeeklyDigest))
+	digestChartLabel, _ := that.renderLocaledMessage(languageCode, "settingsDigestChartButton", "State", that.renderToggleState(languageCode, chat.DigestChart))
+	monthlyLabel, _ := that.renderLocaledMessage(languageCode, "settingsMonthlyDigestButton", "State", that.renderToggleState(languageCode, chat.MonthlyDigest))
+	gainView, _ := that.renderLocaledMessage(languageCode, "gainView."+chat.GetGainView())
+	gainViewLabel, _ := that.renderLocaledMessage(languageCode, "settingsGainViewButton", "View", gainView)
+	backLabel, _ := that.renderLocaledMessage(languageCode, "settingsBackButton")
+
+	keyboard := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{
+			{Text: timeLabel, CallbackData: settingsCallbackPrefix + "time"},
+			{Text: timezoneLabel, CallbackData: settingsCallbackPrefix + "tz"},
+		},
+		{{Text: weeklyLabel, CallbackData: settingsCallbackPrefix + "digest:" + model.DigestWeekly}},
+		{{Text: digestChartLabel, CallbackData: settingsCallbackPrefix + "digestchart"}},
+		{{Text: monthlyLabel, CallbackData: settingsCallbackPrefix + "digest:" + model.DigestMonthly}},
+		{{Text: gainViewLabel, CallbackData: settingsCallbackPrefix + "gain"}},
+		{{Text: backLabel, CallbackData: settingsCallbackPrefix + "page:1"}},
+	}}
+
+	return that.editSettingsMessage(ctx, bot, chatID, messageID, text, keyboard)
+}
+
+func (that *Interaction) sendDeliveryTimeOptions(ctx context.Context, bot *tg.Bot, chatID int64, messageID int, languageCode string) error {
+	text, err := that.renderLocaledMessage(languageCode, "settingsDeliveryTimeTitle", that.deliveryTimeArgs(ctx, chatID)...)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]models.InlineKeyboardButton
+	for i, hour := range deliveryHours {
+		if i%deliveryTimeButtonsPerRow == 0 {
+			rows = append(rows, make([]models.InlineKeyboardButton, 0, deliveryTimeButtonsPerRow))
+		}
+
+		deliveryTime := fmt.Sprintf("%02d:00", hour)
+		rows[len(rows)-1] = append(rows[len(rows)-1], models.InlineKeyboardButton{Text: deliveryTime, CallbackData: settingsCallbackPrefix + "time:" + deliveryTime})
+	}
+
+	return that.editSettingsMessage(ctx, bot, chatID, messageID, text, &models.InlineKeyboardMarkup{InlineKeyboard: append(rows, that.preferencesBackRow(languageCode))})
+}
+
+func (that *Interaction) sendTimezoneOptions(ctx context.Context, bot *tg.Bot, chatID int64, messageID int, languageCode string) error {
+	text, err := that.renderLocaledMessage(languageCode, "settingsTimezoneTitle")
+	if err != nil {
+		return err
+	}
+
+	var rows [][]models.InlineKeyboardButton
+	for i := 0; i < len(deliveryTimezones); i += 2 {
+		row := make([]models.InlineKeyboardButton, 0, 2)
+		for _, timezone := range deliveryTimezones[i:min(i+2, len(deliveryTimezones))] {
+			row = append(row, models.InlineKeyboardButton{Text: timezone, CallbackData: settingsCallbackPrefix + "tz:" + timezone})
+		}
+
+		rows = append(rows, row)
+	}
+
+	return that.editSettingsMessage(ctx, bot, chatID, messageID, text, &models.InlineKeyboardMarkup{InlineKeyboard: append(rows, that.preferencesBackRow(languageCode))})
+}
+
+// renderToggleState returns the localized state of the preference which can be turned on and off.
+func (that *Interaction) renderToggleState(languageCode string, enabled bool) string {
+	messageID := "settingsDisabled"
+	if enabled {
+		messageID = "settingsEnabled"
+	}
+
+	state, _ := that.renderLocaledMessage(languageCode, messageID)
+	return state
+}
+
+func (that *Interaction) preferencesBackRow(languageCode string) []models.InlineKeyboardButton {
+	backLabel, _ := that.renderLocaledMessage(languageCode, "settingsBackButton")
+	return []models.InlineKeyboardButton{{Text: backLabel, CallbackData: settingsCallbackPrefix + "prefs"}}
+}
@@ -0,0 +1,61 @@
+package telegram
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// chatDrafts keeps the alerts and the holdings waiting for the value typed by the user, at most one per chat.
+type chatDrafts struct {
+	mu     sync.Mutex
+	drafts map[int64]any
+}
+
+func (that *chatDrafts) set(chatID int64, draft any) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	if that.drafts == nil {
+		that.drafts = make(map[int64]any)
+	}
+
+	that.drafts[chatID] = draft
+}
+
+func (that *chatDrafts) get(chatID int64) any {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	return that.drafts[chatID]
+}
+
+func (that *chatDrafts) delete(chatID int64) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	delete(that.drafts, chatID)
+}
+
+// parsePositiveNumber parses the number typed by the user, e.g. "120 000" or "120000,50".
+func parsePositiveNumber(text string) (float64, error) {
+	text = strings.NewReplacer(" ", "", " ", "", ",", ".").Replace(strings.TrimSpace(text))
+
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse number: %w", err)
+	}
+
+	// "NaN" and "Inf" are parsed by strconv too
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("number must be finite")
+	}
+
+	if number <= 0 {
+		return 0, fmt.Errorf("number must be positive")
+	}
+
+	return number, nil
+}
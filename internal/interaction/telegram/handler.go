@@ -3,6 +3,7 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -19,6 +20,11 @@ const settingsAlert2PageSize = 10
 func (that *Interaction) handlerStart(ctx context.Context, bot *tg.Bot, update *models.Update) {
 	log := that.logger.With("method", "handlerStart", "user_id", update.Message.From.ID, "language", update.Message.From.LanguageCode)
 
+	// The user is back, so the alerts are delivered again
+	if err := that.chatsRepository.ReactivateChat(ctx, update.Message.Chat.ID); err != nil {
+		log.Error("failed to reactivate chat", "error", err)
+	}
+
 	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
 
 	startText, err := that.renderLocaledMessage(languageCode, "startWelcomeMessage")
@@ -32,7 +38,7 @@ func (that *Interaction) handlerStart(ctx context.Context, bot *tg.Bot, update *
 		{Text: "🇬🇧 English", CallbackData: languageCallbackPrefix + "en"},
 	}}}
 
-	if _, err = bot.SendMessage(ctx, &tg.SendMessageParams{ChatID: update.Message.Chat.ID, Text: startText, ReplyMarkup: replyMarkup}); err != nil {
+	if _, err = that.sendMessage(ctx, bot, &tg.SendMessageParams{ChatID: update.Message.Chat.ID, Text: startText, ReplyMarkup: replyMarkup}); err != nil {
 		log.Error("failed to send message", "error", err)
 		return
 	}
@@ -70,7 +76,7 @@ func (that *Interaction) handlerLanguageSelection(ctx context.Context, bot *tg.B
 	helpText, err := that.renderLocaledMessage(languageCode, "helpMessage")
 	if err != nil {
 		log.Error("failed to render start message", "error", err)
-	} else if _, err = bot.EditMessageText(ctx, &tg.EditMessageTextParams{ChatID: chatID, MessageID: messageID, Text: helpText}); err != nil {
+	} else if _, err = that.editMessageText(ctx, bot, &tg.EditMessageTextParams{ChatID: chatID, MessageID: messageID, Text: helpText}); err != nil {
 		log.Error("failed to edit language selection message", "error", err)
 	}
 
@@ -99,13 +105,88 @@ func (that *Interaction) handlerPrice(ctx context.Context, bot *tg.Bot, update *
 
 	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
 
-	text := that.PricesToString(languageCode, prices)
-	if _, err = bot.SendMessage(ctx, &tg.SendMessageParams{ChatID: update.Message.Chat.ID, Text: text, ParseMode: models.ParseModeHTML}); err != nil {
+	rate, err := that.getCurrencyRate(ctx, update.Message.Chat.ID)
+	if err != nil {
+		log.Error("failed to get currency rate", "error", err)
+	}
+
+	text := that.PricesToString(languageCode, prices, rate)
+	if _, err = that.sendMessage(ctx, bot, &tg.SendMessageParams{ChatID: update.Message.Chat.ID, Text: text, ParseMode: models.ParseModeHTML}); err != nil {
 		log.Error("error sending message", "error", err)
 		return
 	}
 }
 
+// getCurrencyRate returns the latest exchange rate of the chat display currency or nil for KGS.
+func (that *Interaction) getCurrencyRate(ctx context.Context, chatID int64) (*model.CurrencyRate, error) {
+	chat, err := that.chatsRepository.GetChat(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("get chat: %w", err)
+	}
+
+	if chat == nil || chat.GetCurrency() == model.CurrencyKGS {
+		return nil, nil
+	}
+
+	return that.pricesRepository.GetLatestCurrencyRate(ctx, chat.GetCurrency())
+}
+
+func (that *Interaction) handlerCurrency(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerCurrency", "user_id", update.Message.From.ID)
+
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+
+	text, err := that.renderLocaledMessage(languageCode, "chooseCurrencyMessage")
+	if err != nil {
+		log.Error("failed to render currency message", "error", err)
+		return
+	}
+
+	row := make([]models.InlineKeyboardButton, 0, len(model.DisplayCurrencies))
+	for _, currency := range model.DisplayCurrencies {
+		row = append(row, models.InlineKeyboardButton{Text: currency, CallbackData: currencyCallbackPrefix + currency})
+	}
+
+	replyMarkup := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{row}}
+	if _, err = that.sendMessage(ctx, bot, &tg.SendMessageParams{ChatID: update.Message.Chat.ID, Text: text, ReplyMarkup: replyMarkup}); err != nil {
+		log.Error("failed to send message", "error", err)
+		return
+	}
+}
+
+func (that *Interaction) handlerCurrencySelection(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerCurrencySelection")
+
+	if update.CallbackQuery == nil || update.CallbackQuery.Message.Message == nil {
+		return
+	}
+
+	currency := strings.TrimPrefix(update.CallbackQuery.Data, currencyCallbackPrefix)
+	if !slices.Contains(model.DisplayCurrencies, currency) {
+		log.Warn("unsupported currency selected", "currency", currency)
+		return
+	}
+
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	messageID := update.CallbackQuery.Message.Message.ID
+	languageCode := that.getLanguageCode(ctx, update.CallbackQuery.Message.Message.Chat, update.CallbackQuery.Message.Message.From)
+
+	if err := that.chatsRepository.SetCurrency(ctx, chatID, currency); err != nil {
+		log.Error("failed to set chat currency", "error", err, "chat_id", chatID)
+	}
+
+	text, err := that.renderLocaledMessage(languageCode, "currencySelectedMessage", "Currency", currency)
+	if err != nil {
+		log.Error("failed to render currency selected message", "error", err)
+	} else if _, err = that.editMessageText(ctx, bot, &tg.EditMessageTextParams{ChatID: chatID, MessageID: messageID, Text: text}); err != nil {
+		log.Error("failed to edit currency selection message", "error", err)
+	}
+
+	if _, err = bot.AnswerCallbackQuery(ctx, &tg.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID}); err != nil {
+		log.Error("failed to answer callback query", "error", err)
+	}
+}
+
 func (that *Interaction) handlerAlert(ctx context.Context, bot *tg.Bot, update *models.Update) {
 	log := that.logger.With("method", "handlerAlert", "user_id", update.Message.From.ID)
 
@@ -124,7 +205,7 @@ func (that *Interaction) handlerAlert1(ctx context.Context, bot *tg.Bot, update
 		return
 	}
 
-	if _, err := that.sendLocaledMessage(ctx, bot, update, "createAlert1Message"); err != nil {
+	if _, err := that.sendLocaledMessage(ctx, bot, update, "createAlert1Message", that.deliveryTimeArgs(ctx, update.Message.Chat.ID)...); err != nil {
 		log.Error("error sending message", "error", err)
 		return
 	}
@@ -179,13 +260,13 @@ func (that *Interaction) handlerAlert2SelectedDate(ctx context.Context, bot *tg.
 		return
 	}
 
-	text, err := that.renderLocaledMessage(languageCode, "createAlert2Message")
+	text, err := that.renderLocaledMessage(languageCode, "createAlert2Message", that.deliveryTimeArgs(ctx, chatID)...)
 	if err != nil {
 		log.Error("failed to get localized text", "error", err)
 		return
 	}
 
-	if _, err = bot.EditMessageText(ctx, &tg.EditMessageTextParams{ChatID: chatID, MessageID: messageID, Text: text}); err != nil {
+	if _, err = that.editMessageText(ctx, bot, &tg.EditMessageTextParams{ChatID: chatID, MessageID: messageID, Text: text}); err != nil {
 		log.Error("failed to edit selected date message", "error", err)
 		return
 	}
@@ -250,7 +331,7 @@ func (that *Interaction) handlerInfo(ctx context.Context, bot *tg.Bot, update *m
 		return
 	}
 
-	if _, err = bot.SendMessage(ctx, &tg.SendMessageParams{ChatID: update.Message.Chat.ID, Text: text}); err != nil {
+	if _, err = that.sendMessage(ctx, bot, &tg.SendMessageParams{ChatID: update.Message.Chat.ID, Text: text}); err != nil {
 		log.Error("failed to send info message", "error", err)
 		return
 	}
@@ -272,7 +353,7 @@ func (that *Interaction) handlerDelete(ctx context.Context, bot *tg.Bot, update
 		return
 	}
 
-	if _, err = bot.SendMessage(ctx, &tg.SendMessageParams{ChatID: update.Message.Chat.ID, Text: text}); err != nil {
+	if _, err = that.sendMessage(ctx, bot, &tg.SendMessageParams{ChatID: update.Message.Chat.ID, Text: text}); err != nil {
 		log.Error("failed to send delete message", "error", err)
 		return
 	}
@@ -373,8 +454,10 @@ func (that *Interaction) handlerSettingsCallback(ctx context.Context, bot *tg.Bo
 				callbackText, _ = that.renderLocaledMessage(languageCode, "settingsAlert2DeleteSuccess")
 			}
 		}
+	case strings.HasPrefix(data, "a2:"):
+		callbackText, err = that.handlerAlert2SettingsCallback(ctx, bot, chatID, messageID, languageCode, data)
 	default:
-		return
+		callbackText, err = that.handlerPreferencesCallback(ctx, bot, chatID, messageID, languageCode, data)
 	}
 
 	if err != nil {
@@ -409,24 +492,33 @@ func (that *Interaction) sendSettingsPage(ctx context.Context, bot *tg.Bot, chat
 		}
 	}
 
-	if messageID == 0 {
-		params := &tg.SendMessageParams{ChatID: chatID, Text: text}
-		if keyboard != nil {
-			params.ReplyMarkup = keyboard
-		}
+	// The preferences of the alerts are available under the list
+	preferencesLabel, err := that.renderLocaledMessage(languageCode, "settingsPreferencesButton")
+	if err != nil {
+		return err
+	}
+
+	if keyboard == nil {
+		keyboard = &models.InlineKeyboardMarkup{}
+	}
+
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
+		{Text: preferencesLabel, CallbackData: settingsCallbackPrefix + "prefs"},
+	})
 
-		if _, err = bot.SendMessage(ctx, params); err != nil {
+	if messageID == 0 {
+		params := &tg.SendMessageParams{ChatID: chatID, Text: text, ReplyMarkup: keyboard}
+		if _, err = that.sendMessage(ctx, bot, params); err != nil {
 			return fmt.Errorf("send settings message: %w", err)
 		}
 		return nil
 	}
 
-	editParams := &tg.EditMessageTextParams{ChatID: chatID, MessageID: messageID, Text: text}
-	if keyboard != nil {
-		editParams.ReplyMarkup = keyboard
-	}
+	return that.editSettingsMessage(ctx, bot, chatID, messageID, text, keyboard)
+}
 
-	if _, err = bot.EditMessageText(ctx, editParams); err != nil {
+func (that *Interaction) editSettingsMessage(ctx context.Context, bot *tg.Bot, chatID int64, messageID int, text string, keyboard *models.InlineKeyboardMarkup) error {
+	if _, err := that.editMessageText(ctx, bot, &tg.EditMessageTextParams{ChatID: chatID, MessageID: messageID, Text: text, ReplyMarkup: keyboard}); err != nil {
 		return fmt.Errorf("edit settings message: %w", err)
 	}
 
@@ -499,13 +591,22 @@ func (that *Interaction) buildSettingsAlert2Keyboard(languageCode string, alerts
 		return nil, err
 	}
 
+	notificationsLabel, err := that.renderLocaledMessage(languageCode, "settingsAlert2NotificationsButton")
+	if err != nil {
+		return nil, err
+	}
+
 	rows := make([][]models.InlineKeyboardButton, 0, len(alerts)+1)
 	for _, alert := range alerts {
 		btn := models.InlineKeyboardButton{
 			Text:         fmt.Sprintf("%s %s", deleteLabel, alert.PurchaseDate.Format("2006-01-02")),
 			CallbackData: fmt.Sprintf("%sdel:%d:%d", settingsCallbackPrefix, alert.ID, currentPage),
 		}
-		rows = append(rows, []models.InlineKeyboardButton{btn})
+		notificationsBtn := models.InlineKeyboardButton{
+			Text:         notificationsLabel,
+			CallbackData: fmt.Sprintf("%sa2:show:%d:%d", settingsCallbackPrefix, alert.ID, currentPage),
+		}
+		rows = append(rows, []models.InlineKeyboardButton{btn, notificationsBtn})
 	}
 
 	if totalPages > 1 {
@@ -133,7 +133,7 @@ func Test_HandlerAlert1(t *testing.T) {
 
 			// Then: The user should receive the alert1 message
 			require.Equal(t, strconv.FormatInt(newChatID, 10), formData["chat_id"])
-			require.Equal(t, "Done. I'll send you an alert about the gold selling price every day at 10:00 AM (UTC +6)", formData["text"])
+			require.Equal(t, "Done. I'll send you an alert about the gold selling price every day at 10:00 (Asia/Bishkek), you can change the time in /settings", formData["text"])
 			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
 		})
 
@@ -161,7 +161,7 @@ func Test_HandlerAlert1(t *testing.T) {
 
 			// Then: The user should receive the alert1 message
 			require.Equal(t, strconv.FormatInt(dbChat.SourceID, 10), formData["chat_id"])
-			require.Equal(t, "Done. I'll send you an alert about the gold selling price every day at 10:00 AM (UTC +6)", formData["text"])
+			require.Equal(t, "Done. I'll send you an alert about the gold selling price every day at 10:00 (Asia/Bishkek), you can change the time in /settings", formData["text"])
 			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
 		})
 
@@ -197,6 +197,8 @@ func Test_HandlerStop(t *testing.T) {
 		dbChat := &model.TgChat{SourceID: 50, Alert1Enabled: true}
 		require.NoError(t, st.GetDB().WithContext(ctx).Create(dbChat).Error)
 		require.NoError(t, st.GetDB().WithContext(ctx).Create(&model.TgChatAlert2{ChatID: dbChat.ID, PurchaseDate: suite.GetDateTime(t, "2024-09-01")}).Error)
+		require.NoError(t, chatsRepository.CreateSignal(ctx, dbChat.SourceID, &model.TgChatSignal{Weight: 1, Kind: model.SignalKindSMA, Period: 3}))
+		require.NoError(t, st.GetDB().WithContext(ctx).Create(&model.TgChatHolding{ChatID: dbChat.ID, Weight: 10, Quantity: 1, PurchaseDate: suite.GetDateTime(t, "2024-09-01")}).Error)
 
 		mockedHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(request *http.Request) (*http.Response, error) {
 			formData := suite.ParseRequestBody(t, request)
@@ -221,6 +223,15 @@ func Test_HandlerStop(t *testing.T) {
 		var alert2Count int64
 		require.NoError(t, st.GetDB().WithContext(ctx).Model(&model.TgChatAlert2{}).Where("chat_id = ?", updatedChat.ID).Count(&alert2Count).Error)
 		require.EqualValues(t, 0, alert2Count)
+
+		// Then: The holdings and the signals should be kept
+		holdings, err := chatsRepository.ListHoldings(ctx, dbChat.SourceID)
+		require.NoError(t, err)
+		require.Len(t, holdings, 1)
+
+		signals, err := chatsRepository.ListSignals(ctx, dbChat.SourceID)
+		require.NoError(t, err)
+		require.Len(t, signals, 1)
 	})
 
 	t.Run("should create chat if missing and reply in russian", func(t *testing.T) {
@@ -365,7 +376,11 @@ func Test_HandlerSettings(t *testing.T) {
 			require.Contains(t, request.URL.Path, "sendMessage")
 			require.Equal(t, strconv.FormatInt(sourceIDWithoutAlerts, 10), formData["chat_id"])
 			require.Equal(t, "You don't have alert2 subscriptions yet.", formData["text"])
-			require.Empty(t, formData["reply_markup"])
+
+			var markup models.InlineKeyboardMarkup
+			require.NoError(t, json.Unmarshal([]byte(formData["reply_markup"]), &markup))
+			require.Equal(t, 1, len(markup.InlineKeyboard))
+			require.Equal(t, "⚙️ Alert preferences", markup.InlineKeyboard[0][0].Text)
 			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
 		})
 
@@ -390,8 +405,8 @@ func Test_HandlerSettings(t *testing.T) {
 
 			var markup models.InlineKeyboardMarkup
 			require.NoError(t, json.Unmarshal([]byte(formData["reply_markup"]), &markup))
-			require.Equal(t, 11, len(markup.InlineKeyboard))
-			require.Equal(t, "Next »", markup.InlineKeyboard[len(markup.InlineKeyboard)-1][0].Text)
+			require.Equal(t, 12, len(markup.InlineKeyboard))
+			require.Equal(t, "Next »", markup.InlineKeyboard[len(markup.InlineKeyboard)-2][0].Text)
 			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true,"result":{"message_id":1}}`))}, nil
 		})
 
@@ -417,8 +432,8 @@ func Test_HandlerSettings(t *testing.T) {
 
 				var markup models.InlineKeyboardMarkup
 				require.NoError(t, json.Unmarshal([]byte(formData["reply_markup"]), &markup))
-				require.Equal(t, 2, len(markup.InlineKeyboard))
-				require.Equal(t, "« Prev", markup.InlineKeyboard[len(markup.InlineKeyboard)-1][0].Text)
+				require.Equal(t, 3, len(markup.InlineKeyboard))
+				require.Equal(t, "« Prev", markup.InlineKeyboard[len(markup.InlineKeyboard)-2][0].Text)
 			case strings.Contains(request.URL.Path, "answerCallbackQuery"):
 				// Then: The callback query should be answered
 				require.Equal(t, "callback-id", formData["callback_query_id"])
@@ -452,7 +467,7 @@ func Test_HandlerSettings(t *testing.T) {
 
 				var markup models.InlineKeyboardMarkup
 				require.NoError(t, json.Unmarshal([]byte(formData["reply_markup"]), &markup))
-				require.Equal(t, 10, len(markup.InlineKeyboard))
+				require.Equal(t, 11, len(markup.InlineKeyboard))
 			case strings.Contains(request.URL.Path, "answerCallbackQuery"):
 				// Then: The callback query should be answered
 				require.Equal(t, "callback-id", formData["callback_query_id"])
@@ -474,6 +489,42 @@ func Test_HandlerSettings(t *testing.T) {
 		require.NoError(t, st.GetDB().WithContext(ctx).Model(&model.TgChatAlert2{}).Where("chat_id = ?", dbTgChats[1].ID).Count(&count).Error)
 		require.EqualValues(t, 10, count)
 	})
+
+	t.Run("should change the delivery time and the timezone - en", func(t *testing.T) {
+		interaction, mockedHTTPClient := newInteractionHandler()
+
+		var texts []string
+		mockedHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(request *http.Request) (*http.Response, error) {
+			formData := suite.ParseRequestBody(t, request)
+
+			switch {
+			case strings.Contains(request.URL.Path, "editMessageText"):
+				texts = append(texts, formData["text"])
+			case strings.Contains(request.URL.Path, "answerCallbackQuery"):
+			default:
+				t.Fatalf("unexpected telegram method: %s", request.URL.Path)
+			}
+
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
+		})
+
+		// When: We choose the delivery time and the timezone
+		interaction.TgBot.ProcessUpdate(ctx, newCallbackQuery(sourceIDWithoutAlerts, "en", testSettingsCallbackPrefix+"time:07:00"))
+		time.Sleep(time.Millisecond * 100)
+		interaction.TgBot.ProcessUpdate(ctx, newCallbackQuery(sourceIDWithoutAlerts, "en", testSettingsCallbackPrefix+"tz:Europe/Berlin"))
+		time.Sleep(time.Millisecond * 100)
+
+		// Then: The preferences should be saved and shown
+		chat, err := chatsRepository.GetChat(ctx, sourceIDWithoutAlerts)
+		require.NoError(t, err)
+		require.Equal(t, "07:00", chat.DeliveryTime)
+		require.Equal(t, "Europe/Berlin", chat.Timezone)
+
+		require.Equal(t, []string{
+			"Alert preferences:\nDaily alerts time: 07:00\nTime zone: Asia/Bishkek",
+			"Alert preferences:\nDaily alerts time: 07:00\nTime zone: Europe/Berlin",
+		}, texts)
+	})
 }
 
 func Test_HandlerDelete(t *testing.T) {
@@ -667,7 +718,7 @@ func Test_HandlerStart(t *testing.T) {
 			case strings.Contains(request.URL.Path, "editMessageText"):
 				// Then: The message should be updated with the help text
 				require.Equal(t, "1", formData["message_id"])
-				require.Equal(t, "Привет! Это Goldie, ваш помощник по покупке и продаже золотых слитков. Основная функция бота - следить за ценами на золото и рассчитывать, сколько вы заработаете, если продадите его сегодня.\n\nДоступные команды:\n/help - Информация о командах\n/start — Выберите язык\n/price — Показать текущую цену на золото\n/alert — Настроить новое оповещение\n/info - Информация о хранении данных пользователя\n/settings - Настройка оповещений\n/stop - Остановить бота", formData["text"])
+				require.Equal(t, "Привет! Это Goldie, ваш помощник по покупке и продаже золотых слитков. Основная функция бота - следить за ценами на золото и рассчитывать, сколько вы заработаете, если продадите его сегодня.\n\nДоступные команды:\n/help - Информация о командах\n/start — Выберите язык\n/price — Показать текущую цену на золото\n/history — Показать историю цен слитка\n/chart — Показать график цен слитков\n/currency — Выбрать валюту отображения\n/alert — Настроить новое оповещение\n/pricealert — Настроить оповещения о порогах цены\n/movealert — Настроить оповещения об изменении цены\n/signals — Настроить сигналы скользящих средних, максимумов и минимумов\n/portfolio — Управлять портфелем золотых слитков\n/pause — Приостановить все оповещения на время\n/info - Информация о хранении данных пользователя\n/settings - Настройка оповещений\n/stop - Остановить бота", formData["text"])
 			case strings.Contains(request.URL.Path, "answerCallbackQuery"):
 				// Then: The callback query should be answered
 				require.Equal(t, "callback-id", formData["callback_query_id"])
@@ -755,7 +806,7 @@ func Test_handlerAlert2SelectedDate(t *testing.T) {
 			case strings.Contains(request.URL.Path, "editMessageText"):
 				// Then: The message should be updated with the alert2 text
 				require.Equal(t, "1", formData["message_id"])
-				require.Equal(t, "Done. I'll send you an alert about how much I'll earn if you sell today at 10:00 AM (UTC +6)", formData["text"])
+				require.Equal(t, "Done. I'll send you an alert about how much I'll earn if you sell today at 10:00 (Asia/Bishkek), you can change the time in /settings", formData["text"])
 			case strings.Contains(request.URL.Path, "answerCallbackQuery"):
 				// Then: The callback query should be answered
 				require.Equal(t, "callback-id", formData["callback_query_id"])
@@ -791,7 +842,7 @@ func Test_handlerAlert2SelectedDate(t *testing.T) {
 			case strings.Contains(request.URL.Path, "editMessageText"):
 				// Then: The message should be updated with the alert2 text
 				require.Equal(t, "1", formData["message_id"])
-				require.Equal(t, "Готово. Буду слать уведомления о том, сколько ты заработаешь, если сегодня продашь в 10:00 AM (UTC +6)", formData["text"])
+				require.Equal(t, "Готово. Буду слать уведомления о том, сколько ты заработаешь, если сегодня продашь в 10:00 (Asia/Bishkek), время можно изменить в /settings", formData["text"])
 			case strings.Contains(request.URL.Path, "answerCallbackQuery"):
 				// Then: The callback query should be answered
 				require.Equal(t, "callback-id", formData["callback_query_id"])
@@ -831,7 +882,7 @@ func Test_handlerAlert2SelectedDate(t *testing.T) {
 			case strings.Contains(request.URL.Path, "editMessageText"):
 				// Then: The message should be updated with the alert2 text
 				require.Equal(t, "1", formData["message_id"])
-				require.Equal(t, "Done. I'll send you an alert about how much I'll earn if you sell today at 10:00 AM (UTC +6)", formData["text"])
+				require.Equal(t, "Done. I'll send you an alert about how much I'll earn if you sell today at 10:00 (Asia/Bishkek), you can change the time in /settings", formData["text"])
 			case strings.Contains(request.URL.Path, "answerCallbackQuery"):
 				// Then: The callback query should be answered
 				require.Equal(t, "callback-id", formData["callback_query_id"])
@@ -870,7 +921,7 @@ func Test_handlerAlert2SelectedDate(t *testing.T) {
 			case strings.Contains(request.URL.Path, "editMessageText"):
 				// Then: The message should be updated with the alert2 text
 				require.Equal(t, "1", formData["message_id"])
-				require.Equal(t, "Готово. Буду слать уведомления о том, сколько ты заработаешь, если сегодня продашь в 10:00 AM (UTC +6)", formData["text"])
+				require.Equal(t, "Готово. Буду слать уведомления о том, сколько ты заработаешь, если сегодня продашь в 10:00 (Asia/Bishkek), время можно изменить в /settings", formData["text"])
 			case strings.Contains(request.URL.Path, "answerCallbackQuery"):
 				// Then: The callback query should be answered
 				require.Equal(t, "callback-id", formData["callback_query_id"])
@@ -917,7 +968,7 @@ func Test_HandlerHelp(t *testing.T) {
 
 			// Then: The user should receive the help message
 			require.Equal(t, "1", formData["chat_id"])
-			require.Equal(t, "Hello. This is Goldie, your assistant for buying and selling gold bars. The bot's main function is to monitor gold prices and calculate how much you'll earn if you sell it today.\n\nAvailable commands:\n/help - Commands information\n/start - Choose your language\n/price - Show current gold price\n/alert - Configure your alerts\n/info - Information on storing user data\n/settings - Alerts setting\n/stop - Stop bot", formData["text"])
+			require.Equal(t, "Hello. This is Goldie, your assistant for buying and selling gold bars. The bot's main function is to monitor gold prices and calculate how much you'll earn if you sell it today.\n\nAvailable commands:\n/help - Commands information\n/start - Choose your language\n/price - Show current gold price\n/history - Show the price history of a weight\n/chart - Show the price chart of weights\n/currency - Choose display currency\n/alert - Configure your alerts\n/pricealert - Configure price threshold alerts\n/movealert - Configure price move alerts\n/signals - Configure moving average, high and low signals\n/portfolio - Manage your portfolio of gold bars\n/pause - Pause all alerts for a while\n/info - Information on storing user data\n/settings - Alerts setting\n/stop - Stop bot", formData["text"])
 			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
 		})
 
@@ -936,7 +987,7 @@ func Test_HandlerHelp(t *testing.T) {
 
 			// Then: The user should receive the help message
 			require.Equal(t, "1", formData["chat_id"])
-			require.Equal(t, "Привет! Это Goldie, ваш помощник по покупке и продаже золотых слитков. Основная функция бота - следить за ценами на золото и рассчитывать, сколько вы заработаете, если продадите его сегодня.\n\nДоступные команды:\n/help - Информация о командах\n/start — Выберите язык\n/price — Показать текущую цену на золото\n/alert — Настроить новое оповещение\n/info - Информация о хранении данных пользователя\n/settings - Настройка оповещений\n/stop - Остановить бота", formData["text"])
+			require.Equal(t, "Привет! Это Goldie, ваш помощник по покупке и продаже золотых слитков. Основная функция бота - следить за ценами на золото и рассчитывать, сколько вы заработаете, если продадите его сегодня.\n\nДоступные команды:\n/help - Информация о командах\n/start — Выберите язык\n/price — Показать текущую цену на золото\n/history — Показать историю цен слитка\n/chart — Показать график цен слитков\n/currency — Выбрать валюту отображения\n/alert — Настроить новое оповещение\n/pricealert — Настроить оповещения о порогах цены\n/movealert — Настроить оповещения об изменении цены\n/signals — Настроить сигналы скользящих средних, максимумов и минимумов\n/portfolio — Управлять портфелем золотых слитков\n/pause — Приостановить все оповещения на время\n/info - Информация о хранении данных пользователя\n/settings - Настройка оповещений\n/stop - Остановить бота", formData["text"])
 			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
 		})
 
@@ -947,3 +998,470 @@ func Test_HandlerHelp(t *testing.T) {
 		time.Sleep(time.Millisecond * 100)
 	})
 }
+
+func Test_HandlerQuarantine(t *testing.T) {
+	ctx, st := suite.New(t, suite.WithPostgres())
+
+	pricesRepository := prices.NewRepository(st.GetDB())
+	chatsRepository := chats.NewRepository(st.GetDB())
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	const adminID = 1
+
+	// Given: The quarantined price
+	quarantined := &model.QuarantinedPrice{
+		Date:          suite.GetDateTime(t, "2024-10-01"),
+		Weight:        10,
+		Source:        model.PriceSourceNBKR,
+		PurchasePrice: 885000,
+		SellPrice:     895000,
+		Reason:        "price moved 900.0% since 2024-09-30",
+		Status:        model.QuarantinePending,
+	}
+	require.NoError(t, st.GetDB().WithContext(ctx).Create(quarantined).Error)
+
+	newInteractionHandler := func() (*telegram.Interaction, *botMock.MockHttpClient) {
+		mockedHTTPClient := botMock.NewMockHttpClient(t)
+		return telegram.NewInteraction(st.Logger, "token", mockedHTTPClient, bundle, pricesRepository, chatsRepository,
+			telegram.WithAdmins([]int64{adminID}),
+			telegram.WithQuarantine(pricesRepository),
+		), mockedHTTPClient
+	}
+
+	t.Run("should ignore the command from a regular user", func(t *testing.T) {
+		interaction, _ := newInteractionHandler()
+
+		// When: A regular user sends the /quarantine command
+		interaction.TgBot.ProcessUpdate(ctx, newUpdate(2, "en", "/quarantine"))
+
+		// Then: Nothing should be sent, the mock fails on any request
+		time.Sleep(time.Millisecond * 100)
+	})
+
+	t.Run("should show the pending prices to the admin", func(t *testing.T) {
+		interaction, mockedHTTPClient := newInteractionHandler()
+
+		mockedHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(request *http.Request) (*http.Response, error) {
+			formData := suite.ParseRequestBody(t, request)
+
+			// Then: The admin should receive the pending prices with the review buttons
+			require.Equal(t, strconv.Itoa(adminID), formData["chat_id"])
+			require.Equal(t, fmt.Sprintf("Quarantined prices (1 pending):\n\n#%d nbkr 2024-10-01 10 g: purchase 885000.00, sell 895000.00\nprice moved 900.0%% since 2024-09-30", quarantined.ID), formData["text"])
+			require.Contains(t, formData["reply_markup"], fmt.Sprintf("quarantine:approve:%d", quarantined.ID))
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
+		})
+
+		// When: The admin sends the /quarantine command
+		interaction.TgBot.ProcessUpdate(ctx, newUpdate(adminID, "en", "/quarantine"))
+
+		// Wait for the handler to be executed
+		time.Sleep(time.Millisecond * 100)
+	})
+}
+
+func Test_HandlerPriceAlertCallback(t *testing.T) {
+	ctx, st := suite.New(t, suite.WithPostgres())
+
+	chatsRepository := chats.NewRepository(st.GetDB())
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	for _, data := range []string{"pa:w:heavy", "pa:c:10:sell:sideways", "pa:del:first", "pa:unknown"} {
+		t.Run("should answer the callback with the invalid data "+data, func(t *testing.T) {
+			mockedHTTPClient := botMock.NewMockHttpClient(t)
+			interaction := telegram.NewInteraction(st.Logger, "token", mockedHTTPClient, bundle, nil, chatsRepository)
+
+			answered := false
+			mockedHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(request *http.Request) (*http.Response, error) {
+				// Then: Only the callback query should be answered
+				require.Contains(t, request.URL.Path, "answerCallbackQuery")
+				answered = true
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
+			})
+
+			// When: The button with the invalid data is clicked
+			interaction.TgBot.ProcessUpdate(ctx, newCallbackQuery(1, "en", data))
+
+			// Wait for the handler to be executed
+			time.Sleep(time.Millisecond * 100)
+
+			require.True(t, answered)
+		})
+	}
+}
+
+func Test_HandlerPriceAlertThreshold(t *testing.T) {
+	ctx, st := suite.New(t, suite.WithPostgres())
+
+	chatsRepository := chats.NewRepository(st.GetDB())
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	for i, tc := range []struct {
+		text      string
+		threshold float64 // zero if the text is rejected
+	}{
+		{text: "120 000", threshold: 120000},
+		{text: "120000,50", threshold: 120000.5},
+		{text: "0"},
+		{text: "-5"},
+		{text: "abc"},
+		{text: "NaN"},
+		{text: "Inf"},
+		{text: "-Inf"},
+		{text: "1e400"},
+	} {
+		t.Run("should parse the threshold "+tc.text, func(t *testing.T) {
+			chatID := int64(40 + i)
+			mockedHTTPClient := botMock.NewMockHttpClient(t)
+			interaction := telegram.NewInteraction(st.Logger, "token", mockedHTTPClient, bundle, nil, chatsRepository)
+
+			var requests []map[string]string
+			mockedHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(request *http.Request) (*http.Response, error) {
+				requests = append(requests, suite.ParseRequestBody(t, request))
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
+			})
+
+			// When: The user picks the 10 g bar sell price below the threshold and types the threshold
+			for _, update := range []*models.Update{
+				newCallbackQuery(chatID, "en", "pa:c:10:sell:below"),
+				newUpdate(chatID, "en", tc.text),
+			} {
+				interaction.TgBot.ProcessUpdate(ctx, update)
+				time.Sleep(time.Millisecond * 100)
+			}
+
+			alerts, err := chatsRepository.ListPriceAlerts(ctx, chatID)
+			require.NoError(t, err)
+
+			if tc.threshold == 0 {
+				// Then: The number should be asked again without creating the alert
+				require.Equal(t, "Send me a positive number, e.g. 120000 or 120000.50.", requests[len(requests)-1]["text"])
+				require.Empty(t, alerts)
+				return
+			}
+
+			// Then: The alert should be created with the threshold
+			require.Len(t, alerts, 1)
+			require.Equal(t, tc.threshold, alerts[0].Threshold)
+		})
+	}
+}
+
+func Test_HandlerMyChatMember(t *testing.T) {
+	ctx, st := suite.New(t, suite.WithPostgres())
+
+	chatsRepository := chats.NewRepository(st.GetDB())
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	const chatID = 1
+
+	t.Run("should deactivate the chat which blocked the bot and reactivate it on /start", func(t *testing.T) {
+		mockedHTTPClient := botMock.NewMockHttpClient(t)
+		interaction := telegram.NewInteraction(st.Logger, "token", mockedHTTPClient, bundle, nil, chatsRepository)
+
+		// Given: The chat with alert1
+		require.NoError(t, chatsRepository.EnableAlert1(ctx, chatID))
+
+		// When: The user blocks the bot
+		interaction.TgBot.ProcessUpdate(ctx, &models.Update{MyChatMember: &models.ChatMemberUpdated{
+			Chat:          models.Chat{ID: chatID, Type: models.ChatTypePrivate},
+			NewChatMember: models.ChatMember{Type: models.ChatMemberTypeBanned, Banned: &models.ChatMemberBanned{}},
+		}})
+		time.Sleep(time.Millisecond * 100)
+
+		// Then: The chat should be deactivated
+		chat, err := chatsRepository.GetChat(ctx, chatID)
+		require.NoError(t, err)
+		require.NotNil(t, chat.DeactivatedAt)
+		require.Equal(t, model.ChatDeactivatedBlocked, chat.DeactivatedBy)
+
+		mockedHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(request *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
+		})
+
+		// When: The user writes /start again
+		interaction.TgBot.ProcessUpdate(ctx, newUpdate(chatID, "en", "/start"))
+		time.Sleep(time.Millisecond * 100)
+
+		// Then: The chat should be active
+		chat, err = chatsRepository.GetChat(ctx, chatID)
+		require.NoError(t, err)
+		require.Nil(t, chat.DeactivatedAt)
+		require.Empty(t, chat.DeactivatedBy)
+	})
+
+	t.Run("should deactivate the chat when the message is forbidden", func(t *testing.T) {
+		mockedHTTPClient := botMock.NewMockHttpClient(t)
+		interaction := telegram.NewInteraction(st.Logger, "token", mockedHTTPClient, bundle, nil, chatsRepository)
+
+		mockedHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(request *http.Request) (*http.Response, error) {
+			body := `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`
+			return &http.Response{StatusCode: 403, Body: io.NopCloser(strings.NewReader(body))}, nil
+		})
+
+		// When: We send the alert to the chat which blocked the bot
+		err := interaction.SendMessage(ctx, chatID, "alert")
+
+		// Then: The chat should be deactivated
+		require.ErrorIs(t, err, model.ErrChatUnavailable)
+
+		chat, err := chatsRepository.GetChat(ctx, chatID)
+		require.NoError(t, err)
+		require.Equal(t, model.ChatDeactivatedBlocked, chat.DeactivatedBy)
+	})
+}
+
+func Test_HandlerChatMigration(t *testing.T) {
+	ctx, st := suite.New(t, suite.WithPostgres())
+
+	chatsRepository := chats.NewRepository(st.GetDB())
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	const groupID, supergroupID = -1, -1001
+
+	t.Run("should move the alerts to the supergroup", func(t *testing.T) {
+		mockedHTTPClient := botMock.NewMockHttpClient(t)
+		interaction := telegram.NewInteraction(st.Logger, "token", mockedHTTPClient, bundle, nil, chatsRepository)
+
+		// Given: The group with alert1, alert2 and the language
+		require.NoError(t, chatsRepository.SetLanguage(ctx, groupID, "ru"))
+		require.NoError(t, chatsRepository.EnableAlert1(ctx, groupID))
+		require.NoError(t, chatsRepository.CreateAlert2Subscription(ctx, groupID, time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)))
+
+		// When: The group is upgraded to the supergroup
+		interaction.TgBot.ProcessUpdate(ctx, &models.Update{Message: &models.Message{
+			Chat:            models.Chat{ID: groupID, Type: models.ChatTypeGroup},
+			MigrateToChatID: supergroupID,
+		}})
+		time.Sleep(time.Millisecond * 100)
+
+		// Then: The supergroup should have the settings and the alerts of the group
+		chat, err := chatsRepository.GetChat(ctx, groupID)
+		require.NoError(t, err)
+		require.Nil(t, chat)
+
+		chat, err = chatsRepository.GetChat(ctx, supergroupID)
+		require.NoError(t, err)
+		require.NotNil(t, chat)
+		require.Equal(t, "ru", chat.Language)
+		require.True(t, chat.Alert1Enabled)
+
+		alerts, err := chatsRepository.ListAlert2Subscriptions(ctx, supergroupID)
+		require.NoError(t, err)
+		require.Len(t, alerts, 1)
+	})
+
+	t.Run("should merge the settings into the existing supergroup", func(t *testing.T) {
+		mockedHTTPClient := botMock.NewMockHttpClient(t)
+		interaction := telegram.NewInteraction(st.Logger, "token", mockedHTTPClient, bundle, nil, chatsRepository)
+
+		const fromID, toID = int64(-3), int64(-1003)
+		pausedUntil := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+		// Given: The group with the preferences and the pause
+		require.NoError(t, chatsRepository.SetTimezone(ctx, fromID, "Europe/Moscow"))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, fromID, "12:30"))
+		require.NoError(t, chatsRepository.SetDigest(ctx, fromID, model.DigestWeekly, true))
+		require.NoError(t, chatsRepository.SetDigest(ctx, fromID, model.DigestMonthly, true))
+		require.NoError(t, chatsRepository.SetDigestChart(ctx, fromID, true))
+		require.NoError(t, chatsRepository.SetGainView(ctx, fromID, model.GainViewMarket))
+		require.NoError(t, chatsRepository.PauseChat(ctx, fromID, pausedUntil))
+
+		// Given: The supergroup which already exists with its own timezone
+		require.NoError(t, chatsRepository.SetTimezone(ctx, toID, "Asia/Almaty"))
+
+		// When: The group is upgraded to the supergroup
+		interaction.TgBot.ProcessUpdate(ctx, &models.Update{Message: &models.Message{
+			Chat:            models.Chat{ID: fromID, Type: models.ChatTypeGroup},
+			MigrateToChatID: toID,
+		}})
+		time.Sleep(time.Millisecond * 100)
+
+		// Then: The supergroup should keep its timezone and get the other preferences of the group
+		chat, err := chatsRepository.GetChat(ctx, toID)
+		require.NoError(t, err)
+		require.NotNil(t, chat)
+		require.Equal(t, "Asia/Almaty", chat.Timezone)
+		require.Equal(t, "12:30", chat.DeliveryTime)
+		require.True(t, chat.WeeklyDigest)
+		require.True(t, chat.MonthlyDigest)
+		require.True(t, chat.DigestChart)
+		require.Equal(t, model.GainViewMarket, chat.GainView)
+		require.NotNil(t, chat.PausedAt)
+		require.NotNil(t, chat.PausedUntil)
+		require.True(t, pausedUntil.Equal(*chat.PausedUntil))
+	})
+
+	t.Run("should send the message to the supergroup when the group is migrated", func(t *testing.T) {
+		mockedHTTPClient := botMock.NewMockHttpClient(t)
+		interaction := telegram.NewInteraction(st.Logger, "token", mockedHTTPClient, bundle, nil, chatsRepository)
+
+		// Given: The group which isn't migrated yet
+		require.NoError(t, chatsRepository.EnableAlert1(ctx, groupID-1))
+
+		var chatIDs []string
+		mockedHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(request *http.Request) (*http.Response, error) {
+			formData := suite.ParseRequestBody(t, request)
+			chatIDs = append(chatIDs, formData["chat_id"])
+
+			if formData["chat_id"] == "-2" {
+				body := `{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-1002}}`
+				return &http.Response{StatusCode: 400, Body: io.NopCloser(strings.NewReader(body))}, nil
+			}
+
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
+		})
+
+		// When: We send the alert to the group
+		require.NoError(t, interaction.SendMessage(ctx, groupID-1, "alert"))
+
+		// Then: The alert should be sent to the supergroup and the chat should be migrated
+		require.Equal(t, []string{"-2", "-1002"}, chatIDs)
+
+		chat, err := chatsRepository.GetChat(ctx, -1002)
+		require.NoError(t, err)
+		require.NotNil(t, chat)
+		require.True(t, chat.Alert1Enabled)
+	})
+}
+
+func Test_HandlerPortfolio(t *testing.T) {
+	ctx, st := suite.New(t, suite.WithPostgres())
+
+	pricesRepository := prices.NewRepository(st.GetDB())
+	chatsRepository := chats.NewRepository(st.GetDB())
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	// Given: NBKR published the price of 1 g bar only
+	dbPrices := []*model.GoldPrice{{Date: suite.GetDateTime(t, "2024-10-01"), Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 12345, SellPrice: 12588}}
+	require.NoError(t, st.GetDB().WithContext(ctx).Create(&dbPrices).Error)
+
+	const chatID = 30
+
+	t.Run("shouldn't offer the NBKR price on the date without it", func(t *testing.T) {
+		mockedHTTPClient := botMock.NewMockHttpClient(t)
+		interaction := telegram.NewInteraction(st.Logger, "token", mockedHTTPClient, bundle, pricesRepository, chatsRepository)
+
+		var requests []map[string]string
+		mockedHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(request *http.Request) (*http.Response, error) {
+			requests = append(requests, suite.ParseRequestBody(t, request))
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
+		})
+
+		// When: The user adds two 10 g bars bought on the date without the price of the weight
+		for _, update := range []*models.Update{
+			newCallbackQuery(chatID, "en", "pf:w:10"),
+			newUpdate(chatID, "en", "2"),
+			newUpdate(chatID, "en", "2024-10-01"),
+		} {
+			interaction.TgBot.ProcessUpdate(ctx, update)
+			time.Sleep(time.Millisecond * 100)
+		}
+
+		// Then: The price paid should be asked as plain text without the NBKR price button
+		last := requests[len(requests)-1]
+		require.Equal(t, "NBKR didn't publish the price of the 10 g bar on 2024-10-01. How much did you pay per bar in KGS? Send me the price, e.g. 120000.", last["text"])
+		require.Empty(t, last["reply_markup"])
+		require.Empty(t, last["parse_mode"])
+
+		// When: The stale NBKR price button is clicked
+		interaction.TgBot.ProcessUpdate(ctx, newCallbackQuery(chatID, "en", "pf:nbkr"))
+		time.Sleep(time.Millisecond * 100)
+
+		// Then: The holding shouldn't be saved without the price
+		holdings, err := chatsRepository.ListHoldings(ctx, chatID)
+		require.NoError(t, err)
+		require.Empty(t, holdings)
+	})
+}
+
+func Test_HandlerChart(t *testing.T) {
+	ctx, st := suite.New(t, suite.WithPostgres())
+
+	pricesRepository := prices.NewRepository(st.GetDB())
+	chatsRepository := chats.NewRepository(st.GetDB())
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	// Given: The prices of the 1 g and 10 g bars over the last days
+	var dbPrices []*model.GoldPrice
+	for _, date := range []string{"2024-09-27", "2024-09-30", "2024-10-01"} {
+		dbPrices = append(dbPrices,
+			&model.GoldPrice{Date: suite.GetDateTime(t, date), Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 12345, SellPrice: 12588},
+			&model.GoldPrice{Date: suite.GetDateTime(t, date), Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 123450, SellPrice: 125880},
+		)
+	}
+	require.NoError(t, st.GetDB().WithContext(ctx).Create(&dbPrices).Error)
+
+	const chatID = 40
+
+	t.Run("should upload the whole chart again after the flood limit", func(t *testing.T) {
+		mockedHTTPClient := botMock.NewMockHttpClient(t)
+		outbox := telegram.NewOutbox(st.Logger, telegram.OutboxOptions{GlobalRate: 100, MaxRetries: 1})
+		interaction := telegram.NewInteraction(st.Logger, "token", mockedHTTPClient, bundle, pricesRepository, chatsRepository, telegram.WithOutbox(outbox))
+
+		var photos []string
+		mockedHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(request *http.Request) (*http.Response, error) {
+			require.Contains(t, request.URL.Path, "sendPhoto")
+
+			formData := suite.ParseRequestBody(t, request)
+			require.Equal(t, strconv.Itoa(chatID), formData["chat_id"])
+			require.Equal(t, "<b>📊 The 1 g bars over the last year</b>\n🟨 Sell price, 🟦 buyback price", formData["caption"])
+			photos = append(photos, formData["photo"])
+
+			if len(photos) == 1 {
+				body := `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`
+				return &http.Response{StatusCode: 429, Body: io.NopCloser(strings.NewReader(body))}, nil
+			}
+
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
+		})
+
+		// When: We send the /chart command and Telegram asks to wait before the upload
+		interaction.TgBot.ProcessUpdate(ctx, newUpdate(chatID, "en", "/chart"))
+
+		// Wait for the handler to be executed
+		time.Sleep(time.Millisecond * 1500)
+
+		// Then: The chart of the smallest weight should be uploaded whole by both attempts
+		require.Len(t, photos, 2)
+		require.True(t, strings.HasPrefix(photos[0], "\x89PNG"))
+		require.Equal(t, photos[0], photos[1])
+	})
+
+	t.Run("should chart the portfolio weights with the purchase dates after the digest", func(t *testing.T) {
+		mockedHTTPClient := botMock.NewMockHttpClient(t)
+		interaction := telegram.NewInteraction(st.Logger, "token", mockedHTTPClient, bundle, pricesRepository, chatsRepository)
+
+		var captions []string
+		mockedHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(request *http.Request) (*http.Response, error) {
+			require.Contains(t, request.URL.Path, "sendPhoto")
+
+			formData := suite.ParseRequestBody(t, request)
+			require.Equal(t, strconv.Itoa(chatID), formData["chat_id"])
+			require.True(t, strings.HasPrefix(formData["photo"], "\x89PNG"))
+			captions = append(captions, formData["caption"])
+
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
+		})
+
+		// Given: The chat holds the 10 g and 1 g bars, one of them is bought on the charted date
+		chat := &model.TgChat{
+			SourceID: chatID,
+			Language: "en",
+			Holdings: []*model.TgChatHolding{{Weight: 10, Quantity: 1}, {Weight: 1, Quantity: 2}, {Weight: 10, Quantity: 3}},
+			Alerts2:  []*model.TgChatAlert2{{PurchaseDate: suite.GetDateTime(t, "2024-09-30")}},
+		}
+
+		// When: We send the chart of the weekly digest
+		require.NoError(t, interaction.SendDigestChart(ctx, chat))
+
+		// Then: Every weight of the portfolio should be charted once over the month with the purchase dates marked
+		require.Equal(t, []string{"<b>📊 The 10, 1 g bars over the last month</b>\n🟨 Sell price, 🟦 buyback price\n🟥 Your alert2 purchase dates"}, captions)
+	})
+}
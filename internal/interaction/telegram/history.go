@@ -0,0 +1,205 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tg "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"goldie/internal/model"
+)
+
+const (
+	historyCallbackPrefix = "hs:"
+	// historyWeightsPerRow limits the weight buttons in one row of the history keyboard.
+	historyWeightsPerRow = 4
+)
+
+// handlerHistory shows the prices of the weight over the period, the command is /history [weight] [period].
+// The smallest weight and the month are shown by default.
+func (that *Interaction) handlerHistory(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerHistory", "user_id", update.Message.From.ID)
+
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+
+	prices, err := that.pricesRepository.GetLatestPrices(ctx)
+	if err != nil {
+		log.Error("failed to get prices", "error", err)
+		return
+	}
+
+	if len(prices) == 0 {
+		if _, err = that.sendLocaledMessage(ctx, bot, update, "noPricesMessage"); err != nil {
+			log.Error("failed to send message", "error", err)
+		}
+		return
+	}
+
+	weights := make([]float64, 0, len(prices))
+	for _, price := range prices {
+		weights = append(weights, price.Weight)
+	}
+
+	weight, period, ok := parseHistoryArgs(strings.Fields(update.Message.Text)[1:], weights)
+	if !ok {
+		if _, err = that.sendLocaledMessage(ctx, bot, update, "historyUsage", "Weights", joinWeights(weights)); err != nil {
+			log.Error("failed to send history usage", "error", err)
+		}
+		return
+	}
+
+	if err = that.sendHistory(ctx, bot, update.Message.Chat.ID, languageCode, 0, prices, weight, period); err != nil {
+		log.Error("failed to send history", "error", err)
+	}
+}
+
+// handlerHistoryCallback switches the weight or the period of the history message in place.
+// The data is hs:<weight>:<period>.
+func (that *Interaction) handlerHistoryCallback(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerHistoryCallback")
+
+	if update.CallbackQuery == nil || update.CallbackQuery.Message.Message == nil {
+		return
+	}
+
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	messageID := update.CallbackQuery.Message.Message.ID
+	languageCode := that.getLanguageCode(ctx, update.CallbackQuery.Message.Message.Chat, update.CallbackQuery.Message.Message.From)
+
+	parts := strings.Split(strings.TrimPrefix(update.CallbackQuery.Data, historyCallbackPrefix), ":")
+	if len(parts) != 2 || !slices.Contains(model.HistoryPeriods, parts[1]) {
+		return
+	}
+
+	weight, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return
+	}
+
+	prices, err := that.pricesRepository.GetLatestPrices(ctx)
+	if err != nil {
+		log.Error("failed to get prices", "error", err)
+	} else if err = that.sendHistory(ctx, bot, chatID, languageCode, messageID, prices, weight, parts[1]); err != nil {
+		log.Error("failed to send history", "error", err)
+	}
+
+	if _, err = bot.AnswerCallbackQuery(ctx, &tg.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID}); err != nil {
+		log.Error("failed to answer history callback", "error", err)
+	}
+}
+
+// sendHistory shows the prices of the weight over the period until the latest date with the buttons to switch them.
+func (that *Interaction) sendHistory(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, latestPrices []*model.GoldPrice, weight float64, period string) error {
+	if len(latestPrices) == 0 {
+		text, err := that.renderLocaledMessage(languageCode, "noPricesMessage")
+		if err != nil {
+			return err
+		}
+
+		return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, nil)
+	}
+
+	end := latestPrices[0].Date
+	prices, err := that.pricesRepository.GetWeightPricesBetween(ctx, model.PriceSourceNBKR, weight, model.HistoryBegin(period, end), end.AddDate(0, 0, 1))
+	if err != nil {
+		return fmt.Errorf("get weight prices: %w", err)
+	}
+
+	var text string
+	if len(prices) > 0 {
+		text = that.HistoryToString(languageCode, weight, period, prices)
+	} else if text, err = that.renderLocaledMessage(languageCode, "historyEmpty", "Weight", strconv.FormatFloat(weight, 'g', 6, 64)); err != nil {
+		return err
+	}
+
+	return that.sendOrEditHTMLMessage(ctx, bot, chatID, messageID, text, that.buildHistoryKeyboard(languageCode, latestPrices, weight, period))
+}
+
+// buildHistoryKeyboard returns the buttons of the weights and the periods, the shown ones are marked.
+func (that *Interaction) buildHistoryKeyboard(languageCode string, latestPrices []*model.GoldPrice, weight float64, period string) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+	var row []models.InlineKeyboardButton
+
+	for _, price := range latestPrices {
+		label := strconv.FormatFloat(price.Weight, 'g', -1, 64)
+		data := fmt.Sprintf("%s%s:%s", historyCallbackPrefix, label, period)
+		if price.Weight == weight {
+			label = "• " + label + " •"
+		}
+
+		row = append(row, models.InlineKeyboardButton{Text: label, CallbackData: data})
+		if len(row) == historyWeightsPerRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	weightStr := strconv.FormatFloat(weight, 'g', -1, 64)
+	periods := make([]models.InlineKeyboardButton, 0, len(model.HistoryPeriods))
+	for _, p := range model.HistoryPeriods {
+		label, _ := that.renderLocaledMessage(languageCode, "history.button."+p)
+		if p == period {
+			label = "• " + label + " •"
+		}
+
+		periods = append(periods, models.InlineKeyboardButton{Text: label, CallbackData: fmt.Sprintf("%s%s:%s", historyCallbackPrefix, weightStr, p)})
+	}
+
+	return &models.InlineKeyboardMarkup{InlineKeyboard: append(rows, periods)}
+}
+
+// parseHistoryArgs returns the weight and the period of the /history arguments in any order. The weight must be one
+// of the weights, the smallest one and the month are used if they're omitted. It's false if the arguments are wrong.
+func parseHistoryArgs(args []string, weights []float64) (float64, string, bool) {
+	selected, period, ok := parseWeightsArgs(args, weights, model.HistoryPeriods)
+	if !ok {
+		return 0, "", false
+	}
+
+	weight := slices.Min(weights)
+	if len(selected) > 0 {
+		weight = selected[len(selected)-1]
+	}
+
+	if period == "" {
+		period = model.HistoryPeriodMonth
+	}
+
+	return weight, period, true
+}
+
+// parseWeightsArgs returns the distinct weights and the period of the arguments in any order. The weights must be
+// among the weights and the period among the periods, the period is empty if it's omitted.
+// It's false if the arguments are wrong.
+func parseWeightsArgs(args []string, weights []float64, periods []string) ([]float64, string, bool) {
+	var selected []float64
+	var period string
+
+	for _, arg := range args {
+		arg = strings.ToLower(arg)
+
+		if slices.Contains(periods, arg) {
+			period = arg
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSuffix(strings.Replace(arg, ",", ".", 1), "g"), 64)
+		if err != nil || !slices.Contains(weights, value) {
+			return nil, "", false
+		}
+
+		if !slices.Contains(selected, value) {
+			selected = append(selected, value)
+		}
+	}
+
+	return selected, period, true
+}
@@ -0,0 +1,234 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tg "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"goldie/internal/model"
+)
+
+const moveAlertCallbackPrefix = "ma:"
+
+// moveAlertAverageDays are the periods of the average baseline offered to the user.
+var moveAlertAverageDays = []int{7, 30}
+
+func (that *Interaction) handlerMoveAlert(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerMoveAlert", "user_id", update.Message.From.ID)
+
+	that.drafts.delete(update.Message.Chat.ID)
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+
+	if err := that.sendMoveAlertsPage(ctx, bot, update.Message.Chat.ID, languageCode, 0); err != nil {
+		log.Error("failed to send move alerts", "error", err)
+	}
+}
+
+func (that *Interaction) handlerMoveAlertCallback(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerMoveAlertCallback")
+
+	if update.CallbackQuery == nil || update.CallbackQuery.Message.Message == nil {
+		return
+	}
+
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	messageID := update.CallbackQuery.Message.Message.ID
+	languageCode := that.getLanguageCode(ctx, update.CallbackQuery.Message.Message.Chat, update.CallbackQuery.Message.Message.From)
+
+	parts := strings.Split(strings.TrimPrefix(update.CallbackQuery.Data, moveAlertCallbackPrefix), ":")
+
+	var err error
+
+	switch {
+	case parts[0] == "new":
+		err = that.sendAlertWeights(ctx, bot, chatID, languageCode, messageID, moveAlertCallbackPrefix+"w:")
+	case parts[0] == "w" && len(parts) == 2:
+		weight, parseErr := strconv.ParseFloat(parts[1], 64)
+		if parseErr != nil {
+			return
+		}
+
+		err = that.sendMoveAlertBaselines(ctx, bot, chatID, languageCode, messageID, weight)
+	case parts[0] == "b" && len(parts) == 4:
+		weight, weightErr := strconv.ParseFloat(parts[1], 64)
+		days, daysErr := strconv.Atoi(parts[3])
+		if weightErr != nil || daysErr != nil || (parts[2] != model.MoveAlertBaselinePrevious && parts[2] != model.MoveAlertBaselineAverage) {
+			return
+		}
+
+		draft := &model.TgChatMoveAlert{Weight: weight, Baseline: parts[2], Days: days}
+		that.drafts.set(chatID, draft)
+		err = that.sendMoveAlertPercentRequest(ctx, bot, chatID, languageCode, messageID, draft)
+	case parts[0] == "del" && len(parts) == 2:
+		alertID, parseErr := strconv.ParseInt(parts[1], 10, 64)
+		if parseErr != nil {
+			return
+		}
+
+		if err = that.chatsRepository.DeleteMoveAlert(ctx, chatID, alertID); err == nil {
+			err = that.sendMoveAlertsPage(ctx, bot, chatID, languageCode, messageID)
+		}
+	default:
+		return
+	}
+
+	if err != nil {
+		log.Error("failed to process move alert callback", "error", err)
+	}
+
+	if _, err = bot.AnswerCallbackQuery(ctx, &tg.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID}); err != nil {
+		log.Error("failed to answer move alert callback", "error", err)
+	}
+}
+
+// matchMoveAlertPercent matches the plain text messages of the chats which are creating a move alert.
+func (that *Interaction) matchMoveAlertPercent(update *models.Update) bool {
+	if update.Message == nil || update.Message.Text == "" || strings.HasPrefix(update.Message.Text, "/") {
+		return false
+	}
+
+	_, ok := that.drafts.get(update.Message.Chat.ID).(*model.TgChatMoveAlert)
+	return ok
+}
+
+func (that *Interaction) handlerMoveAlertPercent(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerMoveAlertPercent", "user_id", update.Message.From.ID)
+
+	chatID := update.Message.Chat.ID
+	draft, ok := that.drafts.get(chatID).(*model.TgChatMoveAlert)
+	if !ok {
+		return
+	}
+
+	percent, err := parsePositiveNumber(strings.TrimSuffix(strings.TrimSpace(update.Message.Text), "%"))
+	if err != nil || percent >= 100 {
+		if _, err = that.sendLocaledMessage(ctx, bot, update, "moveAlertInvalidPercent"); err != nil {
+			log.Error("failed to send invalid percent message", "error", err)
+		}
+		return
+	}
+
+	alert := &model.TgChatMoveAlert{Weight: draft.Weight, Baseline: draft.Baseline, Days: draft.Days, Percent: percent}
+	if err = that.chatsRepository.CreateMoveAlert(ctx, chatID, alert); err != nil {
+		log.Error("failed to create move alert", "error", err)
+		return
+	}
+
+	that.drafts.delete(chatID)
+
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+	if _, err = that.sendLocaledMessage(ctx, bot, update, "moveAlertCreated",
+		"Weight", strconv.FormatFloat(alert.Weight, 'g', 6, 64),
+		"Percent", strconv.FormatFloat(alert.Percent, 'f', -1, 64),
+		"Baseline", that.renderMoveAlertBaseline(languageCode, alert),
+	); err != nil {
+		log.Error("failed to send move alert created message", "error", err)
+	}
+}
+
+func (that *Interaction) sendMoveAlertsPage(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int) error {
+	alerts, err := that.chatsRepository.ListMoveAlerts(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("list move alerts: %w", err)
+	}
+
+	text, err := that.renderLocaledMessage(languageCode, "moveAlertsEmpty")
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]models.InlineKeyboardButton, 0, len(alerts)+1)
+
+	if len(alerts) > 0 {
+		title, titleErr := that.renderLocaledMessage(languageCode, "moveAlertsListTitle")
+		if titleErr != nil {
+			return titleErr
+		}
+
+		lines := []string{title}
+		for i, alert := range alerts {
+			line, lineErr := that.renderLocaledMessage(languageCode, "moveAlertsItem",
+				"Index", strconv.Itoa(i+1),
+				"Weight", strconv.FormatFloat(alert.Weight, 'g', 6, 64),
+				"Percent", strconv.FormatFloat(alert.Percent, 'f', -1, 64),
+				"Baseline", that.renderMoveAlertBaseline(languageCode, alert))
+			if lineErr != nil {
+				return lineErr
+			}
+			lines = append(lines, line)
+
+			deleteLabel, labelErr := that.renderLocaledMessage(languageCode, "priceAlertDeleteButton", "Index", strconv.Itoa(i+1))
+			if labelErr != nil {
+				return labelErr
+			}
+			rows = append(rows, []models.InlineKeyboardButton{{Text: deleteLabel, CallbackData: fmt.Sprintf("%sdel:%d", moveAlertCallbackPrefix, alert.ID)}})
+		}
+
+		text = strings.Join(lines, "\n")
+	}
+
+	newLabel, err := that.renderLocaledMessage(languageCode, "priceAlertNewButton")
+	if err != nil {
+		return err
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: newLabel, CallbackData: moveAlertCallbackPrefix + "new"}})
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+func (that *Interaction) sendMoveAlertBaselines(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, weight float64) error {
+	weightStr := strconv.FormatFloat(weight, 'g', -1, 64)
+
+	text, err := that.renderLocaledMessage(languageCode, "moveAlertChooseBaseline", "Weight", strconv.FormatFloat(weight, 'g', 6, 64))
+	if err != nil {
+		return err
+	}
+
+	previousLabel, err := that.renderLocaledMessage(languageCode, "moveAlert.button.previous")
+	if err != nil {
+		return err
+	}
+
+	rows := [][]models.InlineKeyboardButton{{
+		{Text: previousLabel, CallbackData: fmt.Sprintf("%sb:%s:%s:0", moveAlertCallbackPrefix, weightStr, model.MoveAlertBaselinePrevious)},
+	}}
+
+	for _, days := range moveAlertAverageDays {
+		label, labelErr := that.renderLocaledMessage(languageCode, "moveAlert.button.average", "Days", strconv.Itoa(days))
+		if labelErr != nil {
+			return labelErr
+		}
+
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: label, CallbackData: fmt.Sprintf("%sb:%s:%s:%d", moveAlertCallbackPrefix, weightStr, model.MoveAlertBaselineAverage, days)},
+		})
+	}
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+func (that *Interaction) sendMoveAlertPercentRequest(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, draft *model.TgChatMoveAlert) error {
+	text, err := that.renderLocaledMessage(languageCode, "moveAlertEnterPercent",
+		"Weight", strconv.FormatFloat(draft.Weight, 'g', 6, 64),
+		"Baseline", that.renderMoveAlertBaseline(languageCode, draft))
+	if err != nil {
+		return err
+	}
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, nil)
+}
+
+// renderMoveAlertBaseline returns the localized baseline the latest price is compared with.
+func (that *Interaction) renderMoveAlertBaseline(languageCode string, alert *model.TgChatMoveAlert) string {
+	if alert.Baseline == model.MoveAlertBaselineAverage {
+		baseline, _ := that.renderLocaledMessage(languageCode, "moveAlert.baseline.averageShort", "Days", strconv.Itoa(alert.Days))
+		return baseline
+	}
+
+	baseline, _ := that.renderLocaledMessage(languageCode, "moveAlert.baseline.previousShort")
+	return baseline
+}
@@ -0,0 +1,234 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	tg "github.com/go-telegram/bot"
+)
+
+// OutboxOptions configures the limits of the outbound messages,
+// see https://core.telegram.org/bots/faq#my-bot-is-hitting-limits-how-do-i-avoid-this
+type OutboxOptions struct {
+	GlobalRate    int           // messages per second to all the chats
+	ChatInterval  time.Duration // between the messages to the same private chat
+	GroupInterval time.Duration // between the messages to the same group
+	MaxRetries    int           // of the message answered with 429
+}
+
+// Outbox sends the outbound messages within the Telegram limits. The messages to the same chat are sent in order,
+// the 429 response pauses all the sending for the retry_after time and the message is sent again.
+type Outbox struct {
+	logger  *slog.Logger
+	options OutboxOptions
+	bucket  *tokenBucket
+
+	mu          sync.Mutex
+	chats       map[int64]*chatQueue
+	pausedUntil time.Time
+}
+
+type chatQueue struct {
+	messages   []*outboxMessage
+	running    bool
+	lastSentAt time.Time
+}
+
+type outboxMessage struct {
+	ctx  context.Context
+	send func(ctx context.Context) error
+	done chan error
+}
+
+// NewOutbox creates a new outbox, the messages are sent by a goroutine per chat with queued messages.
+func NewOutbox(logger *slog.Logger, options OutboxOptions) *Outbox {
+	return &Outbox{
+		logger:  logger.With("component", "telegram_outbox"),
+		options: options,
+		bucket:  newTokenBucket(options.GlobalRate),
+		chats:   make(map[int64]*chatQueue),
+	}
+}
+
+// Send queues the message to the chat and waits until it's sent. The error of the last attempt is returned.
+func (that *Outbox) Send(ctx context.Context, chatID int64, send func(ctx context.Context) error) error {
+	message := &outboxMessage{ctx: ctx, send: send, done: make(chan error, 1)}
+
+	that.mu.Lock()
+	queue, exists := that.chats[chatID]
+	if !exists {
+		queue = &chatQueue{}
+		that.chats[chatID] = queue
+	}
+
+	queue.messages = append(queue.messages, message)
+	if !queue.running {
+		queue.running = true
+		go that.drain(chatID, queue)
+	}
+	that.mu.Unlock()
+
+	select {
+	case err := <-message.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain sends the queued messages of the chat one by one.
+func (that *Outbox) drain(chatID int64, queue *chatQueue) {
+	for {
+		that.mu.Lock()
+		if len(queue.messages) == 0 {
+			queue.running = false
+			that.mu.Unlock()
+
+			// The idle queue is kept for the chat interval to respect it for the next message
+			time.AfterFunc(that.interval(chatID), func() { that.forget(chatID, queue) })
+			return
+		}
+
+		message := queue.messages[0]
+		queue.messages = queue.messages[1:]
+		that.mu.Unlock()
+
+		message.done <- that.sendMessage(chatID, queue, message)
+	}
+}
+
+func (that *Outbox) forget(chatID int64, queue *chatQueue) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	if that.chats[chatID] == queue && !queue.running {
+		delete(that.chats, chatID)
+	}
+}
+
+// sendMessage sends the message, it's sent again after the retry_after time if Telegram asks so.
+func (that *Outbox) sendMessage(chatID int64, queue *chatQueue, message *outboxMessage) error {
+	for attempt := 1; ; attempt++ {
+		if err := that.wait(message.ctx, chatID, queue); err != nil {
+			return err
+		}
+
+		err := message.send(message.ctx)
+
+		that.mu.Lock()
+		queue.lastSentAt = time.Now()
+		that.mu.Unlock()
+
+		var tooManyRequests *tg.TooManyRequestsError
+		if !errors.As(err, &tooManyRequests) || attempt > that.options.MaxRetries {
+			return err
+		}
+
+		retryAfter := time.Duration(tooManyRequests.RetryAfter) * time.Second
+		that.logger.Warn("telegram limit is hit, pausing the outbox", "chat_id", chatID, "retry_after", retryAfter, "attempt", attempt)
+		that.pause(retryAfter)
+	}
+}
+
+// wait blocks until the message can be sent to the chat.
+func (that *Outbox) wait(ctx context.Context, chatID int64, queue *chatQueue) error {
+	that.mu.Lock()
+	readyAt := queue.lastSentAt.Add(that.interval(chatID))
+	that.mu.Unlock()
+
+	if err := sleep(ctx, time.Until(readyAt)); err != nil {
+		return err
+	}
+
+	if err := that.bucket.Wait(ctx); err != nil {
+		return err
+	}
+
+	// The pause may be set while the message is waiting for the token
+	that.mu.Lock()
+	pausedUntil := that.pausedUntil
+	that.mu.Unlock()
+
+	return sleep(ctx, time.Until(pausedUntil))
+}
+
+func (that *Outbox) pause(retryAfter time.Duration) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	if until := time.Now().Add(retryAfter); until.After(that.pausedUntil) {
+		that.pausedUntil = until
+	}
+}
+
+// interval returns the minimal interval between the messages to the chat, the group IDs are negative.
+func (that *Outbox) interval(chatID int64) time.Duration {
+	if chatID < 0 {
+		return that.options.GroupInterval
+	}
+
+	return that.options.ChatInterval
+}
+
+// tokenBucket limits the rate of the messages to all the chats, the burst is a second worth of messages.
+type tokenBucket struct {
+	mu       sync.Mutex
+	interval time.Duration // to refill a token
+	burst    float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(rate int) *tokenBucket {
+	if rate <= 0 {
+		return &tokenBucket{}
+	}
+
+	return &tokenBucket{interval: time.Second / time.Duration(rate), burst: float64(rate), tokens: float64(rate), last: time.Now()}
+}
+
+// Wait takes a token, it waits for the refill if the bucket is empty. The zero bucket doesn't limit the rate.
+func (that *tokenBucket) Wait(ctx context.Context) error {
+	if that.interval == 0 {
+		return nil
+	}
+
+	that.mu.Lock()
+	now := time.Now()
+	that.tokens = min(that.burst, that.tokens+float64(now.Sub(that.last))/float64(that.interval))
+	that.last = now
+
+	// The token is reserved even if the bucket is empty, so the waiting messages are spread in time
+	that.tokens--
+	delay := time.Duration(-that.tokens * float64(that.interval))
+	that.mu.Unlock()
+
+	if err := sleep(ctx, delay); err != nil {
+		that.mu.Lock()
+		that.tokens++
+		that.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
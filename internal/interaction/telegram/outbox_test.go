@@ -0,0 +1,87 @@
+package telegram_test
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	tg "github.com/go-telegram/bot"
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/interaction/telegram"
+)
+
+func Test_Outbox_Send(t *testing.T) {
+	t.Run("should keep the interval between the messages to the same chat", func(t *testing.T) {
+		outbox := telegram.NewOutbox(slog.Default(), telegram.OutboxOptions{GlobalRate: 100, ChatInterval: 100 * time.Millisecond})
+
+		var (
+			mu      sync.Mutex
+			sentAt  []time.Time
+			sending bool
+		)
+
+		send := func(context.Context) error {
+			mu.Lock()
+			require.False(t, sending, "the messages to the same chat are sent one by one")
+			sending = true
+			sentAt = append(sentAt, time.Now())
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			sending = false
+			mu.Unlock()
+			return nil
+		}
+
+		// When: We send three messages to the same chat at once
+		var wg sync.WaitGroup
+		for range 3 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				require.NoError(t, outbox.Send(context.Background(), 100, send))
+			}()
+		}
+		wg.Wait()
+
+		// Then: The messages should be spread by the chat interval
+		require.Len(t, sentAt, 3)
+		require.GreaterOrEqual(t, sentAt[1].Sub(sentAt[0]), 100*time.Millisecond)
+		require.GreaterOrEqual(t, sentAt[2].Sub(sentAt[1]), 100*time.Millisecond)
+	})
+
+	t.Run("should send the message again after the retry_after time", func(t *testing.T) {
+		outbox := telegram.NewOutbox(slog.Default(), telegram.OutboxOptions{GlobalRate: 100, MaxRetries: 1})
+
+		var attempts []time.Time
+		send := func(context.Context) error {
+			attempts = append(attempts, time.Now())
+			if len(attempts) == 1 {
+				return &tg.TooManyRequestsError{Message: "too many requests", RetryAfter: 1}
+			}
+			return nil
+		}
+
+		// When: Telegram asks to wait before the message is sent again
+		require.NoError(t, outbox.Send(context.Background(), 100, send))
+
+		// Then: The message should be sent after the pause
+		require.Len(t, attempts, 2)
+		require.GreaterOrEqual(t, attempts[1].Sub(attempts[0]), time.Second)
+	})
+
+	t.Run("should return the 429 error when the retries are exhausted", func(t *testing.T) {
+		outbox := telegram.NewOutbox(slog.Default(), telegram.OutboxOptions{GlobalRate: 100})
+
+		err := outbox.Send(context.Background(), 100, func(context.Context) error {
+			return &tg.TooManyRequestsError{Message: "too many requests", RetryAfter: 1}
+		})
+
+		require.True(t, tg.IsTooManyRequestsError(err))
+	})
+}
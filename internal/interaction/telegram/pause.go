@@ -0,0 +1,140 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tg "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	pauseCallbackPrefix = "pause:"
+	pauseCalendarPrefix = "pausecal:"
+)
+
+func (that *Interaction) handlerPause(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerPause", "user_id", update.Message.From.ID)
+
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+
+	if err := that.sendPauseOptions(ctx, bot, update.Message.Chat.ID, languageCode, 0); err != nil {
+		log.Error("failed to send pause options", "error", err)
+	}
+}
+
+func (that *Interaction) handlerPauseCallback(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerPauseCallback")
+
+	if update.CallbackQuery == nil || update.CallbackQuery.Message.Message == nil {
+		return
+	}
+
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	messageID := update.CallbackQuery.Message.Message.ID
+	languageCode := that.getLanguageCode(ctx, update.CallbackQuery.Message.Message.Chat, update.CallbackQuery.Message.Message.From)
+
+	now := time.Now()
+
+	var err error
+
+	switch strings.TrimPrefix(update.CallbackQuery.Data, pauseCallbackPrefix) {
+	case "week":
+		err = that.pauseChat(ctx, bot, chatID, languageCode, messageID, now.AddDate(0, 0, 7))
+	case "month":
+		err = that.pauseChat(ctx, bot, chatID, languageCode, messageID, now.AddDate(0, 1, 0))
+	case "date":
+		err = that.pauseCal.SendCalendar(ctx, bot, languageCode, chatID, now.AddDate(0, 0, 1), now.AddDate(1, 0, 0))
+	case "resume":
+		// The pause is over right now, so the resume job sends the summary of the missed prices
+		if that.getChatPreferences(ctx, chatID).IsPaused(now) {
+			err = that.pauseChat(ctx, bot, chatID, languageCode, messageID, now)
+		}
+	default:
+		return
+	}
+
+	if err != nil {
+		log.Error("failed to process pause callback", "error", err)
+	}
+
+	if _, err = bot.AnswerCallbackQuery(ctx, &tg.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID}); err != nil {
+		log.Error("failed to answer pause callback", "error", err)
+	}
+}
+
+func (that *Interaction) handlerPauseCalendarCallback(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerPauseCalendarCallback", "chat_id", update.CallbackQuery.Message.Message.Chat.ID)
+
+	now := time.Now()
+	languageCode := that.getLanguageCode(ctx, update.CallbackQuery.Message.Message.Chat, update.CallbackQuery.Message.Message.From)
+	if err := that.pauseCal.HandleCallback(ctx, bot, languageCode, update, now.AddDate(0, 0, 1), now.AddDate(1, 0, 0)); err != nil {
+		log.Error("failed to handle calendar callback", "error", err)
+	}
+}
+
+// handlerPauseSelectedDate pauses the alerts until the start of the selected date in the chat timezone.
+func (that *Interaction) handlerPauseSelectedDate(ctx context.Context, bot *tg.Bot, languageCode string, callBackQueryID string, chatID int64, messageID int, selected time.Time) {
+	log := that.logger.With("method", "handlerPauseSelectedDate", "chat_id", chatID)
+
+	defer func() {
+		_, _ = bot.AnswerCallbackQuery(ctx, &tg.AnswerCallbackQueryParams{CallbackQueryID: callBackQueryID})
+	}()
+
+	location := that.getChatPreferences(ctx, chatID).GetLocation()
+	until := time.Date(selected.Year(), selected.Month(), selected.Day(), 0, 0, 0, 0, location)
+
+	if err := that.pauseChat(ctx, bot, chatID, languageCode, messageID, until); err != nil {
+		log.Error("failed to pause chat", "error", err)
+	}
+}
+
+func (that *Interaction) pauseChat(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, until time.Time) error {
+	if err := that.chatsRepository.PauseChat(ctx, chatID, until); err != nil {
+		return fmt.Errorf("pause chat: %w", err)
+	}
+
+	return that.sendPauseOptions(ctx, bot, chatID, languageCode, messageID)
+}
+
+// sendPauseOptions shows whether the alerts are paused and the durations to pause them for.
+func (that *Interaction) sendPauseOptions(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int) error {
+	chat := that.getChatPreferences(ctx, chatID)
+	paused := chat.IsPaused(time.Now())
+
+	status, err := that.renderLocaledMessage(languageCode, "pauseStatusActive")
+	if paused {
+		status, err = that.renderLocaledMessage(languageCode, "pauseStatusPaused",
+			"Until", chat.PausedUntil.In(chat.GetLocation()).Format("2006-01-02 15:04"),
+			"Timezone", chat.GetTimezone())
+	}
+	if err != nil {
+		return err
+	}
+
+	choose, err := that.renderLocaledMessage(languageCode, "pauseChoose")
+	if err != nil {
+		return err
+	}
+
+	weekLabel, _ := that.renderLocaledMessage(languageCode, "pause.button.week")
+	monthLabel, _ := that.renderLocaledMessage(languageCode, "pause.button.month")
+	dateLabel, _ := that.renderLocaledMessage(languageCode, "pause.button.date")
+
+	rows := [][]models.InlineKeyboardButton{
+		{
+			{Text: weekLabel, CallbackData: pauseCallbackPrefix + "week"},
+			{Text: monthLabel, CallbackData: pauseCallbackPrefix + "month"},
+		},
+		{{Text: dateLabel, CallbackData: pauseCallbackPrefix + "date"}},
+	}
+
+	if paused {
+		resumeLabel, _ := that.renderLocaledMessage(languageCode, "pause.button.resume")
+		rows = append(rows, []models.InlineKeyboardButton{{Text: resumeLabel, CallbackData: pauseCallbackPrefix + "resume"}})
+	}
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, status+"\n\n"+choose, &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
@@ -0,0 +1,304 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tg "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"goldie/internal/model"
+)
+
+const portfolioCallbackPrefix = "pf:"
+
+// holdingDateLayouts are the layouts of the purchase date typed by the user.
+var holdingDateLayouts = []string{"2006-01-02", "02.01.2006"}
+
+func (that *Interaction) handlerPortfolio(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerPortfolio", "user_id", update.Message.From.ID)
+
+	that.drafts.delete(update.Message.Chat.ID)
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+
+	if err := that.sendPortfolioPage(ctx, bot, update.Message.Chat.ID, languageCode, 0); err != nil {
+		log.Error("failed to send portfolio", "error", err)
+	}
+}
+
+func (that *Interaction) handlerPortfolioCallback(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerPortfolioCallback")
+
+	if update.CallbackQuery == nil || update.CallbackQuery.Message.Message == nil {
+		return
+	}
+
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	messageID := update.CallbackQuery.Message.Message.ID
+	languageCode := that.getLanguageCode(ctx, update.CallbackQuery.Message.Message.Chat, update.CallbackQuery.Message.Message.From)
+
+	parts := strings.Split(strings.TrimPrefix(update.CallbackQuery.Data, portfolioCallbackPrefix), ":")
+
+	var err error
+
+	switch {
+	case parts[0] == "new":
+		err = that.sendAlertWeights(ctx, bot, chatID, languageCode, messageID, portfolioCallbackPrefix+"w:")
+	case parts[0] == "w" && len(parts) == 2:
+		weight, parseErr := strconv.ParseFloat(parts[1], 64)
+		if parseErr != nil {
+			return
+		}
+
+		that.drafts.set(chatID, &model.TgChatHolding{Weight: weight})
+		err = that.sendHoldingRequest(ctx, bot, chatID, languageCode, messageID, "holdingEnterQuantity", weight)
+	case parts[0] == "nbkr":
+		// The holding is valued at the NBKR sell price on the purchase date
+		draft, ok := that.drafts.get(chatID).(*model.TgChatHolding)
+		if !ok || draft.PurchaseDate.IsZero() {
+			return
+		}
+
+		// The price paid is asked again if NBKR didn't publish the price of the weight on the purchase date
+		var priced bool
+		if priced, err = that.hasHoldingNbkrPrice(ctx, draft); err == nil {
+			if priced {
+				err = that.createHolding(ctx, bot, chatID, languageCode, messageID, draft)
+			} else {
+				err = that.sendHoldingPriceRequest(ctx, bot, chatID, languageCode, draft)
+			}
+		}
+	case parts[0] == "del" && len(parts) == 2:
+		holdingID, parseErr := strconv.ParseInt(parts[1], 10, 64)
+		if parseErr != nil {
+			return
+		}
+
+		if err = that.chatsRepository.DeleteHolding(ctx, chatID, holdingID); err == nil {
+			err = that.sendPortfolioPage(ctx, bot, chatID, languageCode, messageID)
+		}
+	default:
+		return
+	}
+
+	if err != nil {
+		log.Error("failed to process portfolio callback", "error", err)
+	}
+
+	if _, err = bot.AnswerCallbackQuery(ctx, &tg.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID}); err != nil {
+		log.Error("failed to answer portfolio callback", "error", err)
+	}
+}
+
+// matchHoldingInput matches the plain text messages of the chats which are adding a holding.
+func (that *Interaction) matchHoldingInput(update *models.Update) bool {
+	if update.Message == nil || update.Message.Text == "" || strings.HasPrefix(update.Message.Text, "/") {
+		return false
+	}
+
+	_, ok := that.drafts.get(update.Message.Chat.ID).(*model.TgChatHolding)
+	return ok
+}
+
+// handlerHoldingInput fills the holding draft step by step: the quantity, the purchase date and the price paid.
+func (that *Interaction) handlerHoldingInput(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerHoldingInput", "user_id", update.Message.From.ID)
+
+	chatID := update.Message.Chat.ID
+	draft, ok := that.drafts.get(chatID).(*model.TgChatHolding)
+	if !ok {
+		return
+	}
+
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+	text := strings.TrimSpace(update.Message.Text)
+
+	var err error
+
+	switch {
+	case draft.Quantity == 0:
+		quantity, parseErr := strconv.Atoi(text)
+		if parseErr != nil || quantity <= 0 {
+			_, err = that.sendLocaledMessage(ctx, bot, update, "holdingInvalidQuantity")
+			break
+		}
+
+		draft.Quantity = quantity
+		err = that.sendHoldingRequest(ctx, bot, chatID, languageCode, 0, "holdingEnterDate", draft.Weight)
+	case draft.PurchaseDate.IsZero():
+		date, parseErr := parseHoldingDate(text)
+		if parseErr != nil || date.After(time.Now()) {
+			_, err = that.sendLocaledMessage(ctx, bot, update, "holdingInvalidDate")
+			break
+		}
+
+		draft.PurchaseDate = date
+		err = that.sendHoldingPriceRequest(ctx, bot, chatID, languageCode, draft)
+	default:
+		pricePaid, parseErr := parsePositiveNumber(text)
+		if parseErr != nil {
+			_, err = that.sendLocaledMessage(ctx, bot, update, "holdingInvalidPrice")
+			break
+		}
+
+		draft.PricePaid = &pricePaid
+		err = that.createHolding(ctx, bot, chatID, languageCode, 0, draft)
+	}
+
+	if err != nil {
+		log.Error("failed to process holding input", "error", err)
+	}
+}
+
+func (that *Interaction) createHolding(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, draft *model.TgChatHolding) error {
+	holding := &model.TgChatHolding{Weight: draft.Weight, Quantity: draft.Quantity, PurchaseDate: draft.PurchaseDate, PricePaid: draft.PricePaid}
+	if err := that.chatsRepository.CreateHolding(ctx, chatID, holding); err != nil {
+		return fmt.Errorf("create holding: %w", err)
+	}
+
+	that.drafts.delete(chatID)
+
+	return that.sendPortfolioPage(ctx, bot, chatID, languageCode, messageID)
+}
+
+func (that *Interaction) sendPortfolioPage(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int) error {
+	holdings, err := that.chatsRepository.ListHoldings(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("list holdings: %w", err)
+	}
+
+	text, err := that.renderLocaledMessage(languageCode, "portfolioEmpty")
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]models.InlineKeyboardButton, 0, len(holdings)+1)
+
+	if len(holdings) > 0 {
+		prices, pricesErr := that.pricesRepository.GetLatestPrices(ctx)
+		if pricesErr != nil {
+			return fmt.Errorf("get latest prices: %w", pricesErr)
+		}
+
+		title, titleErr := that.renderLocaledMessage(languageCode, "holdingsListTitle")
+		if titleErr != nil {
+			return titleErr
+		}
+
+		lines := []string{title}
+		for i, holding := range holdings {
+			lines = append(lines, that.renderHoldingItem(languageCode, i+1, holding))
+
+			deleteLabel, labelErr := that.renderLocaledMessage(languageCode, "priceAlertDeleteButton", "Index", strconv.Itoa(i+1))
+			if labelErr != nil {
+				return labelErr
+			}
+			rows = append(rows, []models.InlineKeyboardButton{{Text: deleteLabel, CallbackData: fmt.Sprintf("%sdel:%d", portfolioCallbackPrefix, holding.ID)}})
+		}
+
+		text = strings.Join(lines, "\n")
+
+		if portfolio := model.NewPortfolio(holdings, prices, that.getChatPreferences(ctx, chatID).GetGainView()); len(portfolio.Lines) > 0 {
+			text = that.PortfolioToString(languageCode, portfolio) + "\n\n" + text
+		}
+	}
+
+	newLabel, err := that.renderLocaledMessage(languageCode, "holdingNewButton")
+	if err != nil {
+		return err
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: newLabel, CallbackData: portfolioCallbackPrefix + "new"}})
+
+	return that.sendOrEditHTMLMessage(ctx, bot, chatID, messageID, text, &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+func (that *Interaction) sendHoldingRequest(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, messageKey string, weight float64) error {
+	text, err := that.renderLocaledMessage(languageCode, messageKey, "Weight", strconv.FormatFloat(weight, 'g', 6, 64))
+	if err != nil {
+		return err
+	}
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, nil)
+}
+
+// sendHoldingPriceRequest asks for the price paid per bar, the NBKR price on the purchase date can be used instead
+// if NBKR published the price of the weight on that date.
+func (that *Interaction) sendHoldingPriceRequest(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, draft *model.TgChatHolding) error {
+	priced, err := that.hasHoldingNbkrPrice(ctx, draft)
+	if err != nil {
+		return err
+	}
+
+	if !priced {
+		text, renderErr := that.renderLocaledMessage(languageCode, "holdingEnterPriceNoNbkr",
+			"Weight", strconv.FormatFloat(draft.Weight, 'g', 6, 64),
+			"Date", draft.PurchaseDate.Format("2006-01-02"))
+		if renderErr != nil {
+			return renderErr
+		}
+
+		return that.sendOrEditMessage(ctx, bot, chatID, 0, text, nil)
+	}
+
+	text, err := that.renderLocaledMessage(languageCode, "holdingEnterPrice", "Weight", strconv.FormatFloat(draft.Weight, 'g', 6, 64))
+	if err != nil {
+		return err
+	}
+
+	nbkrLabel, err := that.renderLocaledMessage(languageCode, "holdingNbkrPriceButton")
+	if err != nil {
+		return err
+	}
+
+	keyboard := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{{Text: nbkrLabel, CallbackData: portfolioCallbackPrefix + "nbkr"}},
+	}}
+
+	return that.sendOrEditMessage(ctx, bot, chatID, 0, text, keyboard)
+}
+
+// hasHoldingNbkrPrice reports whether NBKR published the price of the holding weight on the purchase date.
+func (that *Interaction) hasHoldingNbkrPrice(ctx context.Context, draft *model.TgChatHolding) (bool, error) {
+	prices, err := that.pricesRepository.GetWeightPricesBetween(ctx, model.PriceSourceNBKR, draft.Weight, draft.PurchaseDate, draft.PurchaseDate.AddDate(0, 0, 1))
+	if err != nil {
+		return false, fmt.Errorf("get weight prices: %w", err)
+	}
+
+	return len(prices) > 0, nil
+}
+
+// renderHoldingItem returns the localized line of the holdings list.
+func (that *Interaction) renderHoldingItem(languageCode string, index int, holding *model.TgChatHolding) string {
+	var price string
+	switch {
+	case holding.PricePaid != nil:
+		price, _ = that.renderLocaledMessage(languageCode, "holdingPaidPrice", "Price", strconv.FormatFloat(*holding.PricePaid, 'f', 2, 64))
+	case holding.BuyingPrice != nil:
+		price, _ = that.renderLocaledMessage(languageCode, "holdingNbkrPrice", "Price", strconv.FormatFloat(holding.BuyingPrice.SellPrice, 'f', 2, 64))
+	default:
+		// The holding isn't valued until the price paid is known
+		price, _ = that.renderLocaledMessage(languageCode, "holdingNoPrice")
+	}
+
+	item, _ := that.renderLocaledMessage(languageCode, "holdingsItem",
+		"Index", strconv.Itoa(index),
+		"Quantity", strconv.Itoa(holding.Quantity),
+		"Weight", strconv.FormatFloat(holding.Weight, 'g', 6, 64),
+		"Date", holding.PurchaseDate.Format("2006-01-02"),
+		"Price", price)
+	return item
+}
+
+// parseHoldingDate parses the purchase date typed by the user, the date is at midnight UTC as the price dates are.
+func parseHoldingDate(text string) (time.Time, error) {
+	for _, layout := range holdingDateLayouts {
+		if date, err := time.Parse(layout, text); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("parse date %q", text)
+}
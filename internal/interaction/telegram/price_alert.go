@@ -0,0 +1,330 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tg "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"goldie/internal/model"
+)
+
+const priceAlertCallbackPrefix = "pa:"
+
+func (that *Interaction) handlerPriceAlert(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerPriceAlert", "user_id", update.Message.From.ID)
+
+	that.drafts.delete(update.Message.Chat.ID)
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+
+	if err := that.sendPriceAlertsPage(ctx, bot, update.Message.Chat.ID, languageCode, 0); err != nil {
+		log.Error("failed to send price alerts", "error", err)
+	}
+}
+
+func (that *Interaction) handlerPriceAlertCallback(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerPriceAlertCallback")
+
+	if update.CallbackQuery == nil || update.CallbackQuery.Message.Message == nil {
+		return
+	}
+
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	messageID := update.CallbackQuery.Message.Message.ID
+	languageCode := that.getLanguageCode(ctx, update.CallbackQuery.Message.Message.Chat, update.CallbackQuery.Message.Message.From)
+
+	// The invalid data is answered too, so the button doesn't keep loading
+	defer func() {
+		if _, err := bot.AnswerCallbackQuery(ctx, &tg.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID}); err != nil {
+			log.Error("failed to answer price alert callback", "error", err)
+		}
+	}()
+
+	parts := strings.Split(strings.TrimPrefix(update.CallbackQuery.Data, priceAlertCallbackPrefix), ":")
+
+	var err error
+
+	switch {
+	case parts[0] == "new":
+		err = that.sendAlertWeights(ctx, bot, chatID, languageCode, messageID, priceAlertCallbackPrefix+"w:")
+	case parts[0] == "w" && len(parts) == 2:
+		weight, parseErr := strconv.ParseFloat(parts[1], 64)
+		if parseErr != nil {
+			return
+		}
+
+		err = that.sendPriceAlertConditions(ctx, bot, chatID, languageCode, messageID, weight)
+	case parts[0] == "c" && len(parts) == 4:
+		weight, parseErr := strconv.ParseFloat(parts[1], 64)
+		if parseErr != nil || !isPriceAlertField(parts[2]) || !isPriceAlertDirection(parts[3]) {
+			return
+		}
+
+		draft := &model.TgChatPriceAlert{Weight: weight, Field: parts[2], Direction: parts[3]}
+		that.drafts.set(chatID, draft)
+		err = that.sendPriceAlertThresholdRequest(ctx, bot, chatID, languageCode, messageID, draft)
+	case parts[0] == "del" && len(parts) == 2:
+		alertID, parseErr := strconv.ParseInt(parts[1], 10, 64)
+		if parseErr != nil {
+			return
+		}
+
+		if err = that.chatsRepository.DeletePriceAlert(ctx, chatID, alertID); err == nil {
+			err = that.sendPriceAlertsPage(ctx, bot, chatID, languageCode, messageID)
+		}
+	default:
+		return
+	}
+
+	if err != nil {
+		log.Error("failed to process price alert callback", "error", err)
+	}
+}
+
+// matchPriceAlertThreshold matches the plain text messages of the chats which are creating a price alert.
+func (that *Interaction) matchPriceAlertThreshold(update *models.Update) bool {
+	if update.Message == nil || update.Message.Text == "" || strings.HasPrefix(update.Message.Text, "/") {
+		return false
+	}
+
+	_, ok := that.drafts.get(update.Message.Chat.ID).(*model.TgChatPriceAlert)
+	return ok
+}
+
+func (that *Interaction) handlerPriceAlertThreshold(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerPriceAlertThreshold", "user_id", update.Message.From.ID)
+
+	chatID := update.Message.Chat.ID
+	draft, ok := that.drafts.get(chatID).(*model.TgChatPriceAlert)
+	if !ok {
+		return
+	}
+
+	threshold, err := parsePositiveNumber(update.Message.Text)
+	if err != nil {
+		if _, err = that.sendLocaledMessage(ctx, bot, update, "priceAlertInvalidThreshold"); err != nil {
+			log.Error("failed to send invalid threshold message", "error", err)
+		}
+		return
+	}
+
+	alert := &model.TgChatPriceAlert{Weight: draft.Weight, Field: draft.Field, Direction: draft.Direction, Threshold: threshold}
+	if err = that.chatsRepository.CreatePriceAlert(ctx, chatID, alert); err != nil {
+		log.Error("failed to create price alert", "error", err)
+		return
+	}
+
+	that.drafts.delete(chatID)
+
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+	field, direction := that.renderPriceAlertCondition(languageCode, alert)
+
+	if _, err = that.sendLocaledMessage(ctx, bot, update, "priceAlertCreated",
+		"Weight", strconv.FormatFloat(alert.Weight, 'g', 6, 64),
+		"Field", field,
+		"Direction", direction,
+		"Threshold", strconv.FormatFloat(alert.Threshold, 'f', 2, 64),
+	); err != nil {
+		log.Error("failed to send price alert created message", "error", err)
+	}
+}
+
+func (that *Interaction) sendPriceAlertsPage(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int) error {
+	alerts, err := that.chatsRepository.ListPriceAlerts(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("list price alerts: %w", err)
+	}
+
+	text, err := that.renderLocaledMessage(languageCode, "priceAlertsEmpty")
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]models.InlineKeyboardButton, 0, len(alerts)+1)
+
+	if len(alerts) > 0 {
+		title, titleErr := that.renderLocaledMessage(languageCode, "priceAlertsTitle")
+		if titleErr != nil {
+			return titleErr
+		}
+
+		lines := []string{title}
+		for i, alert := range alerts {
+			field, direction := that.renderPriceAlertCondition(languageCode, alert)
+			line, lineErr := that.renderLocaledMessage(languageCode, "priceAlertsItem",
+				"Index", strconv.Itoa(i+1),
+				"Weight", strconv.FormatFloat(alert.Weight, 'g', 6, 64),
+				"Field", field,
+				"Direction", direction,
+				"Threshold", strconv.FormatFloat(alert.Threshold, 'f', 2, 64))
+			if lineErr != nil {
+				return lineErr
+			}
+			lines = append(lines, line)
+
+			deleteLabel, labelErr := that.renderLocaledMessage(languageCode, "priceAlertDeleteButton", "Index", strconv.Itoa(i+1))
+			if labelErr != nil {
+				return labelErr
+			}
+			rows = append(rows, []models.InlineKeyboardButton{{Text: deleteLabel, CallbackData: fmt.Sprintf("%sdel:%d", priceAlertCallbackPrefix, alert.ID)}})
+		}
+
+		text = strings.Join(lines, "\n")
+	}
+
+	newLabel, err := that.renderLocaledMessage(languageCode, "priceAlertNewButton")
+	if err != nil {
+		return err
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: newLabel, CallbackData: priceAlertCallbackPrefix + "new"}})
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+// sendAlertWeights asks to choose one of the published weights, the weight is appended to the callback prefix.
+func (that *Interaction) sendAlertWeights(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, callbackPrefix string) error {
+	prices, err := that.pricesRepository.GetLatestPrices(ctx)
+	if err != nil {
+		return fmt.Errorf("get latest prices: %w", err)
+	}
+
+	if len(prices) == 0 {
+		text, renderErr := that.renderLocaledMessage(languageCode, "noPricesMessage")
+		if renderErr != nil {
+			return renderErr
+		}
+
+		return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, nil)
+	}
+
+	text, err := that.renderLocaledMessage(languageCode, "priceAlertChooseWeight")
+	if err != nil {
+		return err
+	}
+
+	const maxPerRow = 3
+	var rows [][]models.InlineKeyboardButton
+	var row []models.InlineKeyboardButton
+
+	for _, price := range prices {
+		weight := strconv.FormatFloat(price.Weight, 'g', -1, 64)
+		row = append(row, models.InlineKeyboardButton{Text: weight, CallbackData: callbackPrefix + weight})
+
+		if len(row) == maxPerRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+func (that *Interaction) sendPriceAlertConditions(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, weight float64) error {
+	weightStr := strconv.FormatFloat(weight, 'g', -1, 64)
+
+	text, err := that.renderLocaledMessage(languageCode, "priceAlertChooseCondition", "Weight", strconv.FormatFloat(weight, 'g', 6, 64))
+	if err != nil {
+		return err
+	}
+
+	var rows [][]models.InlineKeyboardButton
+	for _, field := range []string{model.PriceAlertFieldSell, model.PriceAlertFieldPurchase} {
+		row := make([]models.InlineKeyboardButton, 0, 2)
+		for _, direction := range []string{model.PriceAlertBelow, model.PriceAlertAbove} {
+			label, labelErr := that.renderLocaledMessage(languageCode, "priceAlert.button."+field+"."+direction)
+			if labelErr != nil {
+				return labelErr
+			}
+
+			row = append(row, models.InlineKeyboardButton{Text: label, CallbackData: fmt.Sprintf("%sc:%s:%s:%s", priceAlertCallbackPrefix, weightStr, field, direction)})
+		}
+		rows = append(rows, row)
+	}
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+func (that *Interaction) sendPriceAlertThresholdRequest(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, draft *model.TgChatPriceAlert) error {
+	prices, err := that.pricesRepository.GetLatestPrices(ctx)
+	if err != nil {
+		return fmt.Errorf("get latest prices: %w", err)
+	}
+
+	current := "-"
+	for _, price := range prices {
+		if price.Weight == draft.Weight {
+			current = strconv.FormatFloat(draft.PriceOf(price), 'f', 2, 64)
+		}
+	}
+
+	field, _ := that.renderPriceAlertCondition(languageCode, draft)
+	text, err := that.renderLocaledMessage(languageCode, "priceAlertEnterThreshold",
+		"Weight", strconv.FormatFloat(draft.Weight, 'g', 6, 64),
+		"Field", field,
+		"Price", current)
+	if err != nil {
+		return err
+	}
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, nil)
+}
+
+// renderPriceAlertCondition returns the localized watched price and the direction of the alert.
+func (that *Interaction) renderPriceAlertCondition(languageCode string, alert *model.TgChatPriceAlert) (string, string) {
+	field, _ := that.renderLocaledMessage(languageCode, "priceAlert.field."+alert.Field)
+	direction, _ := that.renderLocaledMessage(languageCode, "priceAlert.direction."+alert.Direction)
+
+	return field, direction
+}
+
+// sendOrEditMessage sends a new plain text message or edits the existing one if the message ID is given.
+func (that *Interaction) sendOrEditMessage(ctx context.Context, bot *tg.Bot, chatID int64, messageID int, text string, keyboard *models.InlineKeyboardMarkup) error {
+	return that.sendOrEditParsedMessage(ctx, bot, chatID, messageID, text, "", keyboard)
+}
+
+// sendOrEditHTMLMessage sends a new HTML message or edits the existing one if the message ID is given.
+// The values interpolated into the text must not come from the users as they aren't escaped.
+func (that *Interaction) sendOrEditHTMLMessage(ctx context.Context, bot *tg.Bot, chatID int64, messageID int, text string, keyboard *models.InlineKeyboardMarkup) error {
+	return that.sendOrEditParsedMessage(ctx, bot, chatID, messageID, text, models.ParseModeHTML, keyboard)
+}
+
+func (that *Interaction) sendOrEditParsedMessage(ctx context.Context, bot *tg.Bot, chatID int64, messageID int, text string, parseMode models.ParseMode, keyboard *models.InlineKeyboardMarkup) error {
+	if messageID == 0 {
+		params := &tg.SendMessageParams{ChatID: chatID, Text: text, ParseMode: parseMode}
+		if keyboard != nil {
+			params.ReplyMarkup = keyboard
+		}
+
+		if _, err := that.sendMessage(ctx, bot, params); err != nil {
+			return fmt.Errorf("send message: %w", err)
+		}
+
+		return nil
+	}
+
+	params := &tg.EditMessageTextParams{ChatID: chatID, MessageID: messageID, Text: text, ParseMode: parseMode}
+	if keyboard != nil {
+		params.ReplyMarkup = keyboard
+	}
+
+	if _, err := that.editMessageText(ctx, bot, params); err != nil {
+		return fmt.Errorf("edit message: %w", err)
+	}
+
+	return nil
+}
+
+func isPriceAlertField(field string) bool {
+	return field == model.PriceAlertFieldSell || field == model.PriceAlertFieldPurchase
+}
+
+func isPriceAlertDirection(direction string) bool {
+	return direction == model.PriceAlertBelow || direction == model.PriceAlertAbove
+}
@@ -0,0 +1,222 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tg "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"goldie/internal/interaction/telegram/chart"
+	"goldie/internal/model"
+)
+
+// chartMaxWeights limits the panels of one chart, so it stays readable on the phone screen.
+const chartMaxWeights = 4
+
+// errNoChartPrices is returned when none of the weights has prices over the period.
+var errNoChartPrices = errors.New("no prices to chart")
+
+// handlerChart sends the chart of the weights over the period, the command is /chart [weights] [period].
+// The smallest weight and the year are charted by default, the alert2 purchase dates of the chat are marked.
+func (that *Interaction) handlerChart(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerChart", "user_id", update.Message.From.ID)
+
+	chatID := update.Message.Chat.ID
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+
+	prices, err := that.pricesRepository.GetLatestPrices(ctx)
+	if err != nil {
+		log.Error("failed to get prices", "error", err)
+		return
+	}
+
+	if len(prices) == 0 {
+		if _, err = that.sendLocaledMessage(ctx, bot, update, "noPricesMessage"); err != nil {
+			log.Error("failed to send message", "error", err)
+		}
+		return
+	}
+
+	weights := make([]float64, 0, len(prices))
+	for _, price := range prices {
+		weights = append(weights, price.Weight)
+	}
+
+	selected, period, ok := parseWeightsArgs(strings.Fields(update.Message.Text)[1:], weights, model.ChartPeriods)
+	if !ok || len(selected) > chartMaxWeights {
+		if _, err = that.sendLocaledMessage(ctx, bot, update, "chartUsage", "Weights", joinWeights(weights), "Max", strconv.Itoa(chartMaxWeights)); err != nil {
+			log.Error("failed to send chart usage", "error", err)
+		}
+		return
+	}
+
+	if len(selected) == 0 {
+		selected = []float64{slices.Min(weights)}
+	}
+
+	if period == "" {
+		period = model.HistoryPeriodYear
+	}
+
+	alerts, err := that.chatsRepository.ListAlert2Subscriptions(ctx, chatID)
+	if err != nil {
+		log.Error("failed to get alert2 subscriptions", "error", err)
+		return
+	}
+
+	marks := make([]time.Time, 0, len(alerts))
+	for _, alert := range alerts {
+		marks = append(marks, alert.PurchaseDate)
+	}
+
+	err = that.sendChart(ctx, bot, chatID, languageCode, prices[0].Date, selected, period, marks)
+	if errors.Is(err, errNoChartPrices) {
+		if _, err = that.sendLocaledMessage(ctx, bot, update, "chartEmpty", "Weights", joinWeights(selected)); err != nil {
+			log.Error("failed to send message", "error", err)
+		}
+		return
+	}
+
+	if err != nil {
+		log.Error("failed to send chart", "error", err)
+	}
+}
+
+// SendDigestChart sends the chart of the last month to the chat after its weekly digest. The weights of the portfolio
+// are charted or the smallest weight if the portfolio is empty, the alert2 purchase dates of the chat are marked.
+// The holdings and the alert2 subscriptions of the chat must be loaded.
+func (that *Interaction) SendDigestChart(ctx context.Context, chat *model.TgChat) error {
+	prices, err := that.pricesRepository.GetLatestPrices(ctx)
+	if err != nil {
+		return fmt.Errorf("get prices: %w", err)
+	}
+
+	if len(prices) == 0 {
+		return errNoChartPrices
+	}
+
+	var weights []float64
+	for _, holding := range chat.Holdings {
+		if len(weights) < chartMaxWeights && !slices.Contains(weights, holding.Weight) {
+			weights = append(weights, holding.Weight)
+		}
+	}
+
+	if len(weights) == 0 {
+		weights = []float64{prices[0].Weight}
+		for _, price := range prices {
+			weights[0] = min(weights[0], price.Weight)
+		}
+	}
+
+	marks := make([]time.Time, 0, len(chat.Alerts2))
+	for _, alert := range chat.Alerts2 {
+		marks = append(marks, alert.PurchaseDate)
+	}
+
+	return that.sendChart(ctx, that.TgBot, chat.SourceID, chat.GetLanguageCode(), prices[0].Date, weights, model.HistoryPeriodMonth, marks)
+}
+
+// sendChart sends the chart of the weights over the period which ends with the date. The panels are drawn once
+// per weight, period, date and revision of the prices, the marks are drawn on every chart.
+func (that *Interaction) sendChart(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, end time.Time, weights []float64, period string, marks []time.Time) error {
+	begin := model.HistoryBegin(period, end)
+
+	revision, err := that.pricesRepository.GetPricesRevision(ctx, model.PriceSourceNBKR)
+	if err != nil {
+		return fmt.Errorf("get prices revision: %w", err)
+	}
+
+	panels := make([]*chart.Panel, 0, len(weights))
+	for _, weight := range weights {
+		panel, err := that.charts.Panel(weight, period, end, revision, func() (*chart.Panel, error) {
+			prices, err := that.pricesRepository.GetWeightPricesBetween(ctx, model.PriceSourceNBKR, weight, begin, end.AddDate(0, 0, 1))
+			if err != nil {
+				return nil, fmt.Errorf("get weight prices: %w", err)
+			}
+
+			// The weight without prices is cached too, so it isn't fetched again
+			if len(prices) == 0 {
+				return nil, nil
+			}
+
+			return chart.NewPanel(weight, prices, begin, end), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if panel != nil {
+			panels = append(panels, panel)
+		}
+	}
+
+	if len(panels) == 0 {
+		return errNoChartPrices
+	}
+
+	image, err := chart.Render(panels, marks)
+	if err != nil {
+		return fmt.Errorf("render chart: %w", err)
+	}
+
+	caption, err := that.renderChartCaption(languageCode, weights, period, begin, end, marks)
+	if err != nil {
+		return err
+	}
+
+	_, err = that.sendPhoto(ctx, bot, &tg.SendPhotoParams{
+		ChatID:    chatID,
+		Caption:   caption,
+		ParseMode: models.ParseModeHTML,
+	}, "chart.png", image)
+	if err != nil {
+		return fmt.Errorf("send chart: %w", err)
+	}
+
+	return nil
+}
+
+// renderChartCaption returns the title and the legend of the chart, the marks are explained if any is charted.
+func (that *Interaction) renderChartCaption(languageCode string, weights []float64, period string, begin, end time.Time, marks []time.Time) (string, error) {
+	title, err := that.renderLocaledMessage(languageCode, "chartTitle."+period, "Weights", joinWeights(weights))
+	if err != nil {
+		return "", err
+	}
+
+	legend, err := that.renderLocaledMessage(languageCode, "chartLegend")
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{"<b>" + title + "</b>", legend}
+	for _, mark := range marks {
+		if !mark.Before(begin) && !mark.After(end) {
+			note, err := that.renderLocaledMessage(languageCode, "chartMarks")
+			if err != nil {
+				return "", err
+			}
+
+			lines = append(lines, note)
+			break
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// joinWeights returns the weights separated by commas.
+func joinWeights(weights []float64) string {
+	names := make([]string, 0, len(weights))
+	for _, weight := range weights {
+		names = append(names, strconv.FormatFloat(weight, 'g', -1, 64))
+	}
+
+	return strings.Join(names, ", ")
+}
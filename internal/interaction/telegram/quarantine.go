@@ -0,0 +1,131 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tg "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"goldie/internal/model"
+)
+
+// quarantinePageSize is the number of the pending prices shown at once.
+const quarantinePageSize = 10
+
+type QuarantineRepository interface {
+	ListPendingQuarantinedPrices(ctx context.Context, limit int) ([]*model.QuarantinedPrice, int64, error)
+	ApproveQuarantinedPrice(ctx context.Context, id int64) (*model.QuarantinedPrice, error)
+	RejectQuarantinedPrice(ctx context.Context, id int64) (*model.QuarantinedPrice, error)
+}
+
+// isAdmin reports whether the user can run the admin commands.
+func (that *Interaction) isAdmin(userID int64) bool {
+	return slices.Contains(that.adminIDs, userID)
+}
+
+// handlerQuarantine shows the pending quarantined prices to the admin. The admin messages aren't localized,
+// they are in English like the other operational messages.
+func (that *Interaction) handlerQuarantine(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerQuarantine", "user_id", update.Message.From.ID)
+
+	if that.quarantineRepository == nil || !that.isAdmin(update.Message.From.ID) {
+		log.Warn("quarantine command is not allowed")
+		return
+	}
+
+	if err := that.sendQuarantinePage(ctx, bot, update.Message.Chat.ID, 0); err != nil {
+		log.Error("failed to send quarantine page", "error", err)
+	}
+}
+
+func (that *Interaction) handlerQuarantineCallback(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerQuarantineCallback")
+
+	if update.CallbackQuery == nil || update.CallbackQuery.Message.Message == nil {
+		return
+	}
+
+	if that.quarantineRepository == nil || !that.isAdmin(update.CallbackQuery.From.ID) {
+		log.Warn("quarantine callback is not allowed", "user_id", update.CallbackQuery.From.ID)
+		return
+	}
+
+	action, idStr, _ := strings.Cut(strings.TrimPrefix(update.CallbackQuery.Data, quarantineCallbackPrefix), ":")
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return
+	}
+
+	var price *model.QuarantinedPrice
+
+	switch action {
+	case "approve":
+		price, err = that.quarantineRepository.ApproveQuarantinedPrice(ctx, id)
+	case "reject":
+		price, err = that.quarantineRepository.RejectQuarantinedPrice(ctx, id)
+	default:
+		return
+	}
+
+	callbackText := fmt.Sprintf("#%d is already reviewed", id)
+	if err != nil {
+		log.Error("failed to review quarantined price", "error", err, "id", id)
+	} else {
+		log.Info("quarantined price is reviewed", "id", id, "status", price.Status, "user_id", update.CallbackQuery.From.ID)
+		callbackText = fmt.Sprintf("#%d is %s", id, price.Status)
+	}
+
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	messageID := update.CallbackQuery.Message.Message.ID
+
+	if err = that.sendQuarantinePage(ctx, bot, chatID, messageID); err != nil {
+		log.Error("failed to update quarantine page", "error", err)
+	}
+
+	if _, err = bot.AnswerCallbackQuery(ctx, &tg.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID, Text: callbackText}); err != nil {
+		log.Error("failed to answer quarantine callback", "error", err)
+	}
+}
+
+func (that *Interaction) sendQuarantinePage(ctx context.Context, bot *tg.Bot, chatID int64, messageID int) error {
+	prices, total, err := that.quarantineRepository.ListPendingQuarantinedPrices(ctx, quarantinePageSize)
+	if err != nil {
+		return fmt.Errorf("list pending quarantined prices: %w", err)
+	}
+
+	text := "No quarantined prices to review."
+	var keyboard *models.InlineKeyboardMarkup
+
+	if len(prices) > 0 {
+		lines := []string{fmt.Sprintf("Quarantined prices (%d pending):", total)}
+		rows := make([][]models.InlineKeyboardButton, 0, len(prices))
+
+		for _, price := range prices {
+			if price.IsMissing() {
+				lines = append(lines, fmt.Sprintf("#%d %s %s %g g: missing\n%s",
+					price.ID, price.Source, price.Date.Format(time.DateOnly), price.Weight, price.Reason,
+				))
+			} else {
+				lines = append(lines, fmt.Sprintf("#%d %s %s %g g: purchase %.2f, sell %.2f\n%s",
+					price.ID, price.Source, price.Date.Format(time.DateOnly), price.Weight, price.PurchasePrice, price.SellPrice, price.Reason,
+				))
+			}
+
+			rows = append(rows, []models.InlineKeyboardButton{
+				{Text: fmt.Sprintf("✅ Approve #%d", price.ID), CallbackData: fmt.Sprintf("%sapprove:%d", quarantineCallbackPrefix, price.ID)},
+				{Text: fmt.Sprintf("❌ Reject #%d", price.ID), CallbackData: fmt.Sprintf("%sreject:%d", quarantineCallbackPrefix, price.ID)},
+			})
+		}
+
+		text = strings.Join(lines, "\n\n")
+		keyboard = &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+	}
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, keyboard)
+}
@@ -2,14 +2,18 @@ package telegram
 
 import (
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"goldie/internal/model"
 )
 
 // PricesToString returns a string representation of the prices to send to the user.
-func (that *Interaction) PricesToString(languageCode string, prices []*model.GoldPrice) string {
+// If the rate is given, the prices are converted into its currency.
+func (that *Interaction) PricesToString(languageCode string, prices []*model.GoldPrice, rate *model.CurrencyRate) string {
 	sort.SliceStable(prices, func(i, j int) bool {
 		if prices[i].Date.Equal(prices[j].Date) {
 			return prices[i].Weight < prices[j].Weight
@@ -31,15 +35,18 @@ func (that *Interaction) PricesToString(languageCode string, prices []*model.Gol
 		if !p.Date.Equal(currentDate) {
 			break
 		}
-		sb.WriteString(fmt.Sprintf("%-8.4g %-12.2f %-12.2f\n", p.Weight, p.PurchasePrice, p.SellPrice))
+		sb.WriteString(fmt.Sprintf("%-8.4g %-12.2f %-12.2f\n", p.Weight, convertPrice(p.PurchasePrice, rate), convertPrice(p.SellPrice, rate)))
 	}
 
 	sb.WriteString("</pre>")
+	sb.WriteString(that.currencyRateLine(languageCode, "pricesInCurrencyLine", rate))
 	return sb.String()
 }
 
 // PricesWithGainToString returns a string representation of the prices to send to the user.
-func (that *Interaction) PricesWithGainToString(languageCode string, prices []*model.GoldPrice, buyingPrices []*model.GoldPrice) string {
+// The realizable view shows the gain of selling the bars back at today's buyback price, the market view shows
+// the change of the sell price. If both rates are given, the gain in their currency is shown next to the gain in KGS.
+func (that *Interaction) PricesWithGainToString(languageCode string, view string, prices []*model.GoldPrice, buyingPrices []*model.GoldPrice, rate *model.CurrencyRate, buyingRate *model.CurrencyRate) string {
 	sort.SliceStable(prices, func(i, j int) bool {
 		if prices[i].Date.Equal(prices[j].Date) {
 			return prices[i].Weight < prices[j].Weight
@@ -47,6 +54,71 @@ func (that *Interaction) PricesWithGainToString(languageCode string, prices []*m
 		return prices[i].Date.After(prices[j].Date)
 	})
 
+	if view == model.GainViewMarket {
+		return that.marketGainToString(languageCode, prices, buyingPrices, rate, buyingRate)
+	}
+
+	currentDate := prices[0].Date
+	title, _ := that.renderLocaledMessage(languageCode, "goldPricesTitle", "Date", currentDate.Format("2006-01-02"))
+	headerWeight, _ := that.renderLocaledMessage(languageCode, "columnWeight")
+	headerBuyback, _ := that.renderLocaledMessage(languageCode, "columnBuyback")
+	headerBreakEven, _ := that.renderLocaledMessage(languageCode, "columnBreakEven")
+	headerProfit, _ := that.renderLocaledMessage(languageCode, "columnProfit")
+	headerGain, _ := that.renderLocaledMessage(languageCode, "columnGain")
+
+	withCurrencyGain := rate != nil && buyingRate != nil
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>%s</b>\n<pre>\n", title))
+	if withCurrencyGain {
+		headerCurrencyGain, _ := that.renderLocaledMessage(languageCode, "columnGainCurrency", "Currency", rate.Currency)
+		sb.WriteString(fmt.Sprintf("%-8s %-12s %-12s %-12s %-12s %-12s\n", headerWeight, headerBuyback, headerBreakEven, headerProfit, headerGain, headerCurrencyGain))
+	} else {
+		sb.WriteString(fmt.Sprintf("%-8s %-12s %-12s %-12s %-12s\n", headerWeight, headerBuyback, headerBreakEven, headerProfit, headerGain))
+	}
+
+	weightLookup := make(map[float64]*model.GoldPrice, len(prices))
+	for _, bp := range buyingPrices {
+		weightLookup[bp.Weight] = bp
+	}
+
+	for _, p := range prices {
+		if !p.Date.Equal(currentDate) {
+			break
+		}
+
+		bp, ok := weightLookup[p.Weight]
+		if !ok {
+			continue
+		}
+
+		// The bar bought at the sell price is sold back at the buyback price, so the paid price is the break-even
+		profit := p.PurchasePrice - bp.SellPrice
+		gain := profit / bp.SellPrice * 100
+		if !withCurrencyGain {
+			sb.WriteString(fmt.Sprintf("%-8.4g %-12.2f %-12.2f %-12.2f %-12.2f\n", p.Weight, p.PurchasePrice, bp.SellPrice, profit, gain))
+			continue
+		}
+
+		buyingSellPrice := convertPrice(bp.SellPrice, buyingRate)
+		currencyGain := (convertPrice(p.PurchasePrice, rate) - buyingSellPrice) / buyingSellPrice * 100
+		sb.WriteString(fmt.Sprintf("%-8.4g %-12.2f %-12.2f %-12.2f %-12.2f %-12.2f\n", p.Weight, p.PurchasePrice, bp.SellPrice, profit, gain, currencyGain))
+	}
+
+	sb.WriteString("</pre>")
+
+	note, _ := that.renderLocaledMessage(languageCode, "realizableGainNote")
+	sb.WriteString("\n<i>" + note + "</i>")
+
+	if withCurrencyGain {
+		sb.WriteString(that.currencyRateLine(languageCode, "currencyRateLine", rate))
+	}
+	sb.WriteString(that.missingBuyingRateLine(languageCode, rate, buyingRate, buyingPrices))
+	return sb.String()
+}
+
+// marketGainToString returns the prices with the change of the sell price since the purchase, the prices are sorted.
+func (that *Interaction) marketGainToString(languageCode string, prices []*model.GoldPrice, buyingPrices []*model.GoldPrice, rate *model.CurrencyRate, buyingRate *model.CurrencyRate) string {
 	currentDate := prices[0].Date
 	title, _ := that.renderLocaledMessage(languageCode, "goldPricesTitle", "Date", currentDate.Format("2006-01-02"))
 	headerWeight, _ := that.renderLocaledMessage(languageCode, "columnWeight")
@@ -54,9 +126,16 @@ func (that *Interaction) PricesWithGainToString(languageCode string, prices []*m
 	headerSell, _ := that.renderLocaledMessage(languageCode, "columnSell")
 	headerGain, _ := that.renderLocaledMessage(languageCode, "columnGain")
 
+	withCurrencyGain := rate != nil && buyingRate != nil
+
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("<b>%s</b>\n<pre>\n", title))
-	sb.WriteString(fmt.Sprintf("%-8s %-12s %-12s %-12s\n", headerWeight, headerBuy, headerSell, headerGain))
+	if withCurrencyGain {
+		headerCurrencyGain, _ := that.renderLocaledMessage(languageCode, "columnGainCurrency", "Currency", rate.Currency)
+		sb.WriteString(fmt.Sprintf("%-8s %-12s %-12s %-12s %-12s\n", headerWeight, headerBuy, headerSell, headerGain, headerCurrencyGain))
+	} else {
+		sb.WriteString(fmt.Sprintf("%-8s %-12s %-12s %-12s\n", headerWeight, headerBuy, headerSell, headerGain))
+	}
 
 	weightLookup := make(map[float64]*model.GoldPrice, len(prices))
 	for _, bp := range buyingPrices {
@@ -72,9 +151,351 @@ func (that *Interaction) PricesWithGainToString(languageCode string, prices []*m
 
 		// Calculate gain in percents
 		gain := (p.SellPrice - bp.SellPrice) / bp.SellPrice * 100
-		sb.WriteString(fmt.Sprintf("%-8.4g %-12.2f %-12.2f %-12.2f\n", p.Weight, p.PurchasePrice, bp.SellPrice, gain))
+		if !withCurrencyGain {
+			sb.WriteString(fmt.Sprintf("%-8.4g %-12.2f %-12.2f %-12.2f\n", p.Weight, p.PurchasePrice, bp.SellPrice, gain))
+			continue
+		}
+
+		// Calculate gain in percents in the chosen currency to take the KGS depreciation into account
+		buyingSellPrice := convertPrice(bp.SellPrice, buyingRate)
+		currencyGain := (convertPrice(p.SellPrice, rate) - buyingSellPrice) / buyingSellPrice * 100
+		sb.WriteString(fmt.Sprintf("%-8.4g %-12.2f %-12.2f %-12.2f %-12.2f\n", p.Weight, p.PurchasePrice, bp.SellPrice, gain, currencyGain))
 	}
 
 	sb.WriteString("</pre>")
+	if withCurrencyGain {
+		sb.WriteString(that.currencyRateLine(languageCode, "currencyRateLine", rate))
+	}
+	sb.WriteString(that.missingBuyingRateLine(languageCode, rate, buyingRate, buyingPrices))
+	return sb.String()
+}
+
+// currencyRateLine returns a line with the exchange rate used to convert the prices.
+func (that *Interaction) currencyRateLine(languageCode string, messageID string, rate *model.CurrencyRate) string {
+	if rate == nil {
+		return ""
+	}
+
+	line, _ := that.renderLocaledMessage(languageCode, messageID,
+		"Currency", rate.Currency,
+		"Rate", strconv.FormatFloat(rate.Rate, 'f', 4, 64),
+		"Date", rate.Date.Format("2006-01-02"))
+
+	return "\n<i>" + line + "</i>"
+}
+
+// missingBuyingRateLine returns a line explaining that the gain in the chat currency can't be shown, because
+// there is no exchange rate stored for the purchase date. It's empty if the rate is known or the currency is KGS.
+func (that *Interaction) missingBuyingRateLine(languageCode string, rate *model.CurrencyRate, buyingRate *model.CurrencyRate, buyingPrices []*model.GoldPrice) string {
+	if rate == nil || buyingRate != nil || len(buyingPrices) == 0 {
+		return ""
+	}
+
+	line, _ := that.renderLocaledMessage(languageCode, "missingBuyingRateLine",
+		"Currency", rate.Currency,
+		"Date", buyingPrices[0].Date.Format("2006-01-02"))
+
+	return "\n<i>" + line + "</i>"
+}
+
+// convertPrice converts the price in KGS into the rate currency.
+func convertPrice(price float64, rate *model.CurrencyRate) float64 {
+	if rate == nil || rate.Rate == 0 {
+		return price
+	}
+
+	return price / rate.Rate
+}
+
+// PriceAlertToString returns the notification about the price crossing the alert threshold.
+func (that *Interaction) PriceAlertToString(languageCode string, alert *model.TgChatPriceAlert, price *model.GoldPrice) string {
+	field, _ := that.renderLocaledMessage(languageCode, "priceAlert.field."+alert.Field)
+	direction, _ := that.renderLocaledMessage(languageCode, "priceAlert.direction."+alert.Direction)
+
+	text, _ := that.renderLocaledMessage(languageCode, "priceAlertFired",
+		"Weight", strconv.FormatFloat(alert.Weight, 'g', 6, 64),
+		"Field", field,
+		"Direction", direction,
+		"Threshold", strconv.FormatFloat(alert.Threshold, 'f', 2, 64),
+		"Price", strconv.FormatFloat(alert.PriceOf(price), 'f', 2, 64),
+		"Date", price.Date.Format("2006-01-02"))
+
+	return text
+}
+
+// MoveAlertsToString returns the notification about the sell prices which moved more than the alerts percent.
+func (that *Interaction) MoveAlertsToString(languageCode string, moves []*model.PriceMove) string {
+	title, _ := that.renderLocaledMessage(languageCode, "moveAlertsTitle", "Date", moves[0].Price.Date.Format("2006-01-02"))
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>%s</b>", title))
+
+	for _, move := range moves {
+		arrow, direction := "📈", "moveAlert.direction.up"
+		if move.MovedPercent < 0 {
+			arrow, direction = "📉", "moveAlert.direction.down"
+		}
+
+		directionText, _ := that.renderLocaledMessage(languageCode, direction)
+
+		var baseline string
+		if move.Alert.Baseline == model.MoveAlertBaselineAverage {
+			baseline, _ = that.renderLocaledMessage(languageCode, "moveAlert.baseline.average", "Days", strconv.Itoa(move.Alert.Days))
+		} else {
+			baseline, _ = that.renderLocaledMessage(languageCode, "moveAlert.baseline.previous", "Date", move.BaseDate.Format("2006-01-02"))
+		}
+
+		line, _ := that.renderLocaledMessage(languageCode, "moveAlertLine",
+			"Arrow", arrow,
+			"Weight", strconv.FormatFloat(move.Alert.Weight, 'g', 6, 64),
+			"Direction", directionText,
+			"Move", strconv.FormatFloat(math.Abs(move.MovedPercent), 'f', 2, 64),
+			"Price", strconv.FormatFloat(move.Price.SellPrice, 'f', 2, 64),
+			"BasePrice", strconv.FormatFloat(move.BasePrice, 'f', 2, 64),
+			"Baseline", baseline)
+
+		sb.WriteString("\n" + line)
+	}
+
+	return sb.String()
+}
+
+// SignalToString returns the notification about the fired technical-indicator signal with the explanation of it.
+func (that *Interaction) SignalToString(languageCode string, event *model.SignalEvent) string {
+	key := "signalFired." + event.Signal.Kind
+	if event.Direction != "" {
+		key += "." + event.Direction
+	}
+
+	var since string
+	if event.Signal.Since != nil {
+		since = event.Signal.Since.Format("2006-01-02")
+	}
+
+	text, _ := that.renderLocaledMessage(languageCode, key,
+		"Weight", strconv.FormatFloat(event.Signal.Weight, 'g', 6, 64),
+		"Period", strconv.Itoa(event.Signal.Period),
+		"Date", event.Price.Date.Format("2006-01-02"),
+		"Price", strconv.FormatFloat(event.Price.SellPrice, 'f', 2, 64),
+		"Value", strconv.FormatFloat(event.Value, 'f', 2, 64),
+		"ValueDate", event.ValueDate.Format("2006-01-02"),
+		"Percent", strconv.FormatFloat(math.Abs(event.Percent()), 'f', 2, 64),
+		"Since", since)
+	return text
+}
+
+// Alert2CrossingToString returns the notification about the break-even, the target or the stop-loss crossed by the alert2 position.
+func (that *Interaction) Alert2CrossingToString(languageCode string, crossing string, alert *model.TgChatAlert2, gain float64) string {
+	var level float64
+	switch {
+	case crossing == model.Alert2CrossingTarget && alert.TargetGain != nil:
+		level = *alert.TargetGain
+	case crossing == model.Alert2CrossingStop && alert.StopLoss != nil:
+		level = *alert.StopLoss
+	}
+
+	text, _ := that.renderLocaledMessage(languageCode, "alert2Crossing."+crossing,
+		"Date", alert.PurchaseDate.Format("2006-01-02"),
+		"Level", strconv.FormatFloat(level, 'f', -1, 64),
+		"Gain", strconv.FormatFloat(gain, 'f', 2, 64))
+	return text
+}
+
+// PortfolioToString returns the value of the holdings at the latest prices with the cost basis and the unrealized gain.
+func (that *Interaction) PortfolioToString(languageCode string, portfolio *model.Portfolio) string {
+	title, _ := that.renderLocaledMessage(languageCode, "portfolioTitle", "Date", portfolio.Date.Format("2006-01-02"))
+	headerWeight, _ := that.renderLocaledMessage(languageCode, "columnWeight")
+	headerQuantity, _ := that.renderLocaledMessage(languageCode, "columnQuantity")
+	headerCost, _ := that.renderLocaledMessage(languageCode, "columnCost")
+	headerValue, _ := that.renderLocaledMessage(languageCode, "columnValue")
+	headerGain, _ := that.renderLocaledMessage(languageCode, "columnGain")
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>%s</b>\n<pre>\n", title))
+	sb.WriteString(fmt.Sprintf("%-8s %-6s %-12s %-12s %-12s\n", headerWeight, headerQuantity, headerCost, headerValue, headerGain))
+
+	for _, line := range portfolio.Lines {
+		sb.WriteString(fmt.Sprintf("%-8.4g %-6d %-12.2f %-12.2f %-12.2f\n", line.Holding.Weight, line.Holding.Quantity, line.Cost, line.Value, line.GainPercent()))
+	}
+
+	sb.WriteString("</pre>")
+
+	totals, _ := that.renderLocaledMessage(languageCode, "portfolioTotals",
+		"Value", strconv.FormatFloat(portfolio.Value, 'f', 2, 64),
+		"Cost", strconv.FormatFloat(portfolio.Cost, 'f', 2, 64),
+		"Gain", strconv.FormatFloat(portfolio.Gain(), 'f', 2, 64),
+		"GainPercent", strconv.FormatFloat(portfolio.GainPercent(), 'f', 2, 64))
+	sb.WriteString("\n" + totals)
+
+	note, _ := that.renderLocaledMessage(languageCode, "portfolioNote."+portfolio.View)
+	sb.WriteString("\n<i>" + note + "</i>")
+
+	return sb.String()
+}
+
+// ResumedToString returns the welcome back message with the moves of the prices while the alerts were paused.
+func (that *Interaction) ResumedToString(languageCode string, pausedAt, pausedUntil time.Time, stats []*model.PeriodPriceStats) string {
+	title, _ := that.renderLocaledMessage(languageCode, "resumedTitle")
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>%s</b>\n", title))
+
+	if len(stats) == 0 {
+		text, _ := that.renderLocaledMessage(languageCode, "resumedNoPrices")
+		sb.WriteString(text)
+		return sb.String()
+	}
+
+	text, _ := that.renderLocaledMessage(languageCode, "resumedPeriod",
+		"Begin", pausedAt.Format("2006-01-02"),
+		"End", pausedUntil.Format("2006-01-02"))
+	sb.WriteString(text + "\n")
+	sb.WriteString(that.periodStatsToString(languageCode, stats))
+
+	return sb.String()
+}
+
+// periodStatsToString returns the table of the open, close, high and low sell prices of every weight.
+func (that *Interaction) periodStatsToString(languageCode string, stats []*model.PeriodPriceStats) string {
+	headerWeight, _ := that.renderLocaledMessage(languageCode, "columnWeight")
+	headerOpen, _ := that.renderLocaledMessage(languageCode, "columnOpen")
+	headerClose, _ := that.renderLocaledMessage(languageCode, "columnClose")
+	headerHigh, _ := that.renderLocaledMessage(languageCode, "columnHigh")
+	headerLow, _ := that.renderLocaledMessage(languageCode, "columnLow")
+	headerChange, _ := that.renderLocaledMessage(languageCode, "columnChange")
+
+	var sb strings.Builder
+	sb.WriteString("<pre>\n")
+	sb.WriteString(fmt.Sprintf("%-8s %-12s %-12s %-12s %-12s %-12s\n", headerWeight, headerOpen, headerClose, headerHigh, headerLow, headerChange))
+
+	for _, s := range stats {
+		sb.WriteString(fmt.Sprintf("%-8.4g %-12.2f %-12.2f %-12.2f %-12.2f %-12.2f\n", s.Weight, s.Open, s.Close, s.High, s.Low, s.ChangePercent()))
+	}
+
+	sb.WriteString("</pre>")
+	return sb.String()
+}
+
+// DigestToString returns the weekly or monthly digest of the prices with the moves of the alert2 positions.
+func (that *Interaction) DigestToString(languageCode string, digest *model.PriceDigest, positions []*model.PositionMove) string {
+	title, _ := that.renderLocaledMessage(languageCode, "digestTitle."+digest.Kind,
+		"Begin", digest.Begin.Format("2006-01-02"),
+		"End", digest.End.AddDate(0, 0, -1).Format("2006-01-02"))
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>%s</b>\n", title))
+	sb.WriteString(that.periodStatsToString(languageCode, digest.Stats))
+
+	for _, day := range []struct {
+		messageID string
+		change    *model.DailyChange
+	}{{"digestBestDay", digest.BestDay}, {"digestWorstDay", digest.WorstDay}} {
+		if day.change == nil {
+			continue
+		}
+
+		line, _ := that.renderLocaledMessage(languageCode, day.messageID,
+			"Date", day.change.Date.Format("2006-01-02"),
+			"Change", strconv.FormatFloat(day.change.Percent, 'f', 2, 64))
+		sb.WriteString("\n" + line)
+	}
+
+	if len(positions) > 0 {
+		positionsTitle, _ := that.renderLocaledMessage(languageCode, "digestPositionsTitle")
+		sb.WriteString(fmt.Sprintf("\n\n<b>%s</b>", positionsTitle))
+
+		for _, position := range positions {
+			line, _ := that.renderLocaledMessage(languageCode, "digestPositionLine",
+				"Date", position.PurchaseDate.Format("2006-01-02"),
+				"OpenGain", strconv.FormatFloat(position.OpenGain, 'f', 2, 64),
+				"CloseGain", strconv.FormatFloat(position.CloseGain, 'f', 2, 64))
+			sb.WriteString("\n" + line)
+		}
+	}
+
+	return sb.String()
+}
+
+const (
+	// historySparklineWidth limits the points of the sparkline, the longer periods are averaged into it.
+	historySparklineWidth = 30
+	// historyTableRows limits the rows of the history table, the dates are picked evenly from the period.
+	historyTableRows = 8
+)
+
+// sparklineBlocks are the bars of the sparkline from the lowest to the highest value.
+var sparklineBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// HistoryToString returns the sparkline and the table of the prices of one weight over the period.
+// The prices are ordered by date and aren't empty.
+func (that *Interaction) HistoryToString(languageCode string, weight float64, period string, prices []*model.GoldPrice) string {
+	title, _ := that.renderLocaledMessage(languageCode, "historyTitle."+period, "Weight", strconv.FormatFloat(weight, 'g', 6, 64))
+	headerDate, _ := that.renderLocaledMessage(languageCode, "columnDate")
+	headerBuy, _ := that.renderLocaledMessage(languageCode, "columnPurchase")
+	headerSell, _ := that.renderLocaledMessage(languageCode, "columnSell")
+
+	values := model.SellPrices(prices)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>%s</b>\n", title))
+	sb.WriteString(fmt.Sprintf("<code>%s</code>\n<pre>\n", sparkline(values, historySparklineWidth)))
+	sb.WriteString(fmt.Sprintf("%-12s %-12s %-12s\n", headerDate, headerBuy, headerSell))
+
+	rows := min(len(prices), historyTableRows)
+	for row := 0; row < rows; row++ {
+		// The first and the last dates are always shown
+		index := 0
+		if rows > 1 {
+			index = row * (len(prices) - 1) / (rows - 1)
+		}
+
+		p := prices[index]
+		sb.WriteString(fmt.Sprintf("%-12s %-12.2f %-12.2f\n", p.Date.Format("2006-01-02"), p.PurchasePrice, p.SellPrice))
+	}
+
+	sb.WriteString("</pre>")
+
+	var change float64
+	if values[0] != 0 {
+		change = (values[len(values)-1] - values[0]) / values[0] * 100
+	}
+
+	summary, _ := that.renderLocaledMessage(languageCode, "historySummary",
+		"Change", strconv.FormatFloat(change, 'f', 2, 64),
+		"High", strconv.FormatFloat(values[model.Highest(values)], 'f', 2, 64),
+		"Low", strconv.FormatFloat(values[model.Lowest(values)], 'f', 2, 64))
+	sb.WriteString("\n" + summary)
+
+	return sb.String()
+}
+
+// sparkline draws the values with the block characters, the values are averaged into the width if there are more.
+func sparkline(values []float64, width int) string {
+	if len(values) > width {
+		averaged := make([]float64, 0, width)
+		for i := 0; i < width; i++ {
+			bucket := values[i*len(values)/width : (i+1)*len(values)/width]
+
+			var sum float64
+			for _, value := range bucket {
+				sum += value
+			}
+			averaged = append(averaged, sum/float64(len(bucket)))
+		}
+		values = averaged
+	}
+
+	low, high := values[model.Lowest(values)], values[model.Highest(values)]
+
+	var sb strings.Builder
+	for _, value := range values {
+		// The flat prices are drawn in the middle
+		level := len(sparklineBlocks) / 2
+		if high > low {
+			level = int(math.Round((value - low) / (high - low) * float64(len(sparklineBlocks)-1)))
+		}
+		sb.WriteRune(sparklineBlocks[level])
+	}
+
 	return sb.String()
 }
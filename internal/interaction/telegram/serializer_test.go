@@ -0,0 +1,213 @@
+package telegram_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/interaction/telegram"
+	"goldie/internal/model"
+	"goldie/locales"
+	botMock "goldie/mocks/bot"
+	"goldie/testing/suite"
+)
+
+func Test_PricesWithGainToString(t *testing.T) {
+	_, st := suite.New(t)
+
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	interaction := telegram.NewInteraction(st.Logger, "token", botMock.NewMockHttpClient(t), bundle, nil, nil)
+
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	prices := []*model.GoldPrice{{Date: date, Weight: 1, PurchasePrice: 10200, SellPrice: 11000}}
+	buyingPrices := []*model.GoldPrice{{Date: date.AddDate(0, -1, 0), Weight: 1, PurchasePrice: 9500, SellPrice: 10000}}
+
+	t.Run("should show the gain of selling back at the buyback price", func(t *testing.T) {
+		text := interaction.PricesWithGainToString("en", model.GainViewRealizable, prices, buyingPrices, nil, nil)
+
+		require.Equal(t, "<b>Gold prices on (2025-03-10)</b>\n<pre>\n"+
+			"Gram     Buyback      Break-even   Profit       Gain (%)    \n"+
+			"1        10200.00     10000.00     200.00       2.00        \n"+
+			"</pre>\n<i>The gain if you sell the bars back today at the buyback price, the break-even is the price you paid.</i>", text)
+	})
+
+	t.Run("should show the change of the sell price in the market view", func(t *testing.T) {
+		text := interaction.PricesWithGainToString("en", model.GainViewMarket, prices, buyingPrices, nil, nil)
+
+		require.Equal(t, "<b>Gold prices on (2025-03-10)</b>\n<pre>\n"+
+			"Gram     Purchase     Sell         Gain (%)    \n"+
+			"1        10200.00     10000.00     10.00       \n"+
+			"</pre>", text)
+	})
+
+	t.Run("should explain the missing rate of the purchase date", func(t *testing.T) {
+		rate := &model.CurrencyRate{Date: date, Currency: "USD", Rate: 87.5}
+
+		text := interaction.PricesWithGainToString("en", model.GainViewMarket, prices, buyingPrices, rate, nil)
+
+		require.Equal(t, "<b>Gold prices on (2025-03-10)</b>\n<pre>\n"+
+			"Gram     Purchase     Sell         Gain (%)    \n"+
+			"1        10200.00     10000.00     10.00       \n"+
+			"</pre>\n<i>There is no USD rate for the purchase date 2025-02-10, so the gain in USD isn't shown.</i>", text)
+	})
+}
+
+func Test_PortfolioToString(t *testing.T) {
+	_, st := suite.New(t)
+
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	interaction := telegram.NewInteraction(st.Logger, "token", botMock.NewMockHttpClient(t), bundle, nil, nil)
+
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	pricePaid := 98000.0
+	holdings := []*model.TgChatHolding{
+		{Weight: 10, Quantity: 3, BuyingPrice: &model.GoldPrice{Weight: 10, SellPrice: 100000}},
+		{Weight: 10, Quantity: 1, PricePaid: &pricePaid},
+	}
+	prices := []*model.GoldPrice{{Date: date, Weight: 10, PurchasePrice: 105000, SellPrice: 110000}}
+
+	t.Run("should show the value, the cost basis and the unrealized gain", func(t *testing.T) {
+		text := interaction.PortfolioToString("en", model.NewPortfolio(holdings, prices, model.GainViewRealizable))
+
+		require.Equal(t, "<b>💼 Your portfolio on 2025-03-10</b>\n<pre>\n"+
+			"Gram     Qty    Cost         Value        Gain (%)    \n"+
+			"10       3      300000.00    315000.00    5.00        \n"+
+			"10       1      98000.00     105000.00    7.14        \n"+
+			"</pre>\nTotal value: 420000.00 KGS\nCost basis: 398000.00 KGS\nUnrealized gain: 22000.00 KGS (5.53%)\n"+
+			"<i>The bars are valued at today's buyback price, the cost is the price you paid or the NBKR sell price on the purchase date.</i>", text)
+	})
+}
+
+func Test_Alert2CrossingToString(t *testing.T) {
+	_, st := suite.New(t)
+
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	interaction := telegram.NewInteraction(st.Logger, "token", botMock.NewMockHttpClient(t), bundle, nil, nil)
+
+	target, stop := 10.0, 5.5
+	alert := &model.TgChatAlert2{PurchaseDate: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), TargetGain: &target, StopLoss: &stop}
+
+	require.Equal(t, "✅ Your bars bought on 2025-03-10 are above the break-even now, the gain is 0.52%.",
+		interaction.Alert2CrossingToString("en", model.Alert2CrossingBreakEven, alert, 0.5234))
+	require.Equal(t, "🎯 Your bars bought on 2025-03-10 reached the target gain of 10%, the gain is 10.20%.",
+		interaction.Alert2CrossingToString("en", model.Alert2CrossingTarget, alert, 10.2))
+	require.Equal(t, "🛑 Your bars bought on 2025-03-10 hit the stop-loss of -5.5%, the gain is -6.00%.",
+		interaction.Alert2CrossingToString("en", model.Alert2CrossingStop, alert, -6))
+}
+
+func Test_SignalToString(t *testing.T) {
+	_, st := suite.New(t)
+
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	interaction := telegram.NewInteraction(st.Logger, "token", botMock.NewMockHttpClient(t), bundle, nil, nil)
+
+	price := &model.GoldPrice{Date: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), Weight: 1, SellPrice: 9180}
+
+	t.Run("should explain the crossing of the moving average", func(t *testing.T) {
+		event := &model.SignalEvent{
+			Signal:    &model.TgChatSignal{Weight: 1, Kind: model.SignalKindSMA, Period: 50},
+			Price:     price,
+			Direction: model.SignalStateAbove,
+			Value:     9000,
+		}
+
+		require.Equal(t, "📈 The 1 g bar sell price crossed above its 50-day simple moving average on 2025-03-10: 9180.00 KGS vs 9000.00 KGS (2.00% above).\n"+
+			"The moving average smooths out the daily noise. The price rising above it often means the trend turns up.",
+			interaction.SignalToString("en", event))
+	})
+
+	t.Run("should show the previous low since the purchase", func(t *testing.T) {
+		since := time.Date(2024, 11, 4, 0, 0, 0, 0, time.UTC)
+		event := &model.SignalEvent{
+			Signal:    &model.TgChatSignal{Weight: 1, Kind: model.SignalKindPurchaseLow, Since: &since},
+			Price:     price,
+			Value:     9200,
+			ValueDate: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+		}
+
+		require.Equal(t, "🔻 The 1 g bar sell price hit its lowest level since your purchase on 2024-11-04: 9180.00 KGS on 2025-03-10, 0.22% below the previous low of 9200.00 KGS on 2025-01-15.",
+			interaction.SignalToString("en", event))
+	})
+}
+
+func Test_ResumedToString(t *testing.T) {
+	_, st := suite.New(t)
+
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	interaction := telegram.NewInteraction(st.Logger, "token", botMock.NewMockHttpClient(t), bundle, nil, nil)
+
+	pausedAt := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	pausedUntil := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should show the moves of the prices while paused", func(t *testing.T) {
+		text := interaction.ResumedToString("en", pausedAt, pausedUntil, []*model.PeriodPriceStats{
+			{Weight: 1, Open: 10000, Close: 10500, High: 10600, Low: 9900},
+		})
+
+		require.Equal(t, "<b>👋 Welcome back! Your alerts are on again.</b>\n"+
+			"While they were paused from 2025-03-03 to 2025-03-10, the sell prices moved like this:\n<pre>\n"+
+			"Gram     Open         Close        High         Low          Change (%)  \n"+
+			"1        10000.00     10500.00     10600.00     9900.00      5.00        \n"+
+			"</pre>", text)
+	})
+
+	t.Run("should tell that there were no new prices", func(t *testing.T) {
+		text := interaction.ResumedToString("en", pausedAt, pausedUntil, nil)
+
+		require.Equal(t, "<b>👋 Welcome back! Your alerts are on again.</b>\nThere were no new prices while they were paused.", text)
+	})
+}
+
+func Test_HistoryToString(t *testing.T) {
+	_, st := suite.New(t)
+
+	bundle, err := locales.GetBundle(st.BaseDir + "/")
+	require.NoError(t, err)
+
+	interaction := telegram.NewInteraction(st.Logger, "token", botMock.NewMockHttpClient(t), bundle, nil, nil)
+
+	history := func(sellPrices ...float64) []*model.GoldPrice {
+		prices := make([]*model.GoldPrice, 0, len(sellPrices))
+		for i, sellPrice := range sellPrices {
+			prices = append(prices, &model.GoldPrice{Date: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i), Weight: 1, PurchasePrice: sellPrice - 10, SellPrice: sellPrice})
+		}
+		return prices
+	}
+
+	t.Run("should show every price of the short period", func(t *testing.T) {
+		text := interaction.HistoryToString("en", 1, model.HistoryPeriodWeek, history(100, 110, 105))
+
+		require.Equal(t, "<b>📈 The 1 g bar over the last week</b>\n<code>▁█▅</code>\n<pre>\n"+
+			"Date         Purchase     Sell        \n"+
+			"2025-03-01   90.00        100.00      \n"+
+			"2025-03-02   100.00       110.00      \n"+
+			"2025-03-03   95.00        105.00      \n"+
+			"</pre>\nSell price change: 5.00%, high: 110.00 KGS, low: 100.00 KGS", text)
+	})
+
+	t.Run("should squeeze the long period into the sparkline and the table", func(t *testing.T) {
+		sellPrices := make([]float64, 0, 60)
+		for i := range 60 {
+			sellPrices = append(sellPrices, float64(100+i))
+		}
+
+		text := interaction.HistoryToString("en", 1, model.HistoryPeriodYear, history(sellPrices...))
+
+		require.Contains(t, text, "<code>▁▁▁▂▂▂▂▃▃▃▃▄▄▄▄▅▅▅▅▆▆▆▆▇▇▇▇███</code>")
+		require.Equal(t, 8, strings.Count(text, "2025-"))
+		require.Contains(t, text, "2025-03-01   90.00        100.00")
+		require.Contains(t, text, "2025-04-29   149.00       159.00")
+	})
+}
@@ -0,0 +1,284 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tg "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"goldie/internal/model"
+)
+
+const signalCallbackPrefix = "sg:"
+
+// signalPresets are the signals offered to the user, the drawdown percent is typed by the user.
+var signalPresets = []struct {
+	kind   string
+	period int
+}{
+	{kind: model.SignalKindSMA, period: 50},
+	{kind: model.SignalKindSMA, period: 200},
+	{kind: model.SignalKindEMA, period: 20},
+	{kind: model.SignalKindEMA, period: 50},
+	{kind: model.SignalKindHigh, period: 52},
+	{kind: model.SignalKindLow, period: 52},
+	{kind: model.SignalKindDrawdown, period: 52},
+}
+
+func (that *Interaction) handlerSignals(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerSignals", "user_id", update.Message.From.ID)
+
+	that.drafts.delete(update.Message.Chat.ID)
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+
+	if err := that.sendSignalsPage(ctx, bot, update.Message.Chat.ID, languageCode, 0); err != nil {
+		log.Error("failed to send signals", "error", err)
+	}
+}
+
+func (that *Interaction) handlerSignalCallback(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerSignalCallback")
+
+	if update.CallbackQuery == nil || update.CallbackQuery.Message.Message == nil {
+		return
+	}
+
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	messageID := update.CallbackQuery.Message.Message.ID
+	languageCode := that.getLanguageCode(ctx, update.CallbackQuery.Message.Message.Chat, update.CallbackQuery.Message.Message.From)
+
+	parts := strings.Split(strings.TrimPrefix(update.CallbackQuery.Data, signalCallbackPrefix), ":")
+
+	var err error
+
+	switch {
+	case parts[0] == "new":
+		err = that.sendAlertWeights(ctx, bot, chatID, languageCode, messageID, signalCallbackPrefix+"w:")
+	case parts[0] == "w" && len(parts) == 2:
+		weight, parseErr := strconv.ParseFloat(parts[1], 64)
+		if parseErr != nil {
+			return
+		}
+
+		err = that.sendSignalKinds(ctx, bot, chatID, languageCode, messageID, weight)
+	case parts[0] == "k" && len(parts) == 4:
+		weight, weightErr := strconv.ParseFloat(parts[1], 64)
+		period, periodErr := strconv.Atoi(parts[3])
+		if weightErr != nil || periodErr != nil || period <= 0 {
+			return
+		}
+
+		signal := &model.TgChatSignal{Weight: weight, Kind: parts[2], Period: period}
+		switch signal.Kind {
+		case model.SignalKindDrawdown:
+			that.drafts.set(chatID, signal)
+			err = that.sendSignalPercentRequest(ctx, bot, chatID, languageCode, messageID, signal)
+		case model.SignalKindSMA, model.SignalKindEMA, model.SignalKindHigh, model.SignalKindLow:
+			err = that.createSignal(ctx, bot, chatID, languageCode, messageID, signal)
+		default:
+			return
+		}
+	case parts[0] == "p" && len(parts) == 3:
+		// The lowest price since the purchase date of the alert2 subscription
+		weight, weightErr := strconv.ParseFloat(parts[1], 64)
+		subscriptionID, idErr := strconv.ParseInt(parts[2], 10, 64)
+		if weightErr != nil || idErr != nil {
+			return
+		}
+
+		alert, getErr := that.chatsRepository.GetAlert2Subscription(ctx, chatID, subscriptionID)
+		if getErr != nil || alert == nil {
+			err = getErr
+			break
+		}
+
+		err = that.createSignal(ctx, bot, chatID, languageCode, messageID, &model.TgChatSignal{Weight: weight, Kind: model.SignalKindPurchaseLow, Since: &alert.PurchaseDate})
+	case parts[0] == "del" && len(parts) == 2:
+		signalID, parseErr := strconv.ParseInt(parts[1], 10, 64)
+		if parseErr != nil {
+			return
+		}
+
+		if err = that.chatsRepository.DeleteSignal(ctx, chatID, signalID); err == nil {
+			err = that.sendSignalsPage(ctx, bot, chatID, languageCode, messageID)
+		}
+	default:
+		return
+	}
+
+	if err != nil {
+		log.Error("failed to process signal callback", "error", err)
+	}
+
+	if _, err = bot.AnswerCallbackQuery(ctx, &tg.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID}); err != nil {
+		log.Error("failed to answer signal callback", "error", err)
+	}
+}
+
+// matchSignalPercent matches the plain text messages of the chats which are creating a drawdown signal.
+func (that *Interaction) matchSignalPercent(update *models.Update) bool {
+	if update.Message == nil || update.Message.Text == "" || strings.HasPrefix(update.Message.Text, "/") {
+		return false
+	}
+
+	_, ok := that.drafts.get(update.Message.Chat.ID).(*model.TgChatSignal)
+	return ok
+}
+
+func (that *Interaction) handlerSignalPercent(ctx context.Context, bot *tg.Bot, update *models.Update) {
+	log := that.logger.With("method", "handlerSignalPercent", "user_id", update.Message.From.ID)
+
+	chatID := update.Message.Chat.ID
+	draft, ok := that.drafts.get(chatID).(*model.TgChatSignal)
+	if !ok {
+		return
+	}
+
+	percent, err := parsePositiveNumber(strings.TrimSuffix(strings.TrimSpace(update.Message.Text), "%"))
+	if err != nil || percent >= 100 {
+		if _, err = that.sendLocaledMessage(ctx, bot, update, "signalInvalidPercent"); err != nil {
+			log.Error("failed to send invalid percent message", "error", err)
+		}
+		return
+	}
+
+	languageCode := that.getLanguageCode(ctx, update.Message.Chat, update.Message.From)
+	signal := &model.TgChatSignal{Weight: draft.Weight, Kind: draft.Kind, Period: draft.Period, Percent: percent}
+	if err = that.createSignal(ctx, bot, chatID, languageCode, 0, signal); err != nil {
+		log.Error("failed to create signal", "error", err)
+	}
+}
+
+func (that *Interaction) createSignal(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, signal *model.TgChatSignal) error {
+	if err := that.chatsRepository.CreateSignal(ctx, chatID, signal); err != nil {
+		return fmt.Errorf("create signal: %w", err)
+	}
+
+	that.drafts.delete(chatID)
+
+	text, err := that.renderLocaledMessage(languageCode, "signalCreated",
+		"Weight", strconv.FormatFloat(signal.Weight, 'g', 6, 64),
+		"Signal", that.renderSignalName(languageCode, signal))
+	if err != nil {
+		return err
+	}
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, nil)
+}
+
+func (that *Interaction) sendSignalsPage(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int) error {
+	signals, err := that.chatsRepository.ListSignals(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("list signals: %w", err)
+	}
+
+	text, err := that.renderLocaledMessage(languageCode, "signalsEmpty")
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]models.InlineKeyboardButton, 0, len(signals)+1)
+
+	if len(signals) > 0 {
+		title, titleErr := that.renderLocaledMessage(languageCode, "signalsListTitle")
+		if titleErr != nil {
+			return titleErr
+		}
+
+		lines := []string{title}
+		for i, signal := range signals {
+			line, lineErr := that.renderLocaledMessage(languageCode, "signalsItem",
+				"Index", strconv.Itoa(i+1),
+				"Weight", strconv.FormatFloat(signal.Weight, 'g', 6, 64),
+				"Signal", that.renderSignalName(languageCode, signal))
+			if lineErr != nil {
+				return lineErr
+			}
+			lines = append(lines, line)
+
+			deleteLabel, labelErr := that.renderLocaledMessage(languageCode, "priceAlertDeleteButton", "Index", strconv.Itoa(i+1))
+			if labelErr != nil {
+				return labelErr
+			}
+			rows = append(rows, []models.InlineKeyboardButton{{Text: deleteLabel, CallbackData: fmt.Sprintf("%sdel:%d", signalCallbackPrefix, signal.ID)}})
+		}
+
+		text = strings.Join(lines, "\n")
+	}
+
+	newLabel, err := that.renderLocaledMessage(languageCode, "signalNewButton")
+	if err != nil {
+		return err
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: newLabel, CallbackData: signalCallbackPrefix + "new"}})
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+// sendSignalKinds shows the signal presets and the lowest price since every alert2 purchase date of the chat.
+func (that *Interaction) sendSignalKinds(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, weight float64) error {
+	weightStr := strconv.FormatFloat(weight, 'g', -1, 64)
+
+	text, err := that.renderLocaledMessage(languageCode, "signalChooseKind", "Weight", strconv.FormatFloat(weight, 'g', 6, 64))
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]models.InlineKeyboardButton, 0, len(signalPresets))
+	for _, preset := range signalPresets {
+		label, labelErr := that.renderLocaledMessage(languageCode, "signal.button."+preset.kind, "Period", strconv.Itoa(preset.period))
+		if labelErr != nil {
+			return labelErr
+		}
+
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: label, CallbackData: fmt.Sprintf("%sk:%s:%s:%d", signalCallbackPrefix, weightStr, preset.kind, preset.period)},
+		})
+	}
+
+	subscriptions, err := that.chatsRepository.ListAlert2Subscriptions(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("list alert2 subscriptions: %w", err)
+	}
+
+	for _, subscription := range subscriptions {
+		label, labelErr := that.renderLocaledMessage(languageCode, "signal.button.purchase_low", "Date", subscription.PurchaseDate.Format("2006-01-02"))
+		if labelErr != nil {
+			return labelErr
+		}
+
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: label, CallbackData: fmt.Sprintf("%sp:%s:%d", signalCallbackPrefix, weightStr, subscription.ID)},
+		})
+	}
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+func (that *Interaction) sendSignalPercentRequest(ctx context.Context, bot *tg.Bot, chatID int64, languageCode string, messageID int, draft *model.TgChatSignal) error {
+	text, err := that.renderLocaledMessage(languageCode, "signalEnterPercent",
+		"Weight", strconv.FormatFloat(draft.Weight, 'g', 6, 64),
+		"Period", strconv.Itoa(draft.Period))
+	if err != nil {
+		return err
+	}
+
+	return that.sendOrEditMessage(ctx, bot, chatID, messageID, text, nil)
+}
+
+// renderSignalName returns the localized description of the signal.
+func (that *Interaction) renderSignalName(languageCode string, signal *model.TgChatSignal) string {
+	var since string
+	if signal.Since != nil {
+		since = signal.Since.Format("2006-01-02")
+	}
+
+	name, _ := that.renderLocaledMessage(languageCode, "signal.name."+signal.Kind,
+		"Period", strconv.Itoa(signal.Period),
+		"Percent", strconv.FormatFloat(signal.Percent, 'f', -1, 64),
+		"Date", since)
+	return name
+}
@@ -1,7 +1,9 @@
 package telegram
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -13,6 +15,7 @@ import (
 
 	"goldie/internal/config"
 	"goldie/internal/interaction/telegram/calendar"
+	"goldie/internal/interaction/telegram/chart"
 	"goldie/internal/model"
 )
 
@@ -20,7 +23,10 @@ var ErrWrongNumberOfArguments = fmt.Errorf("wrong number of arguments")
 
 type PricesRepository interface {
 	GetLatestPrices(ctx context.Context) ([]*model.GoldPrice, error)
+	GetWeightPricesBetween(ctx context.Context, source string, weight float64, begin, end time.Time) ([]*model.GoldPrice, error)
 	GetFirstPriceDate(ctx context.Context) (time.Time, error)
+	GetPricesRevision(ctx context.Context, source string) (time.Time, error)
+	GetLatestCurrencyRate(ctx context.Context, currency string) (*model.CurrencyRate, error)
 }
 
 type ChatsRepository interface {
@@ -29,26 +35,84 @@ type ChatsRepository interface {
 	DisableAlerts(ctx context.Context, chatID int64) error
 	DeleteChat(ctx context.Context, chatID int64) error
 	SetLanguage(ctx context.Context, chatID int64, language string) error
+	SetCurrency(ctx context.Context, chatID int64, currency string) error
+	SetTimezone(ctx context.Context, chatID int64, timezone string) error
+	SetDeliveryTime(ctx context.Context, chatID int64, deliveryTime string) error
+	SetDigest(ctx context.Context, chatID int64, kind string, enabled bool) error
+	SetDigestChart(ctx context.Context, chatID int64, enabled bool) error
+	SetGainView(ctx context.Context, chatID int64, view string) error
 	GetLanguage(ctx context.Context, chatID int64) (string, error)
 	GetChat(ctx context.Context, chatID int64) (*model.TgChat, error)
 	ListAlert2Subscriptions(ctx context.Context, chatID int64) ([]*model.TgChatAlert2, error)
 	ListAlert2SubscriptionsPaged(ctx context.Context, chatID int64, limit, offset int) ([]*model.TgChatAlert2, int64, error)
 	DeleteAlert2Subscription(ctx context.Context, chatID int64, subscriptionID int64) error
+	GetAlert2Subscription(ctx context.Context, chatID int64, subscriptionID int64) (*model.TgChatAlert2, error)
+	SetAlert2Mode(ctx context.Context, chatID int64, subscriptionID int64, mode string) error
+	SetAlert2Thresholds(ctx context.Context, chatID int64, subscriptionID int64, targetGain, stopLoss *float64) error
+	CreatePriceAlert(ctx context.Context, chatID int64, alert *model.TgChatPriceAlert) error
+	ListPriceAlerts(ctx context.Context, chatID int64) ([]*model.TgChatPriceAlert, error)
+	DeletePriceAlert(ctx context.Context, chatID int64, alertID int64) error
+	CreateMoveAlert(ctx context.Context, chatID int64, alert *model.TgChatMoveAlert) error
+	ListMoveAlerts(ctx context.Context, chatID int64) ([]*model.TgChatMoveAlert, error)
+	DeleteMoveAlert(ctx context.Context, chatID int64, alertID int64) error
+	CreateSignal(ctx context.Context, chatID int64, signal *model.TgChatSignal) error
+	ListSignals(ctx context.Context, chatID int64) ([]*model.TgChatSignal, error)
+	DeleteSignal(ctx context.Context, chatID int64, signalID int64) error
+	CreateHolding(ctx context.Context, chatID int64, holding *model.TgChatHolding) error
+	ListHoldings(ctx context.Context, chatID int64) ([]*model.TgChatHolding, error)
+	DeleteHolding(ctx context.Context, chatID int64, holdingID int64) error
+	DeactivateChat(ctx context.Context, chatID int64, reason string) error
+	ReactivateChat(ctx context.Context, chatID int64) error
+	MigrateChat(ctx context.Context, fromChatID, toChatID int64) error
+	PauseChat(ctx context.Context, chatID int64, until time.Time) error
 }
 
 type Interaction struct {
 	logger           *slog.Logger
 	TgBot            *tg.Bot
 	cal              *calendar.Calendar
+	pauseCal         *calendar.Calendar
 	bundle           *i18n.Bundle
 	pricesRepository PricesRepository
 	chatsRepository  ChatsRepository
 	supportedLangs   map[string]struct{}
+	drafts           chatDrafts
+
+	adminIDs             []int64
+	quarantineRepository QuarantineRepository
+	outbox               *Outbox
+	charts               *chart.Cache
+}
+
+// Option configures the optional features of the Interaction.
+type Option func(that *Interaction)
+
+// WithAdmins sets the users allowed to run the admin commands.
+func WithAdmins(adminIDs []int64) Option {
+	return func(that *Interaction) {
+		that.adminIDs = adminIDs
+	}
+}
+
+// WithQuarantine enables the /quarantine admin command to review the suspicious prices.
+func WithQuarantine(repository QuarantineRepository) Option {
+	return func(that *Interaction) {
+		that.quarantineRepository = repository
+	}
+}
+
+// WithOutbox sends all the outbound messages through the rate limited outbox.
+func WithOutbox(outbox *Outbox) Option {
+	return func(that *Interaction) {
+		that.outbox = outbox
+	}
 }
 
 const (
-	languageCallbackPrefix = "lang:"
-	settingsCallbackPrefix = "settings:"
+	languageCallbackPrefix   = "lang:"
+	currencyCallbackPrefix   = "currency:"
+	settingsCallbackPrefix   = "settings:"
+	quarantineCallbackPrefix = "quarantine:"
 )
 
 var botCommandDefinitions = []struct {
@@ -57,7 +121,15 @@ var botCommandDefinitions = []struct {
 }{
 	{command: "start", descriptionLocale: "command.start.description"},
 	{command: "price", descriptionLocale: "command.price.description"},
+	{command: "history", descriptionLocale: "command.history.description"},
+	{command: "chart", descriptionLocale: "command.chart.description"},
+	{command: "currency", descriptionLocale: "command.currency.description"},
 	{command: "alert", descriptionLocale: "command.alert.description"},
+	{command: "pricealert", descriptionLocale: "command.pricealert.description"},
+	{command: "movealert", descriptionLocale: "command.movealert.description"},
+	{command: "signals", descriptionLocale: "command.signals.description"},
+	{command: "portfolio", descriptionLocale: "command.portfolio.description"},
+	{command: "pause", descriptionLocale: "command.pause.description"},
 	{command: "help", descriptionLocale: "command.help.description"},
 	{command: "info", descriptionLocale: "command.info.description"},
 	{command: "delete", descriptionLocale: "command.delete.description"},
@@ -65,7 +137,7 @@ var botCommandDefinitions = []struct {
 	{command: "stop", descriptionLocale: "command.stop.description"},
 }
 
-func NewInteraction(logger *slog.Logger, token string, client tg.HttpClient, bundle *i18n.Bundle, pricesRepository PricesRepository, chatsRepository ChatsRepository) *Interaction {
+func NewInteraction(logger *slog.Logger, token string, client tg.HttpClient, bundle *i18n.Bundle, pricesRepository PricesRepository, chatsRepository ChatsRepository, opts ...Option) *Interaction {
 	supportedLangs := make(map[string]struct{})
 	for _, tag := range bundle.LanguageTags() {
 		supportedLangs[tag.String()] = struct{}{}
@@ -77,33 +149,66 @@ func NewInteraction(logger *slog.Logger, token string, client tg.HttpClient, bun
 		pricesRepository: pricesRepository,
 		chatsRepository:  chatsRepository,
 		supportedLangs:   supportedLangs,
+		charts:           chart.NewCache(),
+	}
+
+	for _, opt := range opts {
+		opt(cnt)
 	}
 
-	opts := []tg.Option{
+	botOpts := []tg.Option{
 		tg.WithHTTPClient(time.Minute, client),
 		tg.WithSkipGetMe(),
 		tg.WithDefaultHandler(cnt.handler),
 	}
 
-	cal := calendar.New([]time.Weekday{time.Saturday, time.Sunday}, cnt.handlerAlert2SelectedDate, bundle)
+	// The calendars share the outbox with the other messages
+	cal := calendar.New([]time.Weekday{time.Saturday, time.Sunday}, cnt.handlerAlert2SelectedDate, bundle, calendar.WithSender(cnt.sendMessage, cnt.editMessageText))
+	pauseCal := calendar.New(nil, cnt.handlerPauseSelectedDate, bundle, calendar.WithPrefix(pauseCalendarPrefix), calendar.WithSender(cnt.sendMessage, cnt.editMessageText))
 
-	b, _ := tg.New(token, opts...)
+	b, _ := tg.New(token, botOpts...)
 	b.RegisterHandler(tg.HandlerTypeMessageText, "/start", tg.MatchTypeExact, cnt.handlerStart)
 	b.RegisterHandler(tg.HandlerTypeMessageText, "/price", tg.MatchTypeExact, cnt.handlerPrice)
+	b.RegisterHandler(tg.HandlerTypeMessageText, "history", tg.MatchTypeCommandStartOnly, cnt.handlerHistory)
+	b.RegisterHandler(tg.HandlerTypeMessageText, "chart", tg.MatchTypeCommandStartOnly, cnt.handlerChart)
+	b.RegisterHandler(tg.HandlerTypeMessageText, "/currency", tg.MatchTypeExact, cnt.handlerCurrency)
 	b.RegisterHandler(tg.HandlerTypeMessageText, "/alert", tg.MatchTypeExact, cnt.handlerAlert)
 	b.RegisterHandler(tg.HandlerTypeMessageText, "/alert1", tg.MatchTypeExact, cnt.handlerAlert1)
 	b.RegisterHandler(tg.HandlerTypeMessageText, "/alert2", tg.MatchTypeExact, cnt.handlerAlert2)
+	b.RegisterHandler(tg.HandlerTypeMessageText, "/pricealert", tg.MatchTypeExact, cnt.handlerPriceAlert)
+	b.RegisterHandler(tg.HandlerTypeMessageText, "/movealert", tg.MatchTypeExact, cnt.handlerMoveAlert)
+	b.RegisterHandler(tg.HandlerTypeMessageText, "/signals", tg.MatchTypeExact, cnt.handlerSignals)
+	b.RegisterHandler(tg.HandlerTypeMessageText, "/portfolio", tg.MatchTypeExact, cnt.handlerPortfolio)
+	b.RegisterHandler(tg.HandlerTypeMessageText, "/pause", tg.MatchTypeExact, cnt.handlerPause)
 	b.RegisterHandler(tg.HandlerTypeMessageText, "/help", tg.MatchTypeExact, cnt.handlerHelp)
 	b.RegisterHandler(tg.HandlerTypeMessageText, "/settings", tg.MatchTypeExact, cnt.handlerSettings)
 	b.RegisterHandler(tg.HandlerTypeMessageText, "/info", tg.MatchTypeExact, cnt.handlerInfo)
 	b.RegisterHandler(tg.HandlerTypeMessageText, "/delete", tg.MatchTypeExact, cnt.handlerDelete)
 	b.RegisterHandler(tg.HandlerTypeMessageText, "/stop", tg.MatchTypeExact, cnt.handlerStop)
+	b.RegisterHandler(tg.HandlerTypeMessageText, "/quarantine", tg.MatchTypeExact, cnt.handlerQuarantine)
 	b.RegisterHandler(tg.HandlerTypeCallbackQueryData, languageCallbackPrefix, tg.MatchTypePrefix, cnt.handlerLanguageSelection)
+	b.RegisterHandler(tg.HandlerTypeCallbackQueryData, currencyCallbackPrefix, tg.MatchTypePrefix, cnt.handlerCurrencySelection)
 	b.RegisterHandler(tg.HandlerTypeCallbackQueryData, calendar.Prefix, tg.MatchTypePrefix, cnt.handlerAlert2CalendarCallback)
 	b.RegisterHandler(tg.HandlerTypeCallbackQueryData, settingsCallbackPrefix, tg.MatchTypePrefix, cnt.handlerSettingsCallback)
+	b.RegisterHandlerMatchFunc(cnt.matchAlert2Level, cnt.handlerAlert2Level)
+	b.RegisterHandler(tg.HandlerTypeCallbackQueryData, quarantineCallbackPrefix, tg.MatchTypePrefix, cnt.handlerQuarantineCallback)
+	b.RegisterHandler(tg.HandlerTypeCallbackQueryData, priceAlertCallbackPrefix, tg.MatchTypePrefix, cnt.handlerPriceAlertCallback)
+	b.RegisterHandlerMatchFunc(cnt.matchPriceAlertThreshold, cnt.handlerPriceAlertThreshold)
+	b.RegisterHandler(tg.HandlerTypeCallbackQueryData, moveAlertCallbackPrefix, tg.MatchTypePrefix, cnt.handlerMoveAlertCallback)
+	b.RegisterHandlerMatchFunc(cnt.matchMoveAlertPercent, cnt.handlerMoveAlertPercent)
+	b.RegisterHandler(tg.HandlerTypeCallbackQueryData, signalCallbackPrefix, tg.MatchTypePrefix, cnt.handlerSignalCallback)
+	b.RegisterHandler(tg.HandlerTypeCallbackQueryData, historyCallbackPrefix, tg.MatchTypePrefix, cnt.handlerHistoryCallback)
+	b.RegisterHandlerMatchFunc(cnt.matchSignalPercent, cnt.handlerSignalPercent)
+	b.RegisterHandler(tg.HandlerTypeCallbackQueryData, portfolioCallbackPrefix, tg.MatchTypePrefix, cnt.handlerPortfolioCallback)
+	b.RegisterHandlerMatchFunc(cnt.matchHoldingInput, cnt.handlerHoldingInput)
+	b.RegisterHandler(tg.HandlerTypeCallbackQueryData, pauseCallbackPrefix, tg.MatchTypePrefix, cnt.handlerPauseCallback)
+	b.RegisterHandler(tg.HandlerTypeCallbackQueryData, pauseCalendarPrefix, tg.MatchTypePrefix, cnt.handlerPauseCalendarCallback)
+	b.RegisterHandlerMatchFunc(matchMyChatMember, cnt.handlerMyChatMember)
+	b.RegisterHandlerMatchFunc(matchChatMigration, cnt.handlerChatMigration)
 
 	cnt.TgBot = b
 	cnt.cal = cal
+	cnt.pauseCal = pauseCal
 	return cnt
 }
 
@@ -112,9 +217,84 @@ func (that *Interaction) Start(ctx context.Context) {
 	that.TgBot.Start(ctx)
 }
 
+// SendMessage sends the HTML message to the chat. The chat which can't receive messages anymore is deactivated
+// and model.ErrChatUnavailable is returned. The message to the upgraded group is sent again to its supergroup.
 func (that *Interaction) SendMessage(ctx context.Context, chatID int64, text string) error {
-	_, err := that.TgBot.SendMessage(ctx, &tg.SendMessageParams{ChatID: chatID, Text: text, ParseMode: models.ParseModeHTML})
-	return err
+	_, err := that.sendMessage(ctx, that.TgBot, &tg.SendMessageParams{ChatID: chatID, Text: text, ParseMode: models.ParseModeHTML})
+
+	var migrateErr *tg.MigrateError
+	if errors.As(err, &migrateErr) {
+		toChatID := int64(migrateErr.MigrateToChatID)
+		if err = that.migrateChat(ctx, chatID, toChatID); err != nil {
+			return err
+		}
+
+		chatID = toChatID
+		_, err = that.sendMessage(ctx, that.TgBot, &tg.SendMessageParams{ChatID: chatID, Text: text, ParseMode: models.ParseModeHTML})
+	}
+
+	reason := unavailableReason(err)
+	if reason == "" {
+		return err
+	}
+
+	that.logger.Info("deactivating unavailable chat", "chat_id", chatID, "reason", reason, "error", err)
+	if deactivateErr := that.chatsRepository.DeactivateChat(ctx, chatID, reason); deactivateErr != nil {
+		that.logger.Error("failed to deactivate chat", "error", deactivateErr, "chat_id", chatID)
+	}
+
+	return fmt.Errorf("%w: %w", model.ErrChatUnavailable, err)
+}
+
+// sendMessage sends the message through the outbox if it's configured.
+func (that *Interaction) sendMessage(ctx context.Context, bot *tg.Bot, params *tg.SendMessageParams) (*models.Message, error) {
+	var msg *models.Message
+
+	err := that.throttle(ctx, params.ChatID, func(ctx context.Context) error {
+		var err error
+		msg, err = bot.SendMessage(ctx, params)
+		return err
+	})
+
+	return msg, err
+}
+
+// editMessageText edits the message through the outbox if it's configured.
+func (that *Interaction) editMessageText(ctx context.Context, bot *tg.Bot, params *tg.EditMessageTextParams) (*models.Message, error) {
+	var msg *models.Message
+
+	err := that.throttle(ctx, params.ChatID, func(ctx context.Context) error {
+		var err error
+		msg, err = bot.EditMessageText(ctx, params)
+		return err
+	})
+
+	return msg, err
+}
+
+// sendPhoto uploads the photo through the outbox if it's configured. The file is read anew by every attempt,
+// so the retry after the flood limit uploads it whole.
+func (that *Interaction) sendPhoto(ctx context.Context, bot *tg.Bot, params *tg.SendPhotoParams, filename string, photo []byte) (*models.Message, error) {
+	var msg *models.Message
+
+	err := that.throttle(ctx, params.ChatID, func(ctx context.Context) error {
+		params.Photo = &models.InputFileUpload{Filename: filename, Data: bytes.NewReader(photo)}
+
+		var err error
+		msg, err = bot.SendPhoto(ctx, params)
+		return err
+	})
+
+	return msg, err
+}
+
+func (that *Interaction) throttle(ctx context.Context, chatID any, send func(ctx context.Context) error) error {
+	id, ok := chatID.(int64)
+	if that.outbox == nil || !ok {
+		return send(ctx)
+	}
+
+	return that.outbox.Send(ctx, id, send)
 }
 
 func (that *Interaction) handler(_ context.Context, _ *tg.Bot, update *models.Update) {
@@ -163,7 +343,7 @@ func (that *Interaction) sendLocaledMessage(ctx context.Context, bot *tg.Bot, up
 		return nil, fmt.Errorf("render localed message: %w", err)
 	}
 
-	msg, err := bot.SendMessage(ctx, &tg.SendMessageParams{ChatID: update.Message.Chat.ID, Text: text})
+	msg, err := that.sendMessage(ctx, bot, &tg.SendMessageParams{ChatID: update.Message.Chat.ID, Text: text})
 	if err != nil {
 		return nil, fmt.Errorf("send message to telegram user: %w", err)
 	}
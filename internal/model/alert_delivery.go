@@ -0,0 +1,63 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	AlertDeliveryPending = "pending"
+	AlertDeliverySent    = "sent"
+	AlertDeliveryFailed  = "failed"
+
+	AlertKindAlert1    = "alert1"
+	AlertKindMove      = "move"
+	AlertKindPortfolio = "portfolio"
+	AlertKindResumed   = "resumed"
+)
+
+// AlertDelivery records the alert message sent to the chat for the price date.
+// Unique index are ChatID, Kind and Date together, so the alert is never sent twice for the same date.
+type AlertDelivery struct {
+	ChatID        int64      `gorm:"column:chat_id;primaryKey"`
+	Chat          TgChat     `gorm:"foreignKey:ChatID;references:ID;constraint:OnDelete:CASCADE"`
+	Kind          string     `gorm:"column:kind;primaryKey"` // alert1, alert2:<subscription id>[:<crossing>], move, portfolio, resumed, digest:<kind>, signal:<signal id>, price_alert:<alert id>
+	Date          time.Time  `gorm:"column:date;primaryKey"`
+	Status        string     `gorm:"column:status;not null;index"` // pending, sent, failed
+	Text          string     `gorm:"column:text;not null"`         // kept to send the same message again
+	Attempts      int        `gorm:"column:attempts;not null;default:0"`
+	LastError     string     `gorm:"column:last_error"`
+	NextAttemptAt *time.Time `gorm:"column:next_attempt_at"`
+	SentAt        *time.Time `gorm:"column:sent_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (*AlertDelivery) TableName() string {
+	return "alert_deliveries"
+}
+
+// Alert2DeliveryKind returns the delivery kind of the alert2 subscription.
+func Alert2DeliveryKind(subscriptionID int64) string {
+	return fmt.Sprintf("alert2:%d", subscriptionID)
+}
+
+// Alert2CrossingDeliveryKind returns the delivery kind of the notification about the level crossed by the alert2 position.
+func Alert2CrossingDeliveryKind(subscriptionID int64, crossing string) string {
+	return fmt.Sprintf("alert2:%d:%s", subscriptionID, crossing)
+}
+
+// DigestDeliveryKind returns the delivery kind of the weekly or monthly digest.
+func DigestDeliveryKind(kind string) string {
+	return "digest:" + kind
+}
+
+// SignalDeliveryKind returns the delivery kind of the fired technical-indicator signal.
+func SignalDeliveryKind(signalID int64) string {
+	return fmt.Sprintf("signal:%d", signalID)
+}
+
+// PriceAlertDeliveryKind returns the delivery kind of the threshold alert crossed by the price.
+func PriceAlertDeliveryKind(alertID int64) string {
+	return fmt.Sprintf("price_alert:%d", alertID)
+}
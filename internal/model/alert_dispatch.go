@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// AlertDispatch records the price date the alerts are dispatched for.
+// Unique index are Source and Date together.
+type AlertDispatch struct {
+	Source       string    `gorm:"column:source;primaryKey"`
+	Date         time.Time `gorm:"column:date;primaryKey"`
+	DispatchedAt time.Time `gorm:"column:dispatched_at;not null"`
+}
+
+func (*AlertDispatch) TableName() string {
+	return "alert_dispatches"
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// ArchivedPage is a raw NBKR gold prices page kept to parse it again after a parser fix.
+// The page is addressed by the SHA-256 hash of its body, so the same content is stored once.
+type ArchivedPage struct {
+	Hash           string    `gorm:"column:hash;primaryKey"`
+	URL            string    `gorm:"column:url"`
+	BeginDate      time.Time `gorm:"column:begin_date"`
+	EndDate        time.Time `gorm:"column:end_date"`
+	FetchedAt      time.Time `gorm:"column:fetched_at;index"`
+	CompressedBody []byte    `gorm:"column:body"` // gzip
+	Body           string    `gorm:"-"`
+}
+
+func (*ArchivedPage) TableName() string {
+	return "nbkr_page_archive"
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const (
+	BackfillChunkPending = "pending"
+	BackfillChunkRunning = "running"
+	BackfillChunkDone    = "done"
+	BackfillChunkEmpty   = "empty" // the provider has no prices for the period
+	BackfillChunkFailed  = "failed"
+)
+
+// BackfillChunk describes the import progress of a historical period of the source prices.
+// Unique index are Source and BeginDate together.
+type BackfillChunk struct {
+	ID          int64     `gorm:"column:id;primaryKey"`
+	Source      string    `gorm:"column:source;not null;uniqueIndex:idx_backfill_chunk"`
+	BeginDate   time.Time `gorm:"column:begin_date;not null;uniqueIndex:idx_backfill_chunk"`
+	EndDate     time.Time `gorm:"column:end_date;not null"`
+	Status      string    `gorm:"column:status;not null;default:pending"` // pending, running, done, empty, failed
+	Attempts    int       `gorm:"column:attempts;not null;default:0"`
+	PricesCount int       `gorm:"column:prices_count;not null;default:0"`
+	LastError   string    `gorm:"column:last_error"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (*BackfillChunk) TableName() string {
+	return "backfill_chunks"
+}
+
+// IsFinished reports whether the chunk doesn't need to be imported again.
+func (that *BackfillChunk) IsFinished() bool {
+	return that.Status == BackfillChunkDone || that.Status == BackfillChunkEmpty
+}
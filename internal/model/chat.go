@@ -1,20 +1,53 @@
 package model
 
 import (
+	"errors"
 	"time"
+	_ "time/tzdata" // the chat timezones are loaded on the images without the system tzdata
 
 	"goldie/internal/config"
 )
 
+// ErrChatUnavailable is returned when the message can't be delivered to the chat anymore, e.g. the bot is blocked.
+var ErrChatUnavailable = errors.New("telegram chat is unavailable")
+
+const (
+	GainViewRealizable = "realizable" // the gain if the bars are sold back at today's buyback price
+	GainViewMarket     = "market"     // the change of the sell price since the purchase
+)
+
+const (
+	ChatDeactivatedBlocked  = "blocked"   // the user blocked the bot
+	ChatDeactivatedKicked   = "kicked"    // the bot is removed from the group
+	ChatDeactivatedLeft     = "left"      // the bot left the group
+	ChatDeactivatedDeleted  = "deleted"   // the user account is deleted
+	ChatDeactivatedNotFound = "not_found" // the chat doesn't exist anymore
+)
+
 // TgChat - represents a Telegram chat.
 type TgChat struct {
-	ID            int64           `gorm:"column:id;primaryKey"`
-	SourceID      int64           `gorm:"column:source_id;uniqueIndex"`
-	Language      string          `gorm:"column:language"` // en, ru
-	Alert1Enabled bool            `gorm:"column:alert1"`
-	CreatedAt     time.Time       `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt     time.Time       `gorm:"column:updated_at;autoUpdateTime"`
-	Alerts2       []*TgChatAlert2 `gorm:"-"`
+	ID            int64              `gorm:"column:id;primaryKey"`
+	SourceID      int64              `gorm:"column:source_id;uniqueIndex"`
+	Language      string             `gorm:"column:language"` // en, ru
+	Currency      string             `gorm:"column:currency"` // KGS, USD, EUR, RUB, KZT
+	Alert1Enabled bool               `gorm:"column:alert1"`
+	WeeklyDigest  bool               `gorm:"column:weekly_digest"`
+	MonthlyDigest bool               `gorm:"column:monthly_digest"`
+	DigestChart   bool               `gorm:"column:digest_chart"`  // the weekly digest comes with the price chart
+	GainView      string             `gorm:"column:gain_view"`     // realizable, market
+	Timezone      string             `gorm:"column:timezone"`      // IANA name, e.g. Asia/Bishkek
+	DeliveryTime  string             `gorm:"column:delivery_time"` // HH:MM in the chat timezone
+	AlertsDate    *time.Time         `gorm:"column:alerts_date"`   // the price date of the latest daily alerts
+	AlertsSentAt  *time.Time         `gorm:"column:alerts_sent_at"`
+	PausedAt      *time.Time         `gorm:"column:paused_at"`
+	PausedUntil   *time.Time         `gorm:"column:paused_until"`   // the alerts are suspended until then, the subscriptions are kept
+	DeactivatedAt *time.Time         `gorm:"column:deactivated_at"` // the chat doesn't receive the alerts until the user writes /start
+	DeactivatedBy string             `gorm:"column:deactivated_by"` // blocked, kicked, left, deleted, not_found
+	CreatedAt     time.Time          `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt     time.Time          `gorm:"column:updated_at;autoUpdateTime"`
+	Alerts2       []*TgChatAlert2    `gorm:"-"`
+	MoveAlerts    []*TgChatMoveAlert `gorm:"-"`
+	Holdings      []*TgChatHolding   `gorm:"-"`
 }
 
 func (*TgChat) TableName() string {
@@ -28,3 +61,85 @@ func (that *TgChat) GetLanguageCode() string {
 
 	return config.DefaultLanguageCode
 }
+
+// GetCurrency returns the display currency of the chat.
+func (that *TgChat) GetCurrency() string {
+	if that.Currency != "" {
+		return that.Currency
+	}
+
+	return CurrencyKGS
+}
+
+// GetGainView returns how the gain of the alert2 subscriptions is shown to the chat.
+func (that *TgChat) GetGainView() string {
+	if that.GainView != "" {
+		return that.GainView
+	}
+
+	return GainViewRealizable
+}
+
+// GetTimezone returns the IANA timezone name of the chat.
+func (that *TgChat) GetTimezone() string {
+	if that.Timezone != "" {
+		return that.Timezone
+	}
+
+	return config.DefaultTimezone
+}
+
+// GetLocation returns the location of the chat timezone, the unknown timezone falls back to the default one.
+func (that *TgChat) GetLocation() *time.Location {
+	if loc, err := time.LoadLocation(that.GetTimezone()); err == nil {
+		return loc
+	}
+
+	loc, _ := time.LoadLocation(config.DefaultTimezone)
+	return loc
+}
+
+// GetDeliveryTime returns the time of the day the chat receives the daily alerts.
+func (that *TgChat) GetDeliveryTime() string {
+	if that.DeliveryTime != "" {
+		return that.DeliveryTime
+	}
+
+	return config.DefaultDeliveryTime
+}
+
+// IsDeliveryTimeReached reports whether the delivery time of the chat has come today in the chat timezone.
+func (that *TgChat) IsDeliveryTimeReached(now time.Time) bool {
+	// HH:MM is compared as a string since both are zero padded
+	return now.In(that.GetLocation()).Format("15:04") >= that.GetDeliveryTime()
+}
+
+// IsAlertsDue reports whether the delivery time of the chat has come and the daily alerts aren't sent today yet.
+func (that *TgChat) IsAlertsDue(now time.Time) bool {
+	if !that.IsDeliveryTimeReached(now) {
+		return false
+	}
+
+	local := now.In(that.GetLocation())
+
+	if that.AlertsSentAt == nil {
+		return true
+	}
+
+	sentAt := that.AlertsSentAt.In(local.Location())
+	return sentAt.Year() != local.Year() || sentAt.YearDay() != local.YearDay()
+}
+
+// IsDigestDue reports whether the chat receives the digest of the kind now. The digest is sent on the first day
+// of the next period once the delivery time has come.
+func (that *TgChat) IsDigestDue(kind string, now time.Time) bool {
+	local := now.In(that.GetLocation())
+	_, end := DigestPeriod(kind, local)
+
+	return that.IsDeliveryTimeReached(now) && local.Day() == end.Day() && local.Month() == end.Month()
+}
+
+// IsPaused reports whether the alerts of the chat are suspended now.
+func (that *TgChat) IsPaused(now time.Time) bool {
+	return that.PausedUntil != nil && now.Before(*that.PausedUntil)
+}
@@ -4,17 +4,92 @@ import (
 	"time"
 )
 
+const (
+	Alert2ModeDaily    = "daily"    // the gain table with every price update
+	Alert2ModeCrossing = "crossing" // a notification once the position crosses the break-even, the target or the stop
+
+	Alert2CrossingBreakEven = "break_even"
+	Alert2CrossingTarget    = "target"
+	Alert2CrossingStop      = "stop"
+)
+
 // TgChatAlert2 represents alert2 subscription for a chat.
 type TgChatAlert2 struct {
-	ID           int64        `gorm:"column:id;primaryKey"`
-	ChatID       int64        `gorm:"column:chat_id;not null;uniqueIndex:idx_alert2_chat_date"`
-	Chat         TgChat       `gorm:"foreignKey:ChatID;references:ID;constraint:OnDelete:CASCADE"`
-	PurchaseDate time.Time    `gorm:"column:purchase_date;not null;uniqueIndex:idx_alert2_chat_date"`
-	CreatedAt    time.Time    `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt    time.Time    `gorm:"column:updated_at;autoUpdateTime"`
-	BuyingPrices []*GoldPrice `gorm:"-"`
+	ID                 int64        `gorm:"column:id;primaryKey"`
+	ChatID             int64        `gorm:"column:chat_id;not null;uniqueIndex:idx_alert2_chat_date"`
+	Chat               TgChat       `gorm:"foreignKey:ChatID;references:ID;constraint:OnDelete:CASCADE"`
+	PurchaseDate       time.Time    `gorm:"column:purchase_date;not null;uniqueIndex:idx_alert2_chat_date"`
+	Mode               string       `gorm:"column:mode"`        // daily, crossing
+	TargetGain         *float64     `gorm:"column:target_gain"` // in percents
+	StopLoss           *float64     `gorm:"column:stop_loss"`   // the loss in percents, e.g. 5 stops at -5%
+	BreakEvenCrossedAt *time.Time   `gorm:"column:break_even_crossed_at"`
+	TargetCrossedAt    *time.Time   `gorm:"column:target_crossed_at"`
+	StopCrossedAt      *time.Time   `gorm:"column:stop_crossed_at"`
+	CreatedAt          time.Time    `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt          time.Time    `gorm:"column:updated_at;autoUpdateTime"`
+	BuyingPrices       []*GoldPrice `gorm:"-"`
 }
 
 func (*TgChatAlert2) TableName() string {
 	return "tg_chat_alert2"
 }
+
+func (that *TgChatAlert2) GetMode() string {
+	if that.Mode == Alert2ModeCrossing {
+		return Alert2ModeCrossing
+	}
+
+	return Alert2ModeDaily
+}
+
+// Crossings returns the levels the position with the gain has crossed and which weren't notified about yet.
+func (that *TgChatAlert2) Crossings(gain float64) []string {
+	var crossings []string
+
+	if that.BreakEvenCrossedAt == nil && gain >= 0 {
+		crossings = append(crossings, Alert2CrossingBreakEven)
+	}
+
+	if that.TargetGain != nil && that.TargetCrossedAt == nil && gain >= *that.TargetGain {
+		crossings = append(crossings, Alert2CrossingTarget)
+	}
+
+	if that.StopLoss != nil && that.StopCrossedAt == nil && gain <= -*that.StopLoss {
+		crossings = append(crossings, Alert2CrossingStop)
+	}
+
+	return crossings
+}
+
+// PositionGain returns the gain in percents of the position of one bar of every weight bought at the buying prices.
+// The bars are valued at the buyback price in the realizable view and at the sell price in the market one.
+// It's false if none of the weights has both prices.
+func PositionGain(view string, prices []*GoldPrice, buyingPrices []*GoldPrice) (float64, bool) {
+	latestPrices := make(map[float64]*GoldPrice, len(prices))
+	for _, price := range prices {
+		if latest, ok := latestPrices[price.Weight]; !ok || price.Date.After(latest.Date) {
+			latestPrices[price.Weight] = price
+		}
+	}
+
+	var cost, value float64
+	for _, bp := range buyingPrices {
+		price, ok := latestPrices[bp.Weight]
+		if !ok {
+			continue
+		}
+
+		cost += bp.SellPrice
+		if view == GainViewMarket {
+			value += price.SellPrice
+		} else {
+			value += price.PurchasePrice
+		}
+	}
+
+	if cost == 0 {
+		return 0, false
+	}
+
+	return (value - cost) / cost * 100, true
+}
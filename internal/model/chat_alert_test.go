@@ -0,0 +1,77 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+)
+
+func Test_TgChatAlert2_Crossings(t *testing.T) {
+	// Given: The position with the target gain of 10% and the stop-loss of -5%
+	target, stop := 10.0, 5.0
+	crossedAt := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name  string
+		alert *model.TgChatAlert2
+		gain  float64
+		want  []string
+	}{
+		{name: "below the break-even", alert: &model.TgChatAlert2{TargetGain: &target, StopLoss: &stop}, gain: -1},
+		{name: "above the break-even", alert: &model.TgChatAlert2{TargetGain: &target}, gain: 1, want: []string{model.Alert2CrossingBreakEven}},
+		{name: "above the target at once", alert: &model.TgChatAlert2{TargetGain: &target}, gain: 12, want: []string{model.Alert2CrossingBreakEven, model.Alert2CrossingTarget}},
+		{name: "the break-even is already notified", alert: &model.TgChatAlert2{TargetGain: &target, BreakEvenCrossedAt: &crossedAt}, gain: 12, want: []string{model.Alert2CrossingTarget}},
+		{name: "the target is already notified", alert: &model.TgChatAlert2{TargetGain: &target, BreakEvenCrossedAt: &crossedAt, TargetCrossedAt: &crossedAt}, gain: 12},
+		{name: "the stop-loss is hit", alert: &model.TgChatAlert2{StopLoss: &stop}, gain: -5, want: []string{model.Alert2CrossingStop}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// When: The position reaches the gain
+			crossings := tc.alert.Crossings(tc.gain)
+
+			// Then: Only the levels which aren't notified yet should be crossed
+			require.Equal(t, tc.want, crossings)
+		})
+	}
+}
+
+func Test_PositionGain(t *testing.T) {
+	// Given: The prices went up by 5% at the buyback and by 10% at the sell price since the purchase
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	prices := []*model.GoldPrice{
+		{Date: date, Weight: 1, PurchasePrice: 10500, SellPrice: 11000},
+		{Date: date, Weight: 10, PurchasePrice: 105000, SellPrice: 110000},
+	}
+	buyingPrices := []*model.GoldPrice{
+		{Date: date.AddDate(0, -1, 0), Weight: 1, PurchasePrice: 9500, SellPrice: 10000},
+		{Date: date.AddDate(0, -1, 0), Weight: 10, PurchasePrice: 95000, SellPrice: 100000},
+	}
+
+	t.Run("should sell back at the buyback price in the realizable view", func(t *testing.T) {
+		// When: We count the gain
+		gain, ok := model.PositionGain(model.GainViewRealizable, prices, buyingPrices)
+
+		// Then: The gain should be counted from the buyback price
+		require.True(t, ok)
+		require.InDelta(t, 5, gain, 0.0001)
+	})
+
+	t.Run("should compare the sell prices in the market view", func(t *testing.T) {
+		// When: We count the gain
+		gain, ok := model.PositionGain(model.GainViewMarket, prices, buyingPrices)
+
+		// Then: The gain should be counted from the sell price
+		require.True(t, ok)
+		require.InDelta(t, 10, gain, 0.0001)
+	})
+
+	t.Run("shouldn't count the gain without the purchase prices", func(t *testing.T) {
+		// When: We count the gain without the purchase prices
+		_, ok := model.PositionGain(model.GainViewRealizable, prices, nil)
+
+		// Then: There should be no gain
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,113 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+)
+
+func Test_TgChat_IsAlertsDue(t *testing.T) {
+	// Given: Monday 05:30 UTC is 11:30 in Bishkek, 14:30 in Tokyo and 01:30 in New York
+	now := time.Date(2025, 3, 10, 5, 30, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+	earlierToday := now.Add(-time.Hour)
+
+	for _, tc := range []struct {
+		name string
+		chat *model.TgChat
+		want bool
+	}{
+		{name: "default time in the default timezone", chat: &model.TgChat{}, want: true},
+		{name: "delivery time hasn't come", chat: &model.TgChat{DeliveryTime: "12:00"}, want: false},
+		{name: "delivery time has come in the chat timezone", chat: &model.TgChat{Timezone: "Asia/Tokyo", DeliveryTime: "14:00"}, want: true},
+		{name: "delivery time hasn't come in the chat timezone", chat: &model.TgChat{Timezone: "America/New_York", DeliveryTime: "08:00"}, want: false},
+		{name: "sent yesterday", chat: &model.TgChat{DeliveryTime: "09:00", AlertsSentAt: &yesterday}, want: true},
+		{name: "already sent today", chat: &model.TgChat{DeliveryTime: "09:00", AlertsSentAt: &earlierToday}, want: false},
+		{name: "unknown timezone falls back to the default one", chat: &model.TgChat{Timezone: "Mars/Olympus", DeliveryTime: "11:00"}, want: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// When: We check the chat
+			due := tc.chat.IsAlertsDue(now)
+
+			// Then: The alerts should be due only after the delivery time once a day
+			require.Equal(t, tc.want, due)
+		})
+	}
+}
+
+func Test_TgChat_IsDeliveryTimeReached(t *testing.T) {
+	// Given: Monday 05:30 UTC is 11:30 in Bishkek and 14:30 in Tokyo
+	now := time.Date(2025, 3, 10, 5, 30, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name string
+		chat *model.TgChat
+		want bool
+	}{
+		{name: "default time in the default timezone", chat: &model.TgChat{}, want: true},
+		{name: "delivery time hasn't come", chat: &model.TgChat{DeliveryTime: "12:00"}, want: false},
+		{name: "delivery time is right now", chat: &model.TgChat{DeliveryTime: "11:30"}, want: true},
+		{name: "delivery time has come in the chat timezone", chat: &model.TgChat{Timezone: "Asia/Tokyo", DeliveryTime: "14:00"}, want: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// When: We check the chat
+			reached := tc.chat.IsDeliveryTimeReached(now)
+
+			// Then: The delivery time should be compared in the chat timezone
+			require.Equal(t, tc.want, reached)
+		})
+	}
+}
+
+func Test_TgChat_IsDigestDue(t *testing.T) {
+	// Given: Monday 05:30 UTC is Monday 11:30 in Bishkek and Sunday 22:30 in Los Angeles
+	now := time.Date(2025, 3, 10, 5, 30, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name string
+		kind string
+		chat *model.TgChat
+		want bool
+	}{
+		{name: "weekly after the delivery time", kind: model.DigestWeekly, chat: &model.TgChat{}, want: true},
+		{name: "weekly before the delivery time", kind: model.DigestWeekly, chat: &model.TgChat{DeliveryTime: "12:00"}, want: false},
+		{name: "weekly when it's still Sunday in the chat timezone", kind: model.DigestWeekly, chat: &model.TgChat{Timezone: "America/Los_Angeles", DeliveryTime: "06:00"}, want: false},
+		{name: "monthly in the middle of the month", kind: model.DigestMonthly, chat: &model.TgChat{}, want: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// When: We check the chat
+			due := tc.chat.IsDigestDue(tc.kind, now)
+
+			// Then: The digest should be due on the first day of the period after the delivery time
+			require.Equal(t, tc.want, due)
+		})
+	}
+}
+
+func Test_TgChat_IsPaused(t *testing.T) {
+	// Given: The pauses which end around now
+	now := time.Date(2025, 3, 10, 5, 30, 0, 0, time.UTC)
+	later, earlier := now.Add(time.Minute), now.Add(-time.Minute)
+
+	for _, tc := range []struct {
+		name string
+		chat *model.TgChat
+		want bool
+	}{
+		{name: "never paused", chat: &model.TgChat{}, want: false},
+		{name: "paused until later", chat: &model.TgChat{PausedUntil: &later}, want: true},
+		{name: "pause is over", chat: &model.TgChat{PausedUntil: &earlier}, want: false},
+		{name: "pause ends right now", chat: &model.TgChat{PausedUntil: &now}, want: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// When: We check the chat
+			paused := tc.chat.IsPaused(now)
+
+			// Then: The chat should be paused until the end of the pause
+			require.Equal(t, tc.want, paused)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// CurrencyKGS is the national currency, all the prices are stored in it.
+const CurrencyKGS = "KGS"
+
+// DisplayCurrencies are the currencies a chat can choose to see the prices in.
+var DisplayCurrencies = []string{CurrencyKGS, "USD", "EUR", "RUB", "KZT"}
+
+// CurrencyRate describes an official NBKR exchange rate.
+// Unique index are Date and Currency together.
+type CurrencyRate struct {
+	Date      time.Time `gorm:"column:date;uniqueIndex:date_currency"`
+	Currency  string    `gorm:"column:currency;uniqueIndex:date_currency"` // USD, EUR, RUB, KZT
+	Rate      float64   `gorm:"column:rate"`                               // KGS for one unit of the currency
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (*CurrencyRate) TableName() string {
+	return "currency_rates"
+}
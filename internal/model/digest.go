@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+const (
+	DigestWeekly  = "weekly"
+	DigestMonthly = "monthly"
+)
+
+// PeriodPriceStats describes how the sell price of the weight moved over the period.
+// The buyback prices at the open and the close are kept to calculate the realizable gain.
+type PeriodPriceStats struct {
+	Weight        float64   `gorm:"column:weight"`
+	OpenDate      time.Time `gorm:"column:open_date"`
+	CloseDate     time.Time `gorm:"column:close_date"`
+	Open          float64   `gorm:"column:open"`
+	Close         float64   `gorm:"column:close"`
+	High          float64   `gorm:"column:high"`
+	Low           float64   `gorm:"column:low"`
+	PurchaseOpen  float64   `gorm:"column:purchase_open"`
+	PurchaseClose float64   `gorm:"column:purchase_close"`
+}
+
+// ChangePercent returns the change of the sell price from the open to the close in percents.
+func (that *PeriodPriceStats) ChangePercent() float64 {
+	if that.Open == 0 {
+		return 0
+	}
+
+	return (that.Close - that.Open) / that.Open * 100
+}
+
+// DailyChange describes the average change of the sell prices of all weights on the date in percents.
+type DailyChange struct {
+	Date    time.Time `gorm:"column:date"`
+	Percent float64   `gorm:"column:percent"`
+}
+
+// PriceDigest summarizes the prices over the period.
+type PriceDigest struct {
+	Kind     string // weekly, monthly
+	Begin    time.Time
+	End      time.Time // excluded
+	Stats    []*PeriodPriceStats
+	BestDay  *DailyChange
+	WorstDay *DailyChange
+}
+
+// PositionMove describes how the gain of the alert2 subscription moved over the period.
+type PositionMove struct {
+	PurchaseDate time.Time
+	OpenGain     float64 // in percents
+	CloseGain    float64
+}
+
+// DigestPeriod returns the latest period of the kind which is completed by the date.
+// The dates are at midnight UTC as the price dates are.
+func DigestPeriod(kind string, date time.Time) (time.Time, time.Time) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	if kind == DigestMonthly {
+		end := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return end.AddDate(0, -1, 0), end
+	}
+
+	// The week starts on Monday
+	end := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	return end.AddDate(0, 0, -7), end
+}
@@ -0,0 +1,58 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+)
+
+func Test_DigestPeriod(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		kind  string
+		date  time.Time
+		begin time.Time
+		end   time.Time
+	}{
+		{
+			name:  "weekly in the middle of the week",
+			kind:  model.DigestWeekly,
+			date:  time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC),
+			begin: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "weekly on Monday",
+			kind:  model.DigestWeekly,
+			date:  time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+			begin: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "weekly on Sunday",
+			kind:  model.DigestWeekly,
+			date:  time.Date(2025, 3, 9, 23, 0, 0, 0, time.UTC),
+			begin: time.Date(2025, 2, 24, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "monthly across the year",
+			kind:  model.DigestMonthly,
+			date:  time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+			begin: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// When: We get the period of the digest sent on the date
+			begin, end := model.DigestPeriod(tc.kind, tc.date)
+
+			// Then: It should be the previous full week or month
+			require.Equal(t, tc.begin, begin)
+			require.Equal(t, tc.end, end)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+const (
+	HistoryPeriodWeek  = "week"
+	HistoryPeriodMonth = "month"
+	HistoryPeriodYear  = "year"
+	// HistoryPeriodFiveYears is only charted, the table of five years is too long to read.
+	HistoryPeriodFiveYears = "5y"
+)
+
+// HistoryPeriods are the periods of the price history in the order they're offered to the user.
+var HistoryPeriods = []string{HistoryPeriodWeek, HistoryPeriodMonth, HistoryPeriodYear}
+
+// ChartPeriods are the periods of the price charts in the order they're offered to the user.
+var ChartPeriods = []string{HistoryPeriodMonth, HistoryPeriodYear, HistoryPeriodFiveYears}
+
+// HistoryBegin returns the first date of the period which ends with the date, both dates are included.
+func HistoryBegin(period string, date time.Time) time.Time {
+	switch period {
+	case HistoryPeriodWeek:
+		return date.AddDate(0, 0, -7)
+	case HistoryPeriodYear:
+		return date.AddDate(-1, 0, 0)
+	case HistoryPeriodFiveYears:
+		return date.AddDate(-5, 0, 0)
+	default:
+		return date.AddDate(0, -1, 0)
+	}
+}
@@ -0,0 +1,114 @@
+package model
+
+import "time"
+
+// TgChatHolding represents the gold bars of one weight the chat owns, bought on the same date at the same price.
+type TgChatHolding struct {
+	ID           int64      `gorm:"column:id;primaryKey"`
+	ChatID       int64      `gorm:"column:chat_id;not null;index"`
+	Chat         TgChat     `gorm:"foreignKey:ChatID;references:ID;constraint:OnDelete:CASCADE"`
+	Weight       float64    `gorm:"column:weight;not null"`
+	Quantity     int        `gorm:"column:quantity;not null"`
+	PurchaseDate time.Time  `gorm:"column:purchase_date;not null"`
+	PricePaid    *float64   `gorm:"column:price_paid"` // per bar in KGS, the NBKR sell price on the purchase date is used if it's empty
+	CreatedAt    time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+	BuyingPrice  *GoldPrice `gorm:"-"` // the NBKR price of the weight on the purchase date
+}
+
+func (*TgChatHolding) TableName() string {
+	return "tg_chat_holdings"
+}
+
+// CostPerBar returns the price paid for one bar, it's false if neither the price paid nor the NBKR price is known.
+func (that *TgChatHolding) CostPerBar() (float64, bool) {
+	if that.PricePaid != nil {
+		return *that.PricePaid, true
+	}
+
+	if that.BuyingPrice != nil {
+		return that.BuyingPrice.SellPrice, true
+	}
+
+	return 0, false
+}
+
+// PortfolioLine describes the value of the holding at the latest prices.
+type PortfolioLine struct {
+	Holding *TgChatHolding
+	Cost    float64
+	Value   float64
+}
+
+// GainPercent returns the unrealized gain of the holding in percents.
+func (that *PortfolioLine) GainPercent() float64 {
+	if that.Cost == 0 {
+		return 0
+	}
+
+	return (that.Value - that.Cost) / that.Cost * 100
+}
+
+// Portfolio describes the value of all the holdings of the chat at the latest prices.
+type Portfolio struct {
+	Date  time.Time
+	View  string // realizable, market
+	Lines []*PortfolioLine
+	Cost  float64 // the cost basis
+	Value float64
+}
+
+// Gain returns the unrealized gain of the portfolio in KGS.
+func (that *Portfolio) Gain() float64 {
+	return that.Value - that.Cost
+}
+
+// GainPercent returns the unrealized gain of the portfolio in percents.
+func (that *Portfolio) GainPercent() float64 {
+	if that.Cost == 0 {
+		return 0
+	}
+
+	return that.Gain() / that.Cost * 100
+}
+
+// NewPortfolio values the holdings at the latest prices. The bars are valued at the buyback price in the realizable
+// view and at the sell price in the market one. The holdings without the cost or the latest price are skipped.
+func NewPortfolio(holdings []*TgChatHolding, prices []*GoldPrice, view string) *Portfolio {
+	portfolio := &Portfolio{View: view}
+
+	latestPrices := make(map[float64]*GoldPrice, len(prices))
+	for _, price := range prices {
+		latestPrices[price.Weight] = price
+		portfolio.Date = price.Date
+	}
+
+	for _, holding := range holdings {
+		price, ok := latestPrices[holding.Weight]
+		if !ok {
+			continue
+		}
+
+		costPerBar, ok := holding.CostPerBar()
+		if !ok {
+			continue
+		}
+
+		valuePerBar := price.PurchasePrice
+		if view == GainViewMarket {
+			valuePerBar = price.SellPrice
+		}
+
+		line := &PortfolioLine{
+			Holding: holding,
+			Cost:    costPerBar * float64(holding.Quantity),
+			Value:   valuePerBar * float64(holding.Quantity),
+		}
+
+		portfolio.Lines = append(portfolio.Lines, line)
+		portfolio.Cost += line.Cost
+		portfolio.Value += line.Value
+	}
+
+	return portfolio
+}
@@ -0,0 +1,49 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+)
+
+func Test_NewPortfolio(t *testing.T) {
+	// Given: The latest prices and the holdings with the known and the unknown cost or price
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	latest := []*model.GoldPrice{
+		{Date: date, Weight: 1, PurchasePrice: 9500, SellPrice: 10000},
+		{Date: date, Weight: 10, PurchasePrice: 95000, SellPrice: 100000},
+	}
+	pricePaid := 9000.0
+	holdings := []*model.TgChatHolding{
+		{Weight: 1, Quantity: 2, PricePaid: &pricePaid},
+		{Weight: 10, Quantity: 1, BuyingPrice: &model.GoldPrice{Weight: 10, SellPrice: 90000}},
+		{Weight: 10, Quantity: 5},                         // the cost is unknown
+		{Weight: 100, Quantity: 1, PricePaid: &pricePaid}, // the price is unknown
+	}
+
+	t.Run("should value the bars at the buyback price in the realizable view", func(t *testing.T) {
+		// When: We value the portfolio
+		portfolio := model.NewPortfolio(holdings, latest, model.GainViewRealizable)
+
+		// Then: Only the holdings with the known cost and price should be counted
+		require.Len(t, portfolio.Lines, 2)
+		require.Equal(t, date, portfolio.Date)
+		require.InDelta(t, 108000, portfolio.Cost, 0.001)
+		require.InDelta(t, 114000, portfolio.Value, 0.001)
+		require.InDelta(t, 6000, portfolio.Gain(), 0.001)
+		require.InDelta(t, 5.5556, portfolio.GainPercent(), 0.0001)
+		require.InDelta(t, 5.5556, portfolio.Lines[0].GainPercent(), 0.0001)
+	})
+
+	t.Run("should value the bars at the sell price in the market view", func(t *testing.T) {
+		// When: We value the portfolio
+		portfolio := model.NewPortfolio(holdings, latest, model.GainViewMarket)
+
+		// Then: The bars should be valued at the sell price
+		require.InDelta(t, 120000, portfolio.Value, 0.001)
+		require.InDelta(t, 12000, portfolio.Gain(), 0.001)
+	})
+}
@@ -0,0 +1,76 @@
+package model
+
+// SellPrices returns the sell prices in the order of the prices.
+func SellPrices(prices []*GoldPrice) []float64 {
+	values := make([]float64, 0, len(prices))
+	for _, price := range prices {
+		values = append(values, price.SellPrice)
+	}
+
+	return values
+}
+
+// SMA returns the simple moving average of the last period values, it's false if there are fewer values.
+func SMA(values []float64, period int) (float64, bool) {
+	if period <= 0 || len(values) < period {
+		return 0, false
+	}
+
+	var sum float64
+	for _, value := range values[len(values)-period:] {
+		sum += value
+	}
+
+	return sum / float64(period), true
+}
+
+// EMA returns the exponential moving average of the values with the smoothing factor 2/(period+1). It starts from
+// the SMA of the first period values, so the more values there are the less the start matters. It's false if there
+// are fewer values than the period.
+func EMA(values []float64, period int) (float64, bool) {
+	average, ok := SMA(values[:min(period, len(values))], period)
+	if !ok {
+		return 0, false
+	}
+
+	factor := 2 / float64(period+1)
+	for _, value := range values[period:] {
+		average += (value - average) * factor
+	}
+
+	return average, true
+}
+
+// Highest returns the index of the highest value, the earliest one wins. It's -1 if there are no values.
+func Highest(values []float64) int {
+	highest := -1
+	for i, value := range values {
+		if highest == -1 || value > values[highest] {
+			highest = i
+		}
+	}
+
+	return highest
+}
+
+// Lowest returns the index of the lowest value, the earliest one wins. It's -1 if there are no values.
+func Lowest(values []float64) int {
+	lowest := -1
+	for i, value := range values {
+		if lowest == -1 || value < values[lowest] {
+			lowest = i
+		}
+	}
+
+	return lowest
+}
+
+// Drawdown returns the drop of the last value from the highest one in percents.
+func Drawdown(values []float64) float64 {
+	highest := Highest(values)
+	if highest == -1 || values[highest] <= 0 {
+		return 0
+	}
+
+	return (values[highest] - values[len(values)-1]) / values[highest] * 100
+}
@@ -0,0 +1,47 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+)
+
+func Test_Indicators(t *testing.T) {
+	// Given: The growing prices
+	values := []float64{1, 2, 3, 4, 5}
+
+	t.Run("should average the last prices of the period", func(t *testing.T) {
+		// When: We count the simple moving average
+		sma, ok := model.SMA(values, 2)
+
+		// Then: It should be the average of the last two prices, the longer period has no average
+		require.True(t, ok)
+		require.Equal(t, 4.5, sma)
+
+		_, ok = model.SMA(values, 6)
+		require.False(t, ok)
+	})
+
+	t.Run("should start the exponential average from the simple one", func(t *testing.T) {
+		// When: We count the exponential moving average
+		ema, ok := model.EMA(values, 3)
+
+		// Then: It starts from the SMA of 1, 2, 3 and moves half the way to every next value
+		require.True(t, ok)
+		require.Equal(t, 4.0, ema)
+
+		_, ok = model.EMA(values, 6)
+		require.False(t, ok)
+	})
+
+	t.Run("should find the extremes and the drawdown", func(t *testing.T) {
+		// Then: The first extreme should be found and the drawdown counted from the highest price
+		require.Equal(t, 1, model.Highest([]float64{100, 120, 90, 120}))
+		require.Equal(t, 2, model.Lowest([]float64{100, 120, 90, 90}))
+		require.Equal(t, -1, model.Highest(nil))
+		require.Equal(t, 25.0, model.Drawdown([]float64{100, 120, 90}))
+		require.Equal(t, 0.0, model.Drawdown([]float64{100, 120}))
+	})
+}
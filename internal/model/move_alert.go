@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+const (
+	MoveAlertBaselinePrevious = "previous"
+	MoveAlertBaselineAverage  = "average"
+)
+
+// TgChatMoveAlert represents an alert on the daily move of the sell price of one weight for a chat.
+type TgChatMoveAlert struct {
+	ID        int64     `gorm:"column:id;primaryKey"`
+	ChatID    int64     `gorm:"column:chat_id;not null;index"`
+	Chat      TgChat    `gorm:"foreignKey:ChatID;references:ID;constraint:OnDelete:CASCADE"`
+	Weight    float64   `gorm:"column:weight;not null"`
+	Percent   float64   `gorm:"column:percent;not null"`        // the minimal move to fire the alert
+	Baseline  string    `gorm:"column:baseline;not null"`       // previous, average
+	Days      int       `gorm:"column:days;not null;default:0"` // the number of the published dates of the average baseline
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (*TgChatMoveAlert) TableName() string {
+	return "tg_chat_move_alerts"
+}
+
+// PriceMove represents the move of the sell price which fired the alert.
+type PriceMove struct {
+	Alert        *TgChatMoveAlert
+	Price        *GoldPrice
+	BasePrice    float64
+	BaseDate     time.Time // the previous published date, it's zero for the average baseline
+	MovedPercent float64
+}
@@ -2,11 +2,16 @@ package model
 
 import "time"
 
+// PriceSourceNBKR is the source name of the National Bank of the Kyrgyz Republic prices.
+// It's the default source for all the prices shown to the users.
+const PriceSourceNBKR = "nbkr"
+
 // GoldPrice describes a gold price.
-// Unique index are Date and Weight together.
+// Unique index are Date, Weight and Source together.
 type GoldPrice struct {
 	Date          time.Time `gorm:"column:date;uniqueIndex:date_weight"`
 	Weight        float64   `gorm:"column:weight;uniqueIndex:date_weight"`
+	Source        string    `gorm:"column:source;uniqueIndex:date_weight;not null;default:nbkr"` // nbkr, bank name, exchange name
 	PurchasePrice float64   `gorm:"column:purchase_price"`
 	SellPrice     float64   `gorm:"column:sell_price"`
 	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime"`
@@ -16,3 +21,9 @@ type GoldPrice struct {
 func (*GoldPrice) TableName() string {
 	return "gold_prices"
 }
+
+// PriceDateWeights describes how many weights are published for the date.
+type PriceDateWeights struct {
+	Date    time.Time `gorm:"column:date"`
+	Weights int       `gorm:"column:weights"`
+}
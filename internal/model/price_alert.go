@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+const (
+	PriceAlertFieldSell     = "sell"
+	PriceAlertFieldPurchase = "purchase"
+
+	PriceAlertBelow = "below"
+	PriceAlertAbove = "above"
+)
+
+// TgChatPriceAlert represents a threshold alert on the price of one weight for a chat.
+type TgChatPriceAlert struct {
+	ID        int64      `gorm:"column:id;primaryKey"`
+	ChatID    int64      `gorm:"column:chat_id;not null;index"`
+	Chat      TgChat     `gorm:"foreignKey:ChatID;references:ID;constraint:OnDelete:CASCADE"`
+	Weight    float64    `gorm:"column:weight;not null"`
+	Field     string     `gorm:"column:field;not null"`     // sell, purchase
+	Direction string     `gorm:"column:direction;not null"` // below, above
+	Threshold float64    `gorm:"column:threshold;not null"`
+	Crossed   bool       `gorm:"column:crossed;not null;default:false"` // the price is beyond the threshold, the alert is fired
+	CrossedAt *time.Time `gorm:"column:crossed_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (*TgChatPriceAlert) TableName() string {
+	return "tg_chat_price_alerts"
+}
+
+// PriceOf returns the watched price.
+func (that *TgChatPriceAlert) PriceOf(price *GoldPrice) float64 {
+	if that.Field == PriceAlertFieldPurchase {
+		return price.PurchasePrice
+	}
+
+	return price.SellPrice
+}
+
+// IsCrossedBy reports whether the price is beyond the threshold.
+func (that *TgChatPriceAlert) IsCrossedBy(price *GoldPrice) bool {
+	if that.Direction == PriceAlertAbove {
+		return that.PriceOf(price) > that.Threshold
+	}
+
+	return that.PriceOf(price) < that.Threshold
+}
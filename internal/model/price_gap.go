@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+const (
+	PriceGapMissing    = "missing"    // no prices for the trading day
+	PriceGapIncomplete = "incomplete" // some weights are missing for the trading day
+)
+
+// PriceGap describes a trading day which doesn't have all the source prices.
+// Unique index are Source and Date together.
+type PriceGap struct {
+	Source      string    `gorm:"column:source;primaryKey"`
+	Date        time.Time `gorm:"column:date;primaryKey"`
+	Reason      string    `gorm:"column:reason;not null"` // missing, incomplete
+	Attempts    int       `gorm:"column:attempts;not null;default:0"`
+	Unpublished bool      `gorm:"column:unpublished;not null;default:false"` // the source never published the prices, it isn't fetched again
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (*PriceGap) TableName() string {
+	return "price_gaps"
+}
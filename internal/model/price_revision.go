@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// GoldPriceRevision describes a change of the already stored gold price.
+type GoldPriceRevision struct {
+	ID               int64     `gorm:"column:id;primaryKey"`
+	Date             time.Time `gorm:"column:date;not null;index:idx_revision_price"`
+	Weight           float64   `gorm:"column:weight;not null;index:idx_revision_price"`
+	Source           string    `gorm:"column:source;not null;index:idx_revision_price"`
+	OldPurchasePrice float64   `gorm:"column:old_purchase_price"`
+	NewPurchasePrice float64   `gorm:"column:new_purchase_price"`
+	OldSellPrice     float64   `gorm:"column:old_sell_price"`
+	NewSellPrice     float64   `gorm:"column:new_sell_price"`
+	RevisedAt        time.Time `gorm:"column:revised_at;not null"`
+}
+
+func (*GoldPriceRevision) TableName() string {
+	return "gold_price_revisions"
+}
+
+// SavePricesResult describes what happened to the saved prices.
+type SavePricesResult struct {
+	Inserted    int
+	Updated     int
+	Unchanged   int
+	Quarantined int                  // the suspicious prices which wait for the review instead
+	Revisions   []*GoldPriceRevision // the changes of the updated prices
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+const (
+	QuarantinePending  = "pending"
+	QuarantineApproved = "approved"
+	QuarantineRejected = "rejected"
+)
+
+// QuarantinedPrice describes a scraped price which doesn't look sane and waits for the admin review.
+// Unique index are Date, Weight, Source and the prices together, so the decision is remembered for the same value.
+type QuarantinedPrice struct {
+	ID            int64      `gorm:"column:id;primaryKey"`
+	Date          time.Time  `gorm:"column:date;not null;uniqueIndex:idx_quarantined_price"`
+	Weight        float64    `gorm:"column:weight;not null;uniqueIndex:idx_quarantined_price"`
+	Source        string     `gorm:"column:source;not null;uniqueIndex:idx_quarantined_price"`
+	PurchasePrice float64    `gorm:"column:purchase_price;not null;uniqueIndex:idx_quarantined_price"`
+	SellPrice     float64    `gorm:"column:sell_price;not null;uniqueIndex:idx_quarantined_price"`
+	Reason        string     `gorm:"column:reason;not null"`
+	Status        string     `gorm:"column:status;not null;default:pending;index"` // pending, approved, rejected
+	ReviewedAt    *time.Time `gorm:"column:reviewed_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (*QuarantinedPrice) TableName() string {
+	return "quarantined_prices"
+}
+
+// GoldPrice returns the price to save once it's approved.
+func (that *QuarantinedPrice) GoldPrice() *GoldPrice {
+	return &GoldPrice{
+		Date:          that.Date,
+		Weight:        that.Weight,
+		Source:        that.Source,
+		PurchasePrice: that.PurchasePrice,
+		SellPrice:     that.SellPrice,
+	}
+}
+
+// IsMissing reports whether the entry flags the weight which disappeared from the date, it has no prices to save.
+func (that *QuarantinedPrice) IsMissing() bool {
+	return that.PurchasePrice == 0 && that.SellPrice == 0
+}
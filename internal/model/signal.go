@@ -0,0 +1,141 @@
+package model
+
+import "time"
+
+const (
+	SignalKindSMA         = "sma"          // the sell price crosses its simple moving average
+	SignalKindEMA         = "ema"          // the sell price crosses its exponential moving average
+	SignalKindHigh        = "high"         // the sell price makes a new high of the period
+	SignalKindLow         = "low"          // the sell price makes a new low of the period
+	SignalKindDrawdown    = "drawdown"     // the sell price drops from the high of the period by the percent
+	SignalKindPurchaseLow = "purchase_low" // the sell price makes a new low since the alert2 purchase date
+
+	SignalStateAbove   = "above"
+	SignalStateBelow   = "below"
+	SignalStateCrossed = "crossed"
+
+	// signalAverageLookback multiplies the period of the moving averages to get the days of the history. The prices
+	// aren't published on the weekends and holidays, and the EMA needs more prices than the period to settle.
+	signalAverageLookback = 3
+)
+
+// TgChatSignal represents a technical-indicator signal on the sell price of one weight for a chat.
+type TgChatSignal struct {
+	ID          int64      `gorm:"column:id;primaryKey"`
+	ChatID      int64      `gorm:"column:chat_id;not null;index"`
+	Chat        TgChat     `gorm:"foreignKey:ChatID;references:ID;constraint:OnDelete:CASCADE"`
+	Weight      float64    `gorm:"column:weight;not null"`
+	Kind        string     `gorm:"column:kind;not null"`              // sma, ema, high, low, drawdown, purchase_low
+	Period      int        `gorm:"column:period;not null;default:0"`  // the prices of the moving averages, the weeks of the high, the low and the drawdown
+	Percent     float64    `gorm:"column:percent;not null;default:0"` // the drop of the drawdown
+	Since       *time.Time `gorm:"column:since"`                      // the alert2 purchase date of the purchase low
+	State       string     `gorm:"column:state;not null;default:''"`  // the side of the moving average, the crossed drawdown
+	CheckedDate *time.Time `gorm:"column:checked_date"`               // the latest price date the signal was checked with
+	FiredAt     *time.Time `gorm:"column:fired_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (*TgChatSignal) TableName() string {
+	return "tg_chat_signals"
+}
+
+// SignalEvent represents the signal fired by the latest price.
+type SignalEvent struct {
+	Signal    *TgChatSignal
+	Price     *GoldPrice
+	Direction string    // above, below for the moving averages
+	Value     float64   // the moving average, the previous high or low
+	ValueDate time.Time // the date of the previous high or low, it's zero for the moving averages
+}
+
+// Percent returns the distance of the price from the value in percents.
+func (that *SignalEvent) Percent() float64 {
+	if that.Value == 0 {
+		return 0
+	}
+
+	return (that.Price.SellPrice - that.Value) / that.Value * 100
+}
+
+// HistoryBegin returns the first date of the prices the signal needs to be checked with the latest date.
+func (that *TgChatSignal) HistoryBegin(latestDate time.Time) time.Time {
+	switch that.Kind {
+	case SignalKindSMA, SignalKindEMA:
+		return latestDate.AddDate(0, 0, -that.Period*signalAverageLookback)
+	case SignalKindPurchaseLow:
+		if that.Since != nil {
+			return *that.Since
+		}
+		return latestDate
+	default:
+		return latestDate.AddDate(0, 0, -that.Period*7)
+	}
+}
+
+// Check evaluates the signal with the prices of its weight ordered by date, the latest price is the last one.
+// It returns the event if the signal fires and the new state of the signal. The moving averages fire when the price
+// moves to the other side of the average, the drawdown fires once until the price recovers, the highs and lows fire
+// on every new extreme.
+func (that *TgChatSignal) Check(history []*GoldPrice) (*SignalEvent, string) {
+	if len(history) < 2 {
+		return nil, that.State
+	}
+
+	latest := history[len(history)-1]
+	values := SellPrices(history)
+	previous := values[:len(values)-1]
+
+	switch that.Kind {
+	case SignalKindSMA, SignalKindEMA:
+		average, ok := SMA(values, that.Period)
+		if that.Kind == SignalKindEMA {
+			average, ok = EMA(values, that.Period)
+		}
+		if !ok {
+			return nil, that.State
+		}
+
+		state := that.State
+		switch {
+		case latest.SellPrice > average:
+			state = SignalStateAbove
+		case latest.SellPrice < average:
+			state = SignalStateBelow
+		}
+
+		// The first check only finds the side of the price
+		if that.State == "" || state == that.State {
+			return nil, state
+		}
+
+		return &SignalEvent{Signal: that, Price: latest, Direction: state, Value: average}, state
+	case SignalKindHigh:
+		highest := Highest(previous)
+		if latest.SellPrice <= previous[highest] {
+			return nil, that.State
+		}
+
+		return &SignalEvent{Signal: that, Price: latest, Value: previous[highest], ValueDate: history[highest].Date}, that.State
+	case SignalKindLow, SignalKindPurchaseLow:
+		lowest := Lowest(previous)
+		if latest.SellPrice >= previous[lowest] {
+			return nil, that.State
+		}
+
+		return &SignalEvent{Signal: that, Price: latest, Value: previous[lowest], ValueDate: history[lowest].Date}, that.State
+	case SignalKindDrawdown:
+		if Drawdown(values) < that.Percent {
+			return nil, ""
+		}
+
+		if that.State == SignalStateCrossed {
+			return nil, that.State
+		}
+
+		highest := Highest(values)
+		return &SignalEvent{Signal: that, Price: latest, Value: values[highest], ValueDate: history[highest].Date}, SignalStateCrossed
+	default:
+		return nil, that.State
+	}
+}
@@ -0,0 +1,112 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+)
+
+func Test_TgChatSignal_Check(t *testing.T) {
+	// Given: The daily sell prices of the 1 g bar since March 1
+	history := func(sellPrices ...float64) []*model.GoldPrice {
+		result := make([]*model.GoldPrice, 0, len(sellPrices))
+		for i, sellPrice := range sellPrices {
+			result = append(result, &model.GoldPrice{Date: time.Date(2025, 3, 1+i, 0, 0, 0, 0, time.UTC), Weight: 1, SellPrice: sellPrice})
+		}
+		return result
+	}
+
+	testCases := []struct {
+		name      string
+		signal    *model.TgChatSignal
+		history   []*model.GoldPrice
+		fired     bool
+		value     float64
+		valueDate time.Time
+		state     string
+	}{
+		{
+			name:    "the first check of the average should only find the side",
+			signal:  &model.TgChatSignal{Kind: model.SignalKindSMA, Period: 2},
+			history: history(100, 100, 120),
+			state:   model.SignalStateAbove,
+		},
+		{
+			name:    "the average should fire when the price crosses it",
+			signal:  &model.TgChatSignal{Kind: model.SignalKindSMA, Period: 2, State: model.SignalStateAbove},
+			history: history(100, 100, 80),
+			fired:   true,
+			value:   90,
+			state:   model.SignalStateBelow,
+		},
+		{
+			name:    "the average shouldn't fire without enough prices",
+			signal:  &model.TgChatSignal{Kind: model.SignalKindEMA, Period: 5, State: model.SignalStateAbove},
+			history: history(100, 100, 80),
+			state:   model.SignalStateAbove,
+		},
+		{
+			name:      "the high should fire on the new extreme",
+			signal:    &model.TgChatSignal{Kind: model.SignalKindHigh, Period: 52},
+			history:   history(100, 110, 105, 111),
+			fired:     true,
+			value:     110,
+			valueDate: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "the high shouldn't fire on the same price",
+			signal:  &model.TgChatSignal{Kind: model.SignalKindHigh, Period: 52},
+			history: history(100, 110, 110),
+		},
+		{
+			name:      "the purchase low should fire on the new extreme",
+			signal:    &model.TgChatSignal{Kind: model.SignalKindPurchaseLow},
+			history:   history(100, 90, 95, 89),
+			fired:     true,
+			value:     90,
+			valueDate: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "the drawdown should fire once it's deep enough",
+			signal:    &model.TgChatSignal{Kind: model.SignalKindDrawdown, Period: 52, Percent: 10},
+			history:   history(100, 120, 108),
+			fired:     true,
+			value:     120,
+			valueDate: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+			state:     model.SignalStateCrossed,
+		},
+		{
+			name:    "the drawdown shouldn't fire again until the price recovers",
+			signal:  &model.TgChatSignal{Kind: model.SignalKindDrawdown, Period: 52, Percent: 10, State: model.SignalStateCrossed},
+			history: history(100, 120, 100),
+			state:   model.SignalStateCrossed,
+		},
+		{
+			name:    "the drawdown should be armed again when the price recovers",
+			signal:  &model.TgChatSignal{Kind: model.SignalKindDrawdown, Period: 52, Percent: 10, State: model.SignalStateCrossed},
+			history: history(100, 120, 115),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// When: The signal is checked against the history
+			event, state := testCase.signal.Check(testCase.history)
+
+			// Then: The event should be fired with the previous extreme and the state kept for the next check
+			require.Equal(t, testCase.state, state)
+			if !testCase.fired {
+				require.Nil(t, event)
+				return
+			}
+
+			require.NotNil(t, event)
+			require.Equal(t, testCase.value, event.Value)
+			require.Equal(t, testCase.valueDate, event.ValueDate)
+			require.Same(t, testCase.history[len(testCase.history)-1], event.Price)
+		})
+	}
+}
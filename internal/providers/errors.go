@@ -0,0 +1,13 @@
+package providers
+
+import "errors"
+
+// The errors of the price pages which can't be parsed, the providers wrap them so the admins are notified.
+var (
+	// ErrLayoutChanged means the page doesn't look like the prices table anymore.
+	ErrLayoutChanged = errors.New("price page layout changed")
+	// ErrNoRows means the prices table is found but there are no prices for the period.
+	ErrNoRows = errors.New("price page has no price rows")
+	// ErrInvalidRow means a single row of the prices table can't be parsed.
+	ErrInvalidRow = errors.New("price page has an invalid price row")
+)
@@ -0,0 +1,79 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"goldie/internal/model"
+)
+
+// Provider is a source of gold bar prices: the NBKR, a commercial bank, an exchange, etc.
+type Provider interface {
+	// Source returns the unique name of the provider stored along with every price.
+	Source() string
+	// GetPrices returns the prices for the given period with the Source field filled.
+	// It may return the valid prices along with an error describing the skipped ones.
+	GetPrices(ctx context.Context, beginDate time.Time, endDate time.Time) ([]*model.GoldPrice, error)
+}
+
+// Registry keeps the enabled providers in the registration order.
+type Registry struct {
+	providers []Provider
+}
+
+// NewRegistry creates a new registry with the given providers.
+func NewRegistry(providers ...Provider) *Registry {
+	registry := &Registry{}
+	for _, provider := range providers {
+		registry.Register(provider)
+	}
+
+	return registry
+}
+
+// NewRegistryFromConfig creates a new registry with the providers enabled in the config.
+// The available providers are looked up by their source name.
+func NewRegistryFromConfig(available []Provider, enabled []string) (*Registry, error) {
+	lookup := make(map[string]Provider, len(available))
+	for _, provider := range available {
+		lookup[provider.Source()] = provider
+	}
+
+	registry := &Registry{}
+	for _, source := range enabled {
+		provider, ok := lookup[source]
+		if !ok {
+			return nil, fmt.Errorf("unknown price provider: %s", source)
+		}
+
+		registry.Register(provider)
+	}
+
+	return registry, nil
+}
+
+// Register adds the provider to the registry. Providers with an already registered source are ignored.
+func (that *Registry) Register(provider Provider) {
+	if _, ok := that.Get(provider.Source()); ok {
+		return
+	}
+
+	that.providers = append(that.providers, provider)
+}
+
+// Get returns the provider by its source name.
+func (that *Registry) Get(source string) (Provider, bool) {
+	for _, provider := range that.providers {
+		if provider.Source() == source {
+			return provider, true
+		}
+	}
+
+	return nil, false
+}
+
+// All returns all registered providers.
+func (that *Registry) All() []Provider {
+	return that.providers
+}
@@ -0,0 +1,43 @@
+package providers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+	"goldie/internal/providers"
+)
+
+type stubProvider struct {
+	source string
+}
+
+func (that stubProvider) Source() string {
+	return that.source
+}
+
+func (that stubProvider) GetPrices(_ context.Context, _ time.Time, _ time.Time) ([]*model.GoldPrice, error) {
+	return nil, nil
+}
+
+func Test_NewRegistryFromConfig(t *testing.T) {
+	available := []providers.Provider{stubProvider{source: "nbkr"}, stubProvider{source: "bank"}}
+
+	t.Run("should register only enabled providers in the config order", func(t *testing.T) {
+		registry, err := providers.NewRegistryFromConfig(available, []string{"bank", "nbkr", "bank"})
+		require.NoError(t, err)
+
+		require.Equal(t, []providers.Provider{stubProvider{source: "bank"}, stubProvider{source: "nbkr"}}, registry.All())
+
+		_, ok := registry.Get("nbkr")
+		require.True(t, ok)
+	})
+
+	t.Run("should fail on unknown provider", func(t *testing.T) {
+		_, err := providers.NewRegistryFromConfig(available, []string{"exchange"})
+		require.EqualError(t, err, "unknown price provider: exchange")
+	})
+}
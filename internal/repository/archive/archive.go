@@ -0,0 +1,105 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goldie/internal/model"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// SavePage stores the page compressed. If the same content is already archived, the first copy is kept as it is.
+func (that *Repository) SavePage(ctx context.Context, page *model.ArchivedPage) error {
+	hash := sha256.Sum256([]byte(page.Body))
+	page.Hash = hex.EncodeToString(hash[:])
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write([]byte(page.Body)); err != nil {
+		return fmt.Errorf("compress page body: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("compress page body: %w", err)
+	}
+
+	page.CompressedBody = compressed.Bytes()
+
+	query := that.db.WithContext(ctx).Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "hash"}}, DoNothing: true})
+
+	if err := query.Create(page).Error; err != nil {
+		return fmt.Errorf("insert archived page in database: %w", err)
+	}
+
+	return nil
+}
+
+// FindPagesInBatches calls fn for the archived pages in the fetch order with decompressed bodies.
+func (that *Repository) FindPagesInBatches(ctx context.Context, batchSize int, fn func(pages []*model.ArchivedPage) error) error {
+	var last *model.ArchivedPage
+
+	for {
+		query := that.db.WithContext(ctx).Order("fetched_at asc, hash asc").Limit(batchSize)
+		if last != nil {
+			query = query.Where("(fetched_at, hash) > (?, ?)", last.FetchedAt, last.Hash)
+		}
+
+		var pages []*model.ArchivedPage
+		if err := query.Find(&pages).Error; err != nil {
+			return fmt.Errorf("find archived pages in database: %w", err)
+		}
+
+		if len(pages) == 0 {
+			return nil
+		}
+
+		for _, page := range pages {
+			body, err := decompress(page.CompressedBody)
+			if err != nil {
+				return fmt.Errorf("decompress page %s: %w", page.Hash, err)
+			}
+
+			page.Body = body
+		}
+
+		if err := fn(pages); err != nil {
+			return err
+		}
+
+		if len(pages) < batchSize {
+			return nil
+		}
+
+		last = pages[len(pages)-1]
+	}
+}
+
+func decompress(compressed []byte) (string, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
@@ -0,0 +1,60 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goldie/internal/model"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// EnsureChunks creates the chunks which don't exist yet. The progress of the existing ones is kept.
+func (that *Repository) EnsureChunks(ctx context.Context, chunks []*model.BackfillChunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	query := that.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source"}, {Name: "begin_date"}},
+		DoNothing: true,
+	})
+
+	if err := query.Create(chunks).Error; err != nil {
+		return fmt.Errorf("create backfill chunks: %w", err)
+	}
+
+	return nil
+}
+
+// ListUnfinishedChunks returns the chunks of the source which are not imported yet, including the interrupted ones.
+func (that *Repository) ListUnfinishedChunks(ctx context.Context, source string) ([]*model.BackfillChunk, error) {
+	var chunks []*model.BackfillChunk
+
+	query := that.db.WithContext(ctx).
+		Where("source = ? AND status NOT IN ?", source, []string{model.BackfillChunkDone, model.BackfillChunkEmpty}).
+		Order("begin_date asc")
+	if err := query.Find(&chunks).Error; err != nil {
+		return nil, fmt.Errorf("list unfinished backfill chunks: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// SaveChunk saves the chunk progress.
+func (that *Repository) SaveChunk(ctx context.Context, chunk *model.BackfillChunk) error {
+	if err := that.db.WithContext(ctx).Save(chunk).Error; err != nil {
+		return fmt.Errorf("save backfill chunk: %w", err)
+	}
+
+	return nil
+}
@@ -64,7 +64,7 @@ func (that *Repository) CreateAlert2Subscription(ctx context.Context, chatID int
 	})
 }
 
-// DisableAlerts disables all alerts for the chat.
+// DisableAlerts disables all alerts for the chat. The holdings and the signals are kept, only /delete removes them.
 func (that *Repository) DisableAlerts(ctx context.Context, chatID int64) error {
 	return that.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		chat, err := ensureChatExists(tx, chatID)
@@ -73,7 +73,7 @@ func (that *Repository) DisableAlerts(ctx context.Context, chatID int64) error {
 		}
 
 		query := tx.Model(&model.TgChat{}).Where("id = ?", chat.ID)
-		if err = query.Updates(map[string]interface{}{"alert1": false, "updated_at": time.Now()}).Error; err != nil {
+		if err = query.Updates(map[string]interface{}{"alert1": false, "weekly_digest": false, "monthly_digest": false, "updated_at": time.Now()}).Error; err != nil {
 			return fmt.Errorf("update existing chat: %w", err)
 		}
 
@@ -82,6 +82,14 @@ func (that *Repository) DisableAlerts(ctx context.Context, chatID int64) error {
 			return fmt.Errorf("delete alert2 subscriptions: %w", err)
 		}
 
+		if err = tx.Where("chat_id = ?", chat.ID).Delete(&model.TgChatPriceAlert{}).Error; err != nil {
+			return fmt.Errorf("delete price alerts: %w", err)
+		}
+
+		if err = tx.Where("chat_id = ?", chat.ID).Delete(&model.TgChatMoveAlert{}).Error; err != nil {
+			return fmt.Errorf("delete move alerts: %w", err)
+		}
+
 		return nil
 	})
 }
@@ -94,6 +102,26 @@ func (that *Repository) DeleteChat(ctx context.Context, chatID int64) error {
 			if err = tx.Where("chat_id = ?", chat.ID).Delete(&model.TgChatAlert2{}).Error; err != nil {
 				return fmt.Errorf("delete alert2 subscriptions: %w", err)
 			}
+
+			if err = tx.Where("chat_id = ?", chat.ID).Delete(&model.TgChatPriceAlert{}).Error; err != nil {
+				return fmt.Errorf("delete price alerts: %w", err)
+			}
+
+			if err = tx.Where("chat_id = ?", chat.ID).Delete(&model.TgChatMoveAlert{}).Error; err != nil {
+				return fmt.Errorf("delete move alerts: %w", err)
+			}
+
+			if err = tx.Where("chat_id = ?", chat.ID).Delete(&model.TgChatHolding{}).Error; err != nil {
+				return fmt.Errorf("delete holdings: %w", err)
+			}
+
+			if err = tx.Where("chat_id = ?", chat.ID).Delete(&model.TgChatSignal{}).Error; err != nil {
+				return fmt.Errorf("delete signals: %w", err)
+			}
+
+			if err = tx.Where("chat_id = ?", chat.ID).Delete(&model.AlertDelivery{}).Error; err != nil {
+				return fmt.Errorf("delete alert deliveries: %w", err)
+			}
 		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("fetch chat: %w", err)
 		}
@@ -106,6 +134,181 @@ func (that *Repository) DeleteChat(ctx context.Context, chatID int64) error {
 	})
 }
 
+// DeactivateChat stops the alerts to the chat which is unavailable for the given reason.
+func (that *Repository) DeactivateChat(ctx context.Context, chatID int64, reason string) error {
+	query := that.db.WithContext(ctx).Model(&model.TgChat{}).Where("source_id = ? AND deactivated_at IS NULL", chatID)
+	if err := query.Updates(map[string]interface{}{"deactivated_at": time.Now(), "deactivated_by": reason, "updated_at": time.Now()}).Error; err != nil {
+		return fmt.Errorf("deactivate chat: %w", err)
+	}
+
+	return nil
+}
+
+// ReactivateChat resumes the alerts to the chat if it was deactivated.
+func (that *Repository) ReactivateChat(ctx context.Context, chatID int64) error {
+	query := that.db.WithContext(ctx).Model(&model.TgChat{}).Where("source_id = ? AND deactivated_at IS NOT NULL", chatID)
+	if err := query.Updates(map[string]interface{}{"deactivated_at": nil, "deactivated_by": "", "updated_at": time.Now()}).Error; err != nil {
+		return fmt.Errorf("reactivate chat: %w", err)
+	}
+
+	return nil
+}
+
+// PauseChat suspends all alerts of the chat until the time, the subscriptions are kept.
+// The pause can be extended or shortened, it starts when the chat is paused for the first time.
+func (that *Repository) PauseChat(ctx context.Context, chatID int64, until time.Time) error {
+	return that.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		chat, err := ensureChatExists(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"paused_at": gorm.Expr("COALESCE(paused_at, ?)", time.Now()), "paused_until": until, "updated_at": time.Now()}
+		if err = tx.Model(&model.TgChat{}).Where("id = ?", chat.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("update chat pause: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// FetchResumedChats returns the active chats whose pause is over by the time.
+func (that *Repository) FetchResumedChats(ctx context.Context, now time.Time) ([]*model.TgChat, error) {
+	var chats []*model.TgChat
+
+	query := that.db.WithContext(ctx).Model(&model.TgChat{}).
+		Where("deactivated_at IS NULL").
+		Where("paused_until IS NOT NULL AND paused_until <= ?", now)
+	if err := query.Find(&chats).Error; err != nil {
+		return nil, fmt.Errorf("fetch resumed chats: %w", err)
+	}
+
+	return chats, nil
+}
+
+// ClearPause forgets the finished pause of the chats.
+func (that *Repository) ClearPause(ctx context.Context, chatIDs []int64) error {
+	if len(chatIDs) == 0 {
+		return nil
+	}
+
+	query := that.db.WithContext(ctx).Model(&model.TgChat{}).Where("id IN ?", chatIDs)
+	if err := query.Updates(map[string]interface{}{"paused_at": nil, "paused_until": nil, "updated_at": time.Now()}).Error; err != nil {
+		return fmt.Errorf("clear chats pause: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateChat moves the chat settings and the alerts to the new chat ID when the group is upgraded to a supergroup.
+// If the new chat is already known, the old chat is merged into it.
+func (that *Repository) MigrateChat(ctx context.Context, fromChatID, toChatID int64) error {
+	return that.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var from model.TgChat
+		if err := tx.Where("source_id = ?", fromChatID).First(&from).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+
+			return fmt.Errorf("get migrated chat: %w", err)
+		}
+
+		var to model.TgChat
+		if err := tx.Where("source_id = ?", toChatID).First(&to).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("get new chat: %w", err)
+			}
+
+			// The alerts refer to the chat by its ID, so moving the source ID is enough
+			updates := map[string]interface{}{"source_id": toChatID, "deactivated_at": nil, "deactivated_by": "", "updated_at": time.Now()}
+			if err = tx.Model(&model.TgChat{}).Where("id = ?", from.ID).Updates(updates).Error; err != nil {
+				return fmt.Errorf("update chat source id: %w", err)
+			}
+
+			return nil
+		}
+
+		return mergeChats(tx, &from, &to)
+	})
+}
+
+// mergeChats moves the settings and the alerts of the chat into the other one and deletes it.
+// The settings of the other chat win, the duplicated alert2 subscriptions and deliveries are dropped.
+func mergeChats(tx *gorm.DB, from, to *model.TgChat) error {
+	updates := map[string]interface{}{
+		"alert1":         from.Alert1Enabled || to.Alert1Enabled,
+		"weekly_digest":  from.WeeklyDigest || to.WeeklyDigest,
+		"monthly_digest": from.MonthlyDigest || to.MonthlyDigest,
+		"digest_chart":   from.DigestChart || to.DigestChart,
+		"updated_at":     time.Now(),
+	}
+	if to.Language == "" {
+		updates["language"] = from.Language
+	}
+	if to.Currency == "" {
+		updates["currency"] = from.Currency
+	}
+	if to.Timezone == "" {
+		updates["timezone"] = from.Timezone
+	}
+	if to.DeliveryTime == "" {
+		updates["delivery_time"] = from.DeliveryTime
+	}
+	if to.GainView == "" {
+		updates["gain_view"] = from.GainView
+	}
+	if to.PausedUntil == nil {
+		updates["paused_at"] = from.PausedAt
+		updates["paused_until"] = from.PausedUntil
+	}
+
+	if err := tx.Model(&model.TgChat{}).Where("id = ?", to.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("update new chat: %w", err)
+	}
+
+	query := tx.Model(&model.TgChatAlert2{}).
+		Where("chat_id = ? AND purchase_date NOT IN (SELECT purchase_date FROM tg_chat_alert2 WHERE chat_id = ?)", from.ID, to.ID)
+	if err := query.Update("chat_id", to.ID).Error; err != nil {
+		return fmt.Errorf("move alert2 subscriptions: %w", err)
+	}
+
+	if err := tx.Model(&model.TgChatPriceAlert{}).Where("chat_id = ?", from.ID).Update("chat_id", to.ID).Error; err != nil {
+		return fmt.Errorf("move price alerts: %w", err)
+	}
+
+	if err := tx.Model(&model.TgChatMoveAlert{}).Where("chat_id = ?", from.ID).Update("chat_id", to.ID).Error; err != nil {
+		return fmt.Errorf("move move alerts: %w", err)
+	}
+
+	if err := tx.Model(&model.TgChatHolding{}).Where("chat_id = ?", from.ID).Update("chat_id", to.ID).Error; err != nil {
+		return fmt.Errorf("move holdings: %w", err)
+	}
+
+	if err := tx.Model(&model.TgChatSignal{}).Where("chat_id = ?", from.ID).Update("chat_id", to.ID).Error; err != nil {
+		return fmt.Errorf("move signals: %w", err)
+	}
+
+	query = tx.Model(&model.AlertDelivery{}).
+		Where("chat_id = ? AND NOT EXISTS (SELECT 1 FROM alert_deliveries d WHERE d.chat_id = ? AND d.kind = alert_deliveries.kind AND d.date = alert_deliveries.date)", from.ID, to.ID)
+	if err := query.Update("chat_id", to.ID).Error; err != nil {
+		return fmt.Errorf("move alert deliveries: %w", err)
+	}
+
+	if err := tx.Where("chat_id = ?", from.ID).Delete(&model.TgChatAlert2{}).Error; err != nil {
+		return fmt.Errorf("delete duplicated alert2 subscriptions: %w", err)
+	}
+
+	if err := tx.Where("chat_id = ?", from.ID).Delete(&model.AlertDelivery{}).Error; err != nil {
+		return fmt.Errorf("delete duplicated alert deliveries: %w", err)
+	}
+
+	if err := tx.Delete(&model.TgChat{}, from.ID).Error; err != nil {
+		return fmt.Errorf("delete migrated chat: %w", err)
+	}
+
+	return nil
+}
+
 // SetLanguage sets chat language.
 func (that *Repository) SetLanguage(ctx context.Context, chatID int64, language string) error {
 	query := that.db.WithContext(ctx).Model(&model.TgChat{}).Where("source_id = ?", chatID)
@@ -124,6 +327,78 @@ func (that *Repository) SetLanguage(ctx context.Context, chatID int64, language
 	return nil
 }
 
+// SetCurrency sets chat display currency.
+func (that *Repository) SetCurrency(ctx context.Context, chatID int64, currency string) error {
+	query := that.db.WithContext(ctx).Model(&model.TgChat{}).Where("source_id = ?", chatID)
+
+	result := query.Updates(map[string]interface{}{"currency": currency, "updated_at": time.Now()})
+	if err := result.Error; err != nil {
+		return fmt.Errorf("update existing chat currency: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		if err := query.Create(&model.TgChat{SourceID: chatID, Currency: currency}).Error; err != nil {
+			return fmt.Errorf("create new chat with currency: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SetTimezone sets chat timezone.
+func (that *Repository) SetTimezone(ctx context.Context, chatID int64, timezone string) error {
+	query := that.db.WithContext(ctx).Model(&model.TgChat{}).Where("source_id = ?", chatID)
+
+	result := query.Updates(map[string]interface{}{"timezone": timezone, "updated_at": time.Now()})
+	if err := result.Error; err != nil {
+		return fmt.Errorf("update existing chat timezone: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		if err := query.Create(&model.TgChat{SourceID: chatID, Timezone: timezone}).Error; err != nil {
+			return fmt.Errorf("create new chat with timezone: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SetDeliveryTime sets the time of the day the chat receives the daily alerts.
+func (that *Repository) SetDeliveryTime(ctx context.Context, chatID int64, deliveryTime string) error {
+	query := that.db.WithContext(ctx).Model(&model.TgChat{}).Where("source_id = ?", chatID)
+
+	result := query.Updates(map[string]interface{}{"delivery_time": deliveryTime, "updated_at": time.Now()})
+	if err := result.Error; err != nil {
+		return fmt.Errorf("update existing chat delivery time: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		if err := query.Create(&model.TgChat{SourceID: chatID, DeliveryTime: deliveryTime}).Error; err != nil {
+			return fmt.Errorf("create new chat with delivery time: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SetGainView sets how the gain of the alert2 subscriptions is shown to the chat.
+func (that *Repository) SetGainView(ctx context.Context, chatID int64, view string) error {
+	query := that.db.WithContext(ctx).Model(&model.TgChat{}).Where("source_id = ?", chatID)
+
+	result := query.Updates(map[string]interface{}{"gain_view": view, "updated_at": time.Now()})
+	if err := result.Error; err != nil {
+		return fmt.Errorf("update existing chat gain view: %w", err)
+	}
+
+	if result.RowsAffected == 0 {
+		if err := query.Create(&model.TgChat{SourceID: chatID, GainView: view}).Error; err != nil {
+			return fmt.Errorf("create new chat with gain view: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // GetLanguage returns chat language.
 func (that *Repository) GetLanguage(ctx context.Context, chatID int64) (string, error) {
 	var chat model.TgChat
@@ -230,16 +505,161 @@ func (that *Repository) DeleteAlert2Subscription(ctx context.Context, chatID int
 	})
 }
 
-func (that *Repository) FetchChatsWithBuyingPrices(ctx context.Context) ([]*model.TgChat, error) {
+// GetAlert2Subscription returns the alert2 subscription if it belongs to the chat, it's nil if there is no such one.
+func (that *Repository) GetAlert2Subscription(ctx context.Context, chatID int64, subscriptionID int64) (*model.TgChatAlert2, error) {
+	var alert model.TgChatAlert2
+
+	err := that.db.WithContext(ctx).Model(&model.TgChatAlert2{}).
+		Joins("JOIN tg_chats ON tg_chats.id = tg_chat_alert2.chat_id").
+		Where("tg_chat_alert2.id = ? AND tg_chats.source_id = ?", subscriptionID, chatID).
+		First(&alert).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("get alert2 subscription: %w", err)
+	}
+
+	return &alert, nil
+}
+
+// SetAlert2Mode switches the alert2 subscription between the daily table and the crossing notifications.
+func (that *Repository) SetAlert2Mode(ctx context.Context, chatID int64, subscriptionID int64, mode string) error {
+	return that.updateAlert2Subscription(ctx, chatID, subscriptionID, map[string]interface{}{"mode": mode})
+}
+
+// SetAlert2Thresholds sets the target gain and the stop-loss of the alert2 subscription, nil clears them.
+// The changed levels are notified again once they are crossed.
+func (that *Repository) SetAlert2Thresholds(ctx context.Context, chatID int64, subscriptionID int64, targetGain, stopLoss *float64) error {
+	return that.updateAlert2Subscription(ctx, chatID, subscriptionID, map[string]interface{}{
+		"target_gain":       targetGain,
+		"stop_loss":         stopLoss,
+		"target_crossed_at": nil,
+		"stop_crossed_at":   nil,
+	})
+}
+
+// SetAlert2Crossed remembers that the chat is notified about the level crossed by the alert2 subscription.
+func (that *Repository) SetAlert2Crossed(ctx context.Context, subscriptionID int64, crossing string, crossedAt time.Time) error {
+	var column string
+	switch crossing {
+	case model.Alert2CrossingBreakEven:
+		column = "break_even_crossed_at"
+	case model.Alert2CrossingTarget:
+		column = "target_crossed_at"
+	case model.Alert2CrossingStop:
+		column = "stop_crossed_at"
+	default:
+		return fmt.Errorf("unknown alert2 crossing %q", crossing)
+	}
+
+	query := that.db.WithContext(ctx).Model(&model.TgChatAlert2{}).Where("id = ?", subscriptionID)
+	if err := query.Updates(map[string]interface{}{column: crossedAt, "updated_at": time.Now()}).Error; err != nil {
+		return fmt.Errorf("update alert2 crossing: %w", err)
+	}
+
+	return nil
+}
+
+func (that *Repository) updateAlert2Subscription(ctx context.Context, chatID int64, subscriptionID int64, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+
+	query := that.db.WithContext(ctx).Model(&model.TgChatAlert2{}).
+		Where("id = ? AND chat_id IN (SELECT id FROM tg_chats WHERE source_id = ?)", subscriptionID, chatID)
+	if err := query.Updates(updates).Error; err != nil {
+		return fmt.Errorf("update alert2 subscription: %w", err)
+	}
+
+	return nil
+}
+
+// FetchAlertChats returns the active chats with the daily alerts which aren't sent for the price date yet.
+// The paused chats are skipped, the alerts of the chats aren't loaded.
+func (that *Repository) FetchAlertChats(ctx context.Context, date time.Time) ([]*model.TgChat, error) {
 	var chats []*model.TgChat
 
-	query := that.db.WithContext(ctx).Model(&model.TgChat{}).Where("alert1 = true OR EXISTS (SELECT 1 FROM tg_chat_alert2 WHERE tg_chat_alert2.chat_id = tg_chats.id)")
+	query := that.db.WithContext(ctx).Model(&model.TgChat{}).
+		Where("deactivated_at IS NULL").
+		Where("paused_until IS NULL OR paused_until <= ?", time.Now()).
+		Where("alerts_date IS NULL OR alerts_date < ?", date).
+		Where("alert1 = true OR EXISTS (SELECT 1 FROM tg_chat_alert2 WHERE tg_chat_alert2.chat_id = tg_chats.id) OR EXISTS (SELECT 1 FROM tg_chat_move_alerts WHERE tg_chat_move_alerts.chat_id = tg_chats.id) OR EXISTS (SELECT 1 FROM tg_chat_holdings WHERE tg_chat_holdings.chat_id = tg_chats.id)")
 	if err := query.Find(&chats).Error; err != nil {
 		return nil, fmt.Errorf("fetch chats: %w", err)
 	}
 
+	return chats, nil
+}
+
+// FetchDigestChats returns the active chats subscribed to the digest of the kind which didn't get one since the date.
+// The paused chats are skipped, the alerts of the chats aren't loaded.
+func (that *Repository) FetchDigestChats(ctx context.Context, kind string, sentSince time.Time) ([]*model.TgChat, error) {
+	var chats []*model.TgChat
+
+	query := that.db.WithContext(ctx).Model(&model.TgChat{}).
+		Where("deactivated_at IS NULL").
+		Where("paused_until IS NULL OR paused_until <= ?", time.Now()).
+		Where(digestColumn(kind)+" = true").
+		Where("NOT EXISTS (SELECT 1 FROM alert_deliveries WHERE alert_deliveries.chat_id = tg_chats.id AND alert_deliveries.kind = ? AND alert_deliveries.date >= ?)", model.DigestDeliveryKind(kind), sentSince)
+	if err := query.Find(&chats).Error; err != nil {
+		return nil, fmt.Errorf("fetch digest chats: %w", err)
+	}
+
+	return chats, nil
+}
+
+// SetDigest subscribes the chat to the digest of the kind or unsubscribes it.
+func (that *Repository) SetDigest(ctx context.Context, chatID int64, kind string, enabled bool) error {
+	return that.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		chat, err := ensureChatExists(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		query := tx.Model(&model.TgChat{}).Where("id = ?", chat.ID)
+		if err = query.Updates(map[string]interface{}{digestColumn(kind): enabled, "updated_at": time.Now()}).Error; err != nil {
+			return fmt.Errorf("update chat digest: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// SetDigestChart attaches the price chart to the weekly digests of the chat or detaches it.
+func (that *Repository) SetDigestChart(ctx context.Context, chatID int64, enabled bool) error {
+	return that.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		chat, err := ensureChatExists(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		query := tx.Model(&model.TgChat{}).Where("id = ?", chat.ID)
+		if err = query.Updates(map[string]interface{}{"digest_chart": enabled, "updated_at": time.Now()}).Error; err != nil {
+			return fmt.Errorf("update chat digest chart: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// SetAlertsSent remembers the price date the daily alerts of the chats are sent for.
+func (that *Repository) SetAlertsSent(ctx context.Context, chatIDs []int64, date time.Time, sentAt time.Time) error {
+	if len(chatIDs) == 0 {
+		return nil
+	}
+
+	query := that.db.WithContext(ctx).Model(&model.TgChat{}).Where("id IN ?", chatIDs)
+	if err := query.Updates(map[string]interface{}{"alerts_date": date, "alerts_sent_at": sentAt}).Error; err != nil {
+		return fmt.Errorf("update chats alerts date: %w", err)
+	}
+
+	return nil
+}
+
+// LoadChatAlerts loads the alert2 subscriptions and the holdings with their buying prices and the move alerts of the chats.
+func (that *Repository) LoadChatAlerts(ctx context.Context, chats []*model.TgChat) error {
 	if len(chats) == 0 {
-		return chats, nil
+		return nil
 	}
 
 	chatByID := make(map[int64]*model.TgChat, len(chats))
@@ -251,19 +671,27 @@ func (that *Repository) FetchChatsWithBuyingPrices(ctx context.Context) ([]*mode
 
 	var alerts []*model.TgChatAlert2
 	if err := that.db.WithContext(ctx).Model(&model.TgChatAlert2{}).Joins("Chat").Where("chat_id IN ?", chatIDs).Find(&alerts).Error; err != nil {
-		return nil, fmt.Errorf("fetch alert2 subscriptions: %w", err)
+		return fmt.Errorf("fetch alert2 subscriptions: %w", err)
+	}
+
+	var holdings []*model.TgChatHolding
+	if err := that.db.WithContext(ctx).Where("chat_id IN ?", chatIDs).Order("weight ASC, purchase_date ASC, id ASC").Find(&holdings).Error; err != nil {
+		return fmt.Errorf("fetch holdings: %w", err)
 	}
 
-	datesToFetch := make(map[time.Time]struct{}, len(alerts))
+	datesToFetch := make(map[time.Time]struct{}, len(alerts)+len(holdings))
 	for _, alert := range alerts {
 		datesToFetch[alert.PurchaseDate] = struct{}{}
 	}
+	for _, holding := range holdings {
+		datesToFetch[holding.PurchaseDate] = struct{}{}
+	}
 
 	var prices []*model.GoldPrice
 	if len(datesToFetch) > 0 {
-		goldPricesQuery := that.db.WithContext(ctx).Model(&model.GoldPrice{}).Where("date IN (?)", slices.Collect(maps.Keys(datesToFetch)))
+		goldPricesQuery := that.db.WithContext(ctx).Model(&model.GoldPrice{}).Where("source = ? AND date IN (?)", model.PriceSourceNBKR, slices.Collect(maps.Keys(datesToFetch)))
 		if err := goldPricesQuery.Find(&prices).Error; err != nil {
-			return nil, fmt.Errorf("fetch prices from database: %w", err)
+			return fmt.Errorf("fetch prices from database: %w", err)
 		}
 	}
 
@@ -279,7 +707,39 @@ func (that *Repository) FetchChatsWithBuyingPrices(ctx context.Context) ([]*mode
 		}
 	}
 
-	return chats, nil
+	for _, holding := range holdings {
+		for _, price := range pricesMap[holding.PurchaseDate] {
+			if price.Weight == holding.Weight {
+				holding.BuyingPrice = price
+			}
+		}
+
+		if chat := chatByID[holding.ChatID]; chat != nil {
+			chat.Holdings = append(chat.Holdings, holding)
+		}
+	}
+
+	var moveAlerts []*model.TgChatMoveAlert
+	if err := that.db.WithContext(ctx).Where("chat_id IN ?", chatIDs).Order("weight ASC, id ASC").Find(&moveAlerts).Error; err != nil {
+		return fmt.Errorf("fetch move alerts: %w", err)
+	}
+
+	for _, alert := range moveAlerts {
+		if chat := chatByID[alert.ChatID]; chat != nil {
+			chat.MoveAlerts = append(chat.MoveAlerts, alert)
+		}
+	}
+
+	return nil
+}
+
+// digestColumn returns the column of the digest subscription.
+func digestColumn(kind string) string {
+	if kind == model.DigestMonthly {
+		return "monthly_digest"
+	}
+
+	return "weekly_digest"
 }
 
 func ensureChatExists(tx *gorm.DB, sourceID int64) (*model.TgChat, error) {
@@ -0,0 +1,79 @@
+package chats
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"goldie/internal/model"
+)
+
+// CreateHolding adds the gold bars to the portfolio of the chat.
+func (that *Repository) CreateHolding(ctx context.Context, chatID int64, holding *model.TgChatHolding) error {
+	return that.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		chat, err := ensureChatExists(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		holding.ChatID = chat.ID
+		if err = tx.Omit("Chat").Create(holding).Error; err != nil {
+			return fmt.Errorf("create holding: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ListHoldings returns the holdings of the chat with the NBKR prices on their purchase dates.
+func (that *Repository) ListHoldings(ctx context.Context, chatID int64) ([]*model.TgChatHolding, error) {
+	var holdings []*model.TgChatHolding
+
+	query := that.db.WithContext(ctx).Model(&model.TgChatHolding{}).
+		Joins("JOIN tg_chats ON tg_chats.id = tg_chat_holdings.chat_id").
+		Where("tg_chats.source_id = ?", chatID).
+		Order("tg_chat_holdings.weight ASC, tg_chat_holdings.purchase_date ASC, tg_chat_holdings.id ASC")
+	if err := query.Find(&holdings).Error; err != nil {
+		return nil, fmt.Errorf("list holdings: %w", err)
+	}
+
+	if len(holdings) == 0 {
+		return holdings, nil
+	}
+
+	datesToFetch := make(map[time.Time]struct{}, len(holdings))
+	for _, holding := range holdings {
+		datesToFetch[holding.PurchaseDate] = struct{}{}
+	}
+
+	var prices []*model.GoldPrice
+	pricesQuery := that.db.WithContext(ctx).Model(&model.GoldPrice{}).Where("source = ? AND date IN (?)", model.PriceSourceNBKR, slices.Collect(maps.Keys(datesToFetch)))
+	if err := pricesQuery.Find(&prices).Error; err != nil {
+		return nil, fmt.Errorf("fetch prices from database: %w", err)
+	}
+
+	for _, holding := range holdings {
+		for _, price := range prices {
+			if price.Date.Equal(holding.PurchaseDate) && price.Weight == holding.Weight {
+				holding.BuyingPrice = price
+			}
+		}
+	}
+
+	return holdings, nil
+}
+
+// DeleteHolding deletes the holding if it belongs to the chat.
+func (that *Repository) DeleteHolding(ctx context.Context, chatID int64, holdingID int64) error {
+	query := that.db.WithContext(ctx).
+		Where("id = ? AND chat_id IN (SELECT id FROM tg_chats WHERE source_id = ?)", holdingID, chatID)
+	if err := query.Delete(&model.TgChatHolding{}).Error; err != nil {
+		return fmt.Errorf("delete holding: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package chats
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"goldie/internal/model"
+)
+
+// CreateMoveAlert creates the daily move alert for the chat.
+func (that *Repository) CreateMoveAlert(ctx context.Context, chatID int64, alert *model.TgChatMoveAlert) error {
+	return that.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		chat, err := ensureChatExists(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		alert.ChatID = chat.ID
+		if err = tx.Omit("Chat").Create(alert).Error; err != nil {
+			return fmt.Errorf("create move alert: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ListMoveAlerts returns the daily move alerts of the chat.
+func (that *Repository) ListMoveAlerts(ctx context.Context, chatID int64) ([]*model.TgChatMoveAlert, error) {
+	var alerts []*model.TgChatMoveAlert
+
+	query := that.db.WithContext(ctx).Model(&model.TgChatMoveAlert{}).
+		Joins("JOIN tg_chats ON tg_chats.id = tg_chat_move_alerts.chat_id").
+		Where("tg_chats.source_id = ?", chatID).
+		Order("tg_chat_move_alerts.weight ASC, tg_chat_move_alerts.id ASC")
+	if err := query.Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("list move alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// DeleteMoveAlert deletes the daily move alert if it belongs to the chat.
+func (that *Repository) DeleteMoveAlert(ctx context.Context, chatID int64, alertID int64) error {
+	query := that.db.WithContext(ctx).
+		Where("id = ? AND chat_id IN (SELECT id FROM tg_chats WHERE source_id = ?)", alertID, chatID)
+	if err := query.Delete(&model.TgChatMoveAlert{}).Error; err != nil {
+		return fmt.Errorf("delete move alert: %w", err)
+	}
+
+	return nil
+}
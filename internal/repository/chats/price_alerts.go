@@ -0,0 +1,82 @@
+package chats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"goldie/internal/model"
+)
+
+// CreatePriceAlert creates the threshold alert for the chat.
+func (that *Repository) CreatePriceAlert(ctx context.Context, chatID int64, alert *model.TgChatPriceAlert) error {
+	return that.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		chat, err := ensureChatExists(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		alert.ChatID = chat.ID
+		if err = tx.Omit("Chat").Create(alert).Error; err != nil {
+			return fmt.Errorf("create price alert: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ListPriceAlerts returns the threshold alerts of the chat.
+func (that *Repository) ListPriceAlerts(ctx context.Context, chatID int64) ([]*model.TgChatPriceAlert, error) {
+	var alerts []*model.TgChatPriceAlert
+
+	query := that.db.WithContext(ctx).Model(&model.TgChatPriceAlert{}).
+		Joins("JOIN tg_chats ON tg_chats.id = tg_chat_price_alerts.chat_id").
+		Where("tg_chats.source_id = ?", chatID).
+		Order("tg_chat_price_alerts.weight ASC, tg_chat_price_alerts.id ASC")
+	if err := query.Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("list price alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// DeletePriceAlert deletes the threshold alert if it belongs to the chat.
+func (that *Repository) DeletePriceAlert(ctx context.Context, chatID int64, alertID int64) error {
+	query := that.db.WithContext(ctx).
+		Where("id = ? AND chat_id IN (SELECT id FROM tg_chats WHERE source_id = ?)", alertID, chatID)
+	if err := query.Delete(&model.TgChatPriceAlert{}).Error; err != nil {
+		return fmt.Errorf("delete price alert: %w", err)
+	}
+
+	return nil
+}
+
+// FetchPriceAlerts returns the threshold alerts of the active chats which aren't paused with their chats.
+func (that *Repository) FetchPriceAlerts(ctx context.Context) ([]*model.TgChatPriceAlert, error) {
+	var alerts []*model.TgChatPriceAlert
+
+	query := that.db.WithContext(ctx).Preload("Chat").
+		Where("chat_id IN (SELECT id FROM tg_chats WHERE deactivated_at IS NULL AND (paused_until IS NULL OR paused_until <= ?))", time.Now()).
+		Order("id ASC")
+	if err := query.Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("fetch price alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// SetPriceAlertCrossed stores whether the price is beyond the alert threshold.
+func (that *Repository) SetPriceAlertCrossed(ctx context.Context, alertID int64, crossed bool) error {
+	updates := map[string]interface{}{"crossed": crossed, "updated_at": time.Now()}
+	if crossed {
+		updates["crossed_at"] = time.Now()
+	}
+
+	if err := that.db.WithContext(ctx).Model(&model.TgChatPriceAlert{}).Where("id = ?", alertID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("update price alert state: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package chats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"goldie/internal/model"
+)
+
+// CreateSignal creates the technical-indicator signal for the chat.
+func (that *Repository) CreateSignal(ctx context.Context, chatID int64, signal *model.TgChatSignal) error {
+	return that.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		chat, err := ensureChatExists(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		signal.ChatID = chat.ID
+		if err = tx.Omit("Chat").Create(signal).Error; err != nil {
+			return fmt.Errorf("create signal: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ListSignals returns the technical-indicator signals of the chat.
+func (that *Repository) ListSignals(ctx context.Context, chatID int64) ([]*model.TgChatSignal, error) {
+	var signals []*model.TgChatSignal
+
+	query := that.db.WithContext(ctx).Model(&model.TgChatSignal{}).
+		Joins("JOIN tg_chats ON tg_chats.id = tg_chat_signals.chat_id").
+		Where("tg_chats.source_id = ?", chatID).
+		Order("tg_chat_signals.weight ASC, tg_chat_signals.id ASC")
+	if err := query.Find(&signals).Error; err != nil {
+		return nil, fmt.Errorf("list signals: %w", err)
+	}
+
+	return signals, nil
+}
+
+// DeleteSignal deletes the signal if it belongs to the chat.
+func (that *Repository) DeleteSignal(ctx context.Context, chatID int64, signalID int64) error {
+	query := that.db.WithContext(ctx).
+		Where("id = ? AND chat_id IN (SELECT id FROM tg_chats WHERE source_id = ?)", signalID, chatID)
+	if err := query.Delete(&model.TgChatSignal{}).Error; err != nil {
+		return fmt.Errorf("delete signal: %w", err)
+	}
+
+	return nil
+}
+
+// FetchSignals returns the signals of the active chats which aren't paused with their chats.
+func (that *Repository) FetchSignals(ctx context.Context) ([]*model.TgChatSignal, error) {
+	var signals []*model.TgChatSignal
+
+	query := that.db.WithContext(ctx).Preload("Chat").
+		Where("chat_id IN (SELECT id FROM tg_chats WHERE deactivated_at IS NULL AND (paused_until IS NULL OR paused_until <= ?))", time.Now()).
+		Order("id ASC")
+	if err := query.Find(&signals).Error; err != nil {
+		return nil, fmt.Errorf("fetch signals: %w", err)
+	}
+
+	return signals, nil
+}
+
+// SetSignalChecked stores the state of the signal after the check with the prices of the date.
+func (that *Repository) SetSignalChecked(ctx context.Context, signalID int64, state string, date time.Time, fired bool) error {
+	updates := map[string]interface{}{"state": state, "checked_date": date, "updated_at": time.Now()}
+	if fired {
+		updates["fired_at"] = time.Now()
+	}
+
+	if err := that.db.WithContext(ctx).Model(&model.TgChatSignal{}).Where("id = ?", signalID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("update signal state: %w", err)
+	}
+
+	return nil
+}
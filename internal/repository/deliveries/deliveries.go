@@ -0,0 +1,152 @@
+package deliveries
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goldie/internal/model"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetPendingDispatchDate returns the latest price date of the source if the alerts aren't dispatched for it
+// or a later date yet, otherwise it returns nil.
+func (that *Repository) GetPendingDispatchDate(ctx context.Context, source string) (*time.Time, error) {
+	var dates []time.Time
+
+	query := that.db.WithContext(ctx).Raw(
+		`SELECT MAX(date) FROM gold_prices WHERE source = ?
+		HAVING MAX(date) > COALESCE((SELECT MAX(date) FROM alert_dispatches WHERE source = ?), '-infinity')`,
+		source, source,
+	)
+	if err := query.Scan(&dates).Error; err != nil {
+		return nil, fmt.Errorf("get pending dispatch date: %w", err)
+	}
+
+	if len(dates) == 0 {
+		return nil, nil
+	}
+
+	return &dates[0], nil
+}
+
+// ClaimDispatch records the alerts dispatch for the price date of the source once the alerts are sent.
+// It returns false if the date or a later one is already dispatched.
+func (that *Repository) ClaimDispatch(ctx context.Context, source string, date time.Time) (bool, error) {
+	query := that.db.WithContext(ctx).Exec(
+		`INSERT INTO alert_dispatches (source, date, dispatched_at)
+		SELECT ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM alert_dispatches WHERE source = ? AND date >= ?)
+		ON CONFLICT DO NOTHING`,
+		source, date, time.Now(), source, date,
+	)
+	if query.Error != nil {
+		return false, fmt.Errorf("claim alerts dispatch: %w", query.Error)
+	}
+
+	return query.RowsAffected == 1, nil
+}
+
+// CreateDelivery records the pending delivery. It returns false if the alert is already delivered or being delivered
+// to the chat for the date.
+func (that *Repository) CreateDelivery(ctx context.Context, delivery *model.AlertDelivery) (bool, error) {
+	query := that.db.WithContext(ctx).Omit("Chat").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chat_id"}, {Name: "kind"}, {Name: "date"}},
+		DoNothing: true,
+	}).Create(delivery)
+	if query.Error != nil {
+		return false, fmt.Errorf("create alert delivery: %w", query.Error)
+	}
+
+	return query.RowsAffected == 1, nil
+}
+
+// UpdateDelivery stores the outcome of the delivery attempt.
+func (that *Repository) UpdateDelivery(ctx context.Context, delivery *model.AlertDelivery) error {
+	query := that.db.WithContext(ctx).Model(&model.AlertDelivery{}).
+		Where("chat_id = ? AND kind = ? AND date = ?", delivery.ChatID, delivery.Kind, delivery.Date).
+		Updates(map[string]interface{}{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"last_error":      delivery.LastError,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"sent_at":         delivery.SentAt,
+			"updated_at":      time.Now(),
+		})
+	if query.Error != nil {
+		return fmt.Errorf("update alert delivery: %w", query.Error)
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries marks the failed deliveries which are due to be retried as pending and returns them with
+// their chats, so every delivery is retried by one caller only. The pending deliveries are never returned: if the
+// process stopped in the middle of the sending, it's unknown whether the message went out, and it isn't sent twice.
+// The deliveries of the paused chats wait until the pause is over.
+func (that *Repository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.AlertDelivery, error) {
+	var deliveries []*model.AlertDelivery
+
+	query := that.db.WithContext(ctx).Raw(`
+		UPDATE alert_deliveries SET status = ?, updated_at = ?
+		WHERE (chat_id, kind, date) IN (
+			SELECT chat_id, kind, date FROM alert_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+				AND chat_id IN (SELECT id FROM tg_chats WHERE deactivated_at IS NULL AND (paused_until IS NULL OR paused_until <= ?))
+			ORDER BY date ASC, chat_id ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		model.AlertDeliveryPending, now, model.AlertDeliveryFailed, now, now, limit,
+	)
+	if err := query.Scan(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("claim due alert deliveries: %w", err)
+	}
+
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+
+	chatIDs := make([]int64, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		chatIDs = append(chatIDs, delivery.ChatID)
+	}
+
+	var chats []*model.TgChat
+	if err := that.db.WithContext(ctx).Where("id IN ?", chatIDs).Find(&chats).Error; err != nil {
+		return nil, fmt.Errorf("get chats of alert deliveries: %w", err)
+	}
+
+	chatsByID := make(map[int64]*model.TgChat, len(chats))
+	for _, chat := range chats {
+		chatsByID[chat.ID] = chat
+	}
+
+	for _, delivery := range deliveries {
+		if chat, ok := chatsByID[delivery.ChatID]; ok {
+			delivery.Chat = *chat
+		}
+	}
+
+	slices.SortFunc(deliveries, func(a, b *model.AlertDelivery) int {
+		if c := a.Date.Compare(b.Date); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.ChatID, b.ChatID)
+	})
+
+	return deliveries, nil
+}
@@ -0,0 +1,70 @@
+package gaps
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goldie/internal/model"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetGaps returns all the known gaps of the source, including the unpublished dates.
+func (that *Repository) GetGaps(ctx context.Context, source string) ([]*model.PriceGap, error) {
+	var gaps []*model.PriceGap
+
+	query := that.db.WithContext(ctx).Where("source = ?", source).Order("date asc")
+	if err := query.Find(&gaps).Error; err != nil {
+		return nil, fmt.Errorf("get price gaps from database: %w", err)
+	}
+
+	return gaps, nil
+}
+
+// SaveGaps saves the gaps in the database using upsert.
+func (that *Repository) SaveGaps(ctx context.Context, gaps []*model.PriceGap) error {
+	if len(gaps) == 0 {
+		return nil
+	}
+
+	query := that.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "source"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"reason":      gorm.Expr("EXCLUDED.reason"),
+				"attempts":    gorm.Expr("EXCLUDED.attempts"),
+				"unpublished": gorm.Expr("EXCLUDED.unpublished"),
+				"updated_at":  gorm.Expr("EXCLUDED.updated_at"),
+			}),
+		},
+	)
+
+	if err := query.Create(gaps).Error; err != nil {
+		return fmt.Errorf("upsert price gaps in database: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteGaps deletes the gaps of the source which are filled.
+func (that *Repository) DeleteGaps(ctx context.Context, source string, dates []time.Time) error {
+	if len(dates) == 0 {
+		return nil
+	}
+
+	if err := that.db.WithContext(ctx).Where("source = ? AND date IN ?", source, dates).Delete(&model.PriceGap{}).Error; err != nil {
+		return fmt.Errorf("delete price gaps from database: %w", err)
+	}
+
+	return nil
+}
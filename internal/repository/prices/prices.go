@@ -2,7 +2,9 @@ package prices
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"gorm.io/gorm"
@@ -19,34 +21,134 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// SavePrices saves prices in the database using upsert.
-func (that *Repository) SavePrices(ctx context.Context, prices []*model.GoldPrice) error {
-	query := that.db.WithContext(ctx).Clauses(
+// SavePrices saves prices in the database using upsert. The changed prices are recorded in the revision history,
+// the unchanged ones aren't written at all.
+func (that *Repository) SavePrices(ctx context.Context, prices []*model.GoldPrice) (*model.SavePricesResult, error) {
+	var result *model.SavePricesResult
+
+	err := that.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = savePrices(tx, prices)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func savePrices(tx *gorm.DB, prices []*model.GoldPrice) (*model.SavePricesResult, error) {
+	result := &model.SavePricesResult{}
+	if len(prices) == 0 {
+		return result, nil
+	}
+
+	existing, err := lockExistingPrices(tx, prices)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	changed := make([]*model.GoldPrice, 0, len(prices))
+
+	for _, price := range prices {
+		old, ok := existing[priceKey(price)]
+		switch {
+		case !ok:
+			result.Inserted++
+		case old.PurchasePrice == price.PurchasePrice && old.SellPrice == price.SellPrice:
+			result.Unchanged++
+			continue
+		default:
+			result.Updated++
+			result.Revisions = append(result.Revisions, &model.GoldPriceRevision{
+				Date:             price.Date,
+				Weight:           price.Weight,
+				Source:           price.Source,
+				OldPurchasePrice: old.PurchasePrice,
+				NewPurchasePrice: price.PurchasePrice,
+				OldSellPrice:     old.SellPrice,
+				NewSellPrice:     price.SellPrice,
+				RevisedAt:        now,
+			})
+		}
+
+		changed = append(changed, price)
+	}
+
+	if len(changed) == 0 {
+		return result, nil
+	}
+
+	query := tx.Clauses(
 		clause.OnConflict{
-			Columns: []clause.Column{{Name: "date"}, {Name: "weight"}},
+			Columns: []clause.Column{{Name: "date"}, {Name: "weight"}, {Name: "source"}},
 			DoUpdates: clause.Assignments(map[string]interface{}{
 				"purchase_price": gorm.Expr("EXCLUDED.purchase_price"),
 				"sell_price":     gorm.Expr("EXCLUDED.sell_price"),
+				"updated_at":     gorm.Expr("EXCLUDED.updated_at"),
 			}),
 		},
-		clause.Returning{
-			// We should return the inserted fields from the upsert query to compare and count the number of objects inserted.
-			Columns: []clause.Column{{Name: "created_at"}, {Name: "updated_at"}},
-		},
 	)
 
-	if err := query.Create(prices).Error; err != nil {
-		return fmt.Errorf("upsert prices in database: %w", err)
+	if err = query.Create(changed).Error; err != nil {
+		return nil, fmt.Errorf("upsert prices in database: %w", err)
 	}
 
-	return nil
+	if len(result.Revisions) == 0 {
+		return result, nil
+	}
+
+	if err = tx.Create(result.Revisions).Error; err != nil {
+		return nil, fmt.Errorf("create price revisions: %w", err)
+	}
+
+	return result, nil
+}
+
+// lockExistingPrices returns the stored prices matching the given ones by date, weight and source.
+func lockExistingPrices(tx *gorm.DB, prices []*model.GoldPrice) (map[string]*model.GoldPrice, error) {
+	dates := make([]time.Time, 0, len(prices))
+	sources := make([]string, 0, 1)
+
+	for _, price := range prices {
+		dates = append(dates, price.Date)
+		if !slices.Contains(sources, price.Source) {
+			sources = append(sources, price.Source)
+		}
+	}
+
+	var stored []*model.GoldPrice
+
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("source IN ? AND date IN ?", sources, dates)
+	if err := query.Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("get existing prices from database: %w", err)
+	}
+
+	existing := make(map[string]*model.GoldPrice, len(stored))
+	for _, price := range stored {
+		existing[priceKey(price)] = price
+	}
+
+	return existing, nil
 }
 
-// GetLatestPrices returns the latest prices from the database.
+func priceKey(price *model.GoldPrice) string {
+	return fmt.Sprintf("%s|%s|%g", price.Source, price.Date.UTC().Format(time.RFC3339), price.Weight)
+}
+
+// GetLatestPrices returns the latest NBKR prices from the database.
 func (that *Repository) GetLatestPrices(ctx context.Context) ([]*model.GoldPrice, error) {
+	return that.GetLatestPricesBySource(ctx, model.PriceSourceNBKR)
+}
+
+// GetLatestPricesBySource returns the latest prices of the given source from the database.
+func (that *Repository) GetLatestPricesBySource(ctx context.Context, source string) ([]*model.GoldPrice, error) {
 	var prices []*model.GoldPrice
 
-	query := that.db.WithContext(ctx).Where("date = (SELECT MAX(date) FROM gold_prices)").Order("weight asc")
+	query := that.db.WithContext(ctx).Where("source = ? AND date = (SELECT MAX(date) FROM gold_prices WHERE source = ?)", source, source).Order("weight asc")
 	if err := query.Find(&prices).Error; err != nil {
 		return nil, fmt.Errorf("get prices from database: %w", err)
 	}
@@ -54,23 +156,134 @@ func (that *Repository) GetLatestPrices(ctx context.Context) ([]*model.GoldPrice
 	return prices, nil
 }
 
-// ExistsFirstPrice checks if the first price exists in the database.
-func (that *Repository) ExistsFirstPrice(ctx context.Context, date time.Time) (bool, error) {
+// GetLatestPricesBefore returns the prices of the source for the latest date before the given one.
+func (that *Repository) GetLatestPricesBefore(ctx context.Context, source string, date time.Time) ([]*model.GoldPrice, error) {
 	var prices []*model.GoldPrice
 
-	query := that.db.WithContext(ctx).Where("date = ?", date)
+	query := that.db.WithContext(ctx).
+		Where("source = ? AND date = (SELECT MAX(date) FROM gold_prices WHERE source = ? AND date < ?)", source, source, date).
+		Order("weight asc")
 	if err := query.Find(&prices).Error; err != nil {
-		return false, fmt.Errorf("check if first price exists in database: %w", err)
+		return nil, fmt.Errorf("get previous prices from database: %w", err)
 	}
 
-	return len(prices) > 0, nil
+	return prices, nil
 }
 
-// GetFirstPriceDate returns the date of the first price.
+// GetLastPricesBefore returns up to count latest prices of every weight of the source before the date ordered by weight
+// and date, so the days without prices don't shorten the period.
+func (that *Repository) GetLastPricesBefore(ctx context.Context, source string, date time.Time, count int) ([]*model.GoldPrice, error) {
+	var prices []*model.GoldPrice
+
+	query := that.db.WithContext(ctx).Raw(`
+		SELECT *
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY weight ORDER BY date DESC) AS position
+			FROM gold_prices
+			WHERE source = ? AND date < ?
+		) AS latest
+		WHERE position <= ?
+		ORDER BY weight ASC, date ASC`, source, date, count)
+	if err := query.Scan(&prices).Error; err != nil {
+		return nil, fmt.Errorf("get last prices from database: %w", err)
+	}
+
+	return prices, nil
+}
+
+// GetPricesBetween returns the prices of the source in the date range, the end date is excluded.
+func (that *Repository) GetPricesBetween(ctx context.Context, source string, begin, end time.Time) ([]*model.GoldPrice, error) {
+	var prices []*model.GoldPrice
+
+	query := that.db.WithContext(ctx).Where("source = ? AND date >= ? AND date < ?", source, begin, end).Order("date asc, weight asc")
+	if err := query.Find(&prices).Error; err != nil {
+		return nil, fmt.Errorf("get prices from database: %w", err)
+	}
+
+	return prices, nil
+}
+
+// GetWeightPricesBetween returns the prices of one weight of the source in the date range, the end date is excluded.
+func (that *Repository) GetWeightPricesBetween(ctx context.Context, source string, weight float64, begin, end time.Time) ([]*model.GoldPrice, error) {
+	var prices []*model.GoldPrice
+
+	query := that.db.WithContext(ctx).Where("source = ? AND weight = ? AND date >= ? AND date < ?", source, weight, begin, end).Order("date asc")
+	if err := query.Find(&prices).Error; err != nil {
+		return nil, fmt.Errorf("get weight prices from database: %w", err)
+	}
+
+	return prices, nil
+}
+
+// GetPeriodStats returns the open, close, high and low sell prices of every weight of the source in the date range,
+// the end date is excluded.
+func (that *Repository) GetPeriodStats(ctx context.Context, source string, begin, end time.Time) ([]*model.PeriodPriceStats, error) {
+	var stats []*model.PeriodPriceStats
+
+	query := that.db.WithContext(ctx).Model(&model.GoldPrice{}).
+		Select(`weight,
+			MIN(date) AS open_date,
+			MAX(date) AS close_date,
+			(ARRAY_AGG(sell_price ORDER BY date ASC))[1] AS open,
+			(ARRAY_AGG(sell_price ORDER BY date DESC))[1] AS close,
+			MAX(sell_price) AS high,
+			MIN(sell_price) AS low,
+			(ARRAY_AGG(purchase_price ORDER BY date ASC))[1] AS purchase_open,
+			(ARRAY_AGG(purchase_price ORDER BY date DESC))[1] AS purchase_close`).
+		Where("source = ? AND date >= ? AND date < ?", source, begin, end).
+		Group("weight").
+		Order("weight asc")
+	if err := query.Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("get period stats from database: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetDailyChanges returns the average change of the sell prices of all weights for every date of the source
+// in the date range, the end date is excluded. The first date is compared with the latest date before the range.
+func (that *Repository) GetDailyChanges(ctx context.Context, source string, begin, end time.Time) ([]*model.DailyChange, error) {
+	var changes []*model.DailyChange
+
+	query := that.db.WithContext(ctx).Raw(`
+		SELECT date, AVG(percent) AS percent
+		FROM (
+			SELECT date, (sell_price - LAG(sell_price) OVER w) / LAG(sell_price) OVER w * 100 AS percent
+			FROM gold_prices
+			WHERE source = ? AND date < ? AND date >= COALESCE((SELECT MAX(date) FROM gold_prices WHERE source = ? AND date < ?), ?)
+			WINDOW w AS (PARTITION BY weight ORDER BY date)
+		) AS changes
+		WHERE date >= ? AND percent IS NOT NULL
+		GROUP BY date
+		ORDER BY date ASC`, source, end, source, begin, begin, begin)
+	if err := query.Scan(&changes).Error; err != nil {
+		return nil, fmt.Errorf("get daily changes from database: %w", err)
+	}
+
+	return changes, nil
+}
+
+// GetPricesRevision returns the time the prices of the source were saved or revised last.
+func (that *Repository) GetPricesRevision(ctx context.Context, source string) (time.Time, error) {
+	var revision *time.Time
+
+	query := that.db.WithContext(ctx).Model(&model.GoldPrice{}).Select("MAX(updated_at)").Where("source = ?", source)
+	if err := query.Scan(&revision).Error; err != nil {
+		return time.Time{}, fmt.Errorf("get prices revision from database: %w", err)
+	}
+
+	if revision == nil {
+		return time.Time{}, nil
+	}
+
+	return *revision, nil
+}
+
+// GetFirstPriceDate returns the date of the first NBKR price.
 func (that *Repository) GetFirstPriceDate(ctx context.Context) (time.Time, error) {
 	var prices []*model.GoldPrice
 
-	query := that.db.WithContext(ctx).Order("date asc")
+	query := that.db.WithContext(ctx).Where("source = ?", model.PriceSourceNBKR).Order("date asc")
 	if err := query.Find(&prices).Error; err != nil {
 		return time.Time{}, fmt.Errorf("get first price date from database: %w", err)
 	}
@@ -81,3 +294,64 @@ func (that *Repository) GetFirstPriceDate(ctx context.Context) (time.Time, error
 
 	return prices[0].Date, nil
 }
+
+// GetWeightsByDate returns the number of published weights for every date of the source.
+func (that *Repository) GetWeightsByDate(ctx context.Context, source string) ([]*model.PriceDateWeights, error) {
+	var dates []*model.PriceDateWeights
+
+	query := that.db.WithContext(ctx).Model(&model.GoldPrice{}).
+		Select("date, COUNT(*) AS weights").
+		Where("source = ?", source).
+		Group("date").
+		Order("date asc")
+	if err := query.Scan(&dates).Error; err != nil {
+		return nil, fmt.Errorf("get weights by date from database: %w", err)
+	}
+
+	return dates, nil
+}
+
+// SaveCurrencyRates saves exchange rates in the database using upsert.
+func (that *Repository) SaveCurrencyRates(ctx context.Context, rates []*model.CurrencyRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	query := that.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "date"}, {Name: "currency"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"rate":       gorm.Expr("EXCLUDED.rate"),
+				"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+			}),
+		},
+	)
+
+	if err := query.Create(rates).Error; err != nil {
+		return fmt.Errorf("upsert currency rates in database: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestCurrencyRate returns the latest exchange rate of the currency or nil if there is no rate.
+func (that *Repository) GetLatestCurrencyRate(ctx context.Context, currency string) (*model.CurrencyRate, error) {
+	return that.GetCurrencyRate(ctx, currency, time.Now())
+}
+
+// GetCurrencyRate returns the exchange rate of the currency on the date or the closest rate before it.
+// Returns nil if there is no rate.
+func (that *Repository) GetCurrencyRate(ctx context.Context, currency string, date time.Time) (*model.CurrencyRate, error) {
+	var rate model.CurrencyRate
+
+	query := that.db.WithContext(ctx).Where("currency = ? AND date <= ?", currency, date).Order("date desc")
+	if err := query.First(&rate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("get currency rate from database: %w", err)
+	}
+
+	return &rate, nil
+}
@@ -0,0 +1,137 @@
+package prices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goldie/internal/model"
+)
+
+// ErrNotPending is returned when the quarantined price doesn't exist or is already reviewed.
+var ErrNotPending = errors.New("quarantined price is not pending")
+
+// QuarantinePrices saves the suspicious prices for the review and returns how many of them are new.
+// The prices which are already reviewed keep their decision.
+func (that *Repository) QuarantinePrices(ctx context.Context, prices []*model.QuarantinedPrice) (int64, error) {
+	if len(prices) == 0 {
+		return 0, nil
+	}
+
+	query := that.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}, {Name: "weight"}, {Name: "source"}, {Name: "purchase_price"}, {Name: "sell_price"}},
+		DoNothing: true,
+	}).Create(prices)
+	if query.Error != nil {
+		return 0, fmt.Errorf("quarantine prices in database: %w", query.Error)
+	}
+
+	return query.RowsAffected, nil
+}
+
+// GetApprovedQuarantinedPrices returns the approved quarantined prices of the source in the date range.
+func (that *Repository) GetApprovedQuarantinedPrices(ctx context.Context, source string, begin, end time.Time) ([]*model.QuarantinedPrice, error) {
+	var prices []*model.QuarantinedPrice
+
+	query := that.db.WithContext(ctx).Where("source = ? AND date >= ? AND date <= ? AND status = ?", source, begin, end, model.QuarantineApproved)
+	if err := query.Find(&prices).Error; err != nil {
+		return nil, fmt.Errorf("get approved quarantined prices from database: %w", err)
+	}
+
+	return prices, nil
+}
+
+// GetPendingQuarantinedDates returns the dates of the source which have prices waiting for the review.
+func (that *Repository) GetPendingQuarantinedDates(ctx context.Context, source string) ([]time.Time, error) {
+	var dates []time.Time
+
+	query := that.db.WithContext(ctx).Model(&model.QuarantinedPrice{}).Where("source = ? AND status = ?", source, model.QuarantinePending).Order("date asc")
+	if err := query.Distinct().Pluck("date", &dates).Error; err != nil {
+		return nil, fmt.Errorf("get pending quarantined dates from database: %w", err)
+	}
+
+	return dates, nil
+}
+
+// ListPendingQuarantinedPrices returns the oldest quarantined prices waiting for the review and the total number of them.
+func (that *Repository) ListPendingQuarantinedPrices(ctx context.Context, limit int) ([]*model.QuarantinedPrice, int64, error) {
+	var prices []*model.QuarantinedPrice
+	var total int64
+
+	query := that.db.WithContext(ctx).Model(&model.QuarantinedPrice{}).Where("status = ?", model.QuarantinePending)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("count pending quarantined prices: %w", err)
+	}
+
+	if err := query.Order("date asc, weight asc").Limit(limit).Find(&prices).Error; err != nil {
+		return nil, 0, fmt.Errorf("list pending quarantined prices: %w", err)
+	}
+
+	return prices, total, nil
+}
+
+// ApproveQuarantinedPrice marks the pending price as approved and saves it as a regular price.
+// The approved missing weight is only acknowledged, there is nothing to save.
+func (that *Repository) ApproveQuarantinedPrice(ctx context.Context, id int64) (*model.QuarantinedPrice, error) {
+	var price model.QuarantinedPrice
+
+	err := that.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := reviewQuarantinedPrice(tx, id, model.QuarantineApproved, &price); err != nil {
+			return err
+		}
+
+		if price.IsMissing() {
+			return nil
+		}
+
+		if _, err := savePrices(tx, []*model.GoldPrice{price.GoldPrice()}); err != nil {
+			return fmt.Errorf("save approved price: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &price, nil
+}
+
+// RejectQuarantinedPrice marks the pending price as rejected, so it's never saved.
+func (that *Repository) RejectQuarantinedPrice(ctx context.Context, id int64) (*model.QuarantinedPrice, error) {
+	var price model.QuarantinedPrice
+
+	err := that.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return reviewQuarantinedPrice(tx, id, model.QuarantineRejected, &price)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &price, nil
+}
+
+func reviewQuarantinedPrice(tx *gorm.DB, id int64, status string, price *model.QuarantinedPrice) error {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND status = ?", id, model.QuarantinePending)
+	if err := query.First(price).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("quarantined price %d: %w", id, ErrNotPending)
+		}
+
+		return fmt.Errorf("get quarantined price: %w", err)
+	}
+
+	now := time.Now()
+	price.Status = status
+	price.ReviewedAt = &now
+
+	if err := tx.Model(price).Updates(map[string]interface{}{"status": status, "reviewed_at": now}).Error; err != nil {
+		return fmt.Errorf("update quarantined price: %w", err)
+	}
+
+	return nil
+}
@@ -18,8 +18,12 @@ type Scheduler struct {
 	}
 }
 
+// New creates the scheduler, the job isn't started again until its previous run is finished.
 func New(ctx context.Context, loc *time.Location) *Scheduler {
-	return &Scheduler{s: gocron.NewScheduler(loc), ctx: ctx}
+	s := gocron.NewScheduler(loc)
+	s.SingletonModeAll()
+
+	return &Scheduler{s: s, ctx: ctx}
 }
 
 func (sch *Scheduler) Add(spec string, fn JobFunc) {
@@ -56,19 +56,64 @@ func (s *PostgresConnection) MustClose() {
 }
 
 func (s *PostgresConnection) MustMigration() {
+	migrateGoldPriceSource(s.DB)
+
+	// The column is checked before AutoMigrate adds it
+	seedAlertsDate := s.DB.Migrator().HasTable(&model.TgChat{}) && !s.DB.Migrator().HasColumn(&model.TgChat{}, "alerts_date")
+
 	err := s.DB.AutoMigrate(
 		model.GoldPrice{},
 		model.TgChat{},
 		model.TgChatAlert2{},
+		model.TgChatPriceAlert{},
+		model.TgChatMoveAlert{},
+		model.TgChatHolding{},
+		model.TgChatSignal{},
+		model.CurrencyRate{},
+		model.ArchivedPage{},
+		model.BackfillChunk{},
+		model.PriceGap{},
+		model.GoldPriceRevision{},
+		model.QuarantinedPrice{},
+		model.AlertDispatch{},
+		model.AlertDelivery{},
 	)
 
 	if err != nil {
 		panic(fmt.Errorf("migrate models: %w", err))
 	}
 
+	if seedAlertsDate {
+		migrateAlertsDate(s.DB)
+	}
+
 	migrateAlert2Data(s.DB)
 }
 
+// migrateAlertsDate marks the latest prices as already alerted for the existing chats, the fixed schedule
+// has sent them, so the chats don't get the same alerts again at their delivery time.
+func migrateAlertsDate(db *gorm.DB) {
+	if err := db.Exec(`UPDATE tg_chats SET alerts_date = (SELECT MAX(date) FROM gold_prices) WHERE alerts_date IS NULL`).Error; err != nil {
+		panic(fmt.Errorf("seed alerts date: %w", err))
+	}
+}
+
+// migrateGoldPriceSource drops the legacy date_weight index without the source column.
+// AutoMigrate recreates it together with the source column right after.
+func migrateGoldPriceSource(db *gorm.DB) {
+	migrator := db.Migrator()
+
+	if !migrator.HasTable(&model.GoldPrice{}) || migrator.HasColumn(&model.GoldPrice{}, "source") {
+		return
+	}
+
+	if migrator.HasIndex(&model.GoldPrice{}, "date_weight") {
+		if err := migrator.DropIndex(&model.GoldPrice{}, "date_weight"); err != nil {
+			panic(fmt.Errorf("drop legacy date_weight index: %w", err))
+		}
+	}
+}
+
 func migrateAlert2Data(db *gorm.DB) {
 	migrator := db.Migrator()
 
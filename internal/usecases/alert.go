@@ -15,16 +15,31 @@ const ParallelSendLimit = 100
 
 type AlertPricesRepository interface {
 	GetLatestPrices(ctx context.Context) ([]*model.GoldPrice, error)
+	GetLatestCurrencyRate(ctx context.Context, currency string) (*model.CurrencyRate, error)
+	GetCurrencyRate(ctx context.Context, currency string, date time.Time) (*model.CurrencyRate, error)
+	GetLatestPricesBefore(ctx context.Context, source string, date time.Time) ([]*model.GoldPrice, error)
+	GetLastPricesBefore(ctx context.Context, source string, date time.Time, count int) ([]*model.GoldPrice, error)
 }
 
 type AlertChatsRepository interface {
-	FetchChatsWithBuyingPrices(ctx context.Context) ([]*model.TgChat, error)
+	FetchAlertChats(ctx context.Context, date time.Time) ([]*model.TgChat, error)
+	LoadChatAlerts(ctx context.Context, chats []*model.TgChat) error
+	SetAlertsSent(ctx context.Context, chatIDs []int64, date time.Time, sentAt time.Time) error
+	SetAlert2Crossed(ctx context.Context, subscriptionID int64, crossing string, crossedAt time.Time) error
 }
 
 type AlertTGIntegration interface {
-	SendMessage(ctx context.Context, chatID int64, text string) error
-	PricesToString(languageCode string, prices []*model.GoldPrice) string
-	PricesWithGainToString(languageCode string, prices []*model.GoldPrice, buyingPrices []*model.GoldPrice) string
+	PricesToString(languageCode string, prices []*model.GoldPrice, rate *model.CurrencyRate) string
+	PricesWithGainToString(languageCode string, view string, prices []*model.GoldPrice, buyingPrices []*model.GoldPrice, rate *model.CurrencyRate, buyingRate *model.CurrencyRate) string
+	MoveAlertsToString(languageCode string, moves []*model.PriceMove) string
+	PortfolioToString(languageCode string, portfolio *model.Portfolio) string
+	Alert2CrossingToString(languageCode string, crossing string, alert *model.TgChatAlert2, gain float64) string
+}
+
+// AlertDeliverer sends the alert once per chat, kind and price date.
+type AlertDeliverer interface {
+	Deliver(ctx context.Context, chat *model.TgChat, kind string, date time.Time, text string) bool
+	Queue(ctx context.Context, chat *model.TgChat, kind string, date time.Time, text string) bool
 }
 
 type AlertUseCase struct {
@@ -34,12 +49,15 @@ type AlertUseCase struct {
 	pricesRepository AlertPricesRepository
 	chatsRepository  AlertChatsRepository
 	tgIntegration    AlertTGIntegration
+	deliverer        AlertDeliverer
 }
 
-func NewAlertUseCase(logger *slog.Logger, bundle *i18n.Bundle, loc *time.Location, pricesRepository AlertPricesRepository, chatsRepository AlertChatsRepository, tgIntegration AlertTGIntegration) *AlertUseCase {
-	return &AlertUseCase{logger: logger.With("component", "alert1"), bundle: bundle, loc: loc, pricesRepository: pricesRepository, chatsRepository: chatsRepository, tgIntegration: tgIntegration}
+func NewAlertUseCase(logger *slog.Logger, bundle *i18n.Bundle, loc *time.Location, pricesRepository AlertPricesRepository, chatsRepository AlertChatsRepository, tgIntegration AlertTGIntegration, deliverer AlertDeliverer) *AlertUseCase {
+	return &AlertUseCase{logger: logger.With("component", "alert1"), bundle: bundle, loc: loc, pricesRepository: pricesRepository, chatsRepository: chatsRepository, tgIntegration: tgIntegration, deliverer: deliverer}
 }
 
+// Run sends the daily alerts for the latest prices to the chats whose delivery time has come.
+// Every chat gets the alerts once a day and once for the price date.
 func (that *AlertUseCase) Run(ctx context.Context) {
 	log := that.logger.With("method", "Run")
 
@@ -54,34 +72,76 @@ func (that *AlertUseCase) Run(ctx context.Context) {
 		return
 	}
 
-	chats, err := that.chatsRepository.FetchChatsWithBuyingPrices(ctx)
+	// Every alert is sent once for the date, the failed ones are retried by the delivery worker
+	date := prices[0].Date
+	now := time.Now()
+
+	candidates, err := that.chatsRepository.FetchAlertChats(ctx, date)
 	if err != nil {
 		log.Error("failed to get chats", "error", err)
 		return
 	}
 
-	// Prepare localized texts for all languages
+	chats := make([]*model.TgChat, 0, len(candidates))
+	for _, chat := range candidates {
+		if chat.IsAlertsDue(now) {
+			chats = append(chats, chat)
+		}
+	}
+
+	if len(chats) == 0 {
+		return
+	}
+
+	if err = that.chatsRepository.LoadChatAlerts(ctx, chats); err != nil {
+		log.Error("failed to get chat alerts", "error", err)
+		return
+	}
+
+	// Prepare the latest exchange rates for all display currencies
+	latestRates := make(map[string]*model.CurrencyRate)
+	for _, chat := range chats {
+		currency := chat.GetCurrency()
+		if _, exists := latestRates[currency]; exists || currency == model.CurrencyKGS {
+			continue
+		}
+
+		if latestRates[currency], err = that.pricesRepository.GetLatestCurrencyRate(ctx, currency); err != nil {
+			log.Error("failed to get currency rate", "error", err, "currency", currency)
+		}
+	}
+
+	// Prepare localized texts for all languages and currencies
 	localizedAlert1Lookup := make(map[string]string)
 	for _, chat := range chats {
 		if !chat.Alert1Enabled {
 			continue
 		}
 
-		languageCode := chat.GetLanguageCode()
-		if _, exists := localizedAlert1Lookup[languageCode]; !exists {
-			localizedAlert1Lookup[languageCode] = that.tgIntegration.PricesToString(languageCode, prices)
+		lookupKey := chat.GetLanguageCode() + ":" + chat.GetCurrency()
+		if _, exists := localizedAlert1Lookup[lookupKey]; !exists {
+			localizedAlert1Lookup[lookupKey] = that.tgIntegration.PricesToString(chat.GetLanguageCode(), prices, latestRates[chat.GetCurrency()])
 		}
 	}
 
+	// Prepare the baselines of the move alerts, the quiet days produce no message
+	baselines, err := that.loadMoveBaselines(ctx, chats, prices[0].Date)
+	if err != nil {
+		log.Error("failed to get move alert baselines", "error", err)
+	}
+
+	latestPrices := make(map[float64]*model.GoldPrice, len(prices))
+	for _, price := range prices {
+		latestPrices[price.Weight] = price
+	}
+
 	parallelSend, parallelSendCtx := errgroup.WithContext(ctx)
 	parallelSend.SetLimit(ParallelSendLimit)
 
 	for _, chat := range chats {
 		if chat.Alert1Enabled {
 			parallelSend.Go(func() error {
-				if err = that.tgIntegration.SendMessage(parallelSendCtx, chat.SourceID, localizedAlert1Lookup[chat.GetLanguageCode()]); err != nil {
-					log.Error("failed to send alert1", "error", err, "chat_id", chat.SourceID)
-				}
+				that.deliverer.Deliver(parallelSendCtx, chat, model.AlertKindAlert1, date, localizedAlert1Lookup[chat.GetLanguageCode()+":"+chat.GetCurrency()])
 				return nil
 			})
 		}
@@ -91,11 +151,38 @@ func (that *AlertUseCase) Run(ctx context.Context) {
 				continue
 			}
 
-			parallelSend.Go(func() error {
-				textForAlert2 := that.tgIntegration.PricesWithGainToString(chat.GetLanguageCode(), prices, alert.BuyingPrices)
-				if err = that.tgIntegration.SendMessage(parallelSendCtx, chat.SourceID, textForAlert2); err != nil {
-					log.Error("failed to send alert2", "error", err, "chat_id", chat.SourceID)
+			if alert.GetMode() == model.Alert2ModeCrossing {
+				that.notifyAlert2Crossings(parallelSendCtx, parallelSend, chat, alert, prices, date, now)
+				continue
+			}
+
+			rate := latestRates[chat.GetCurrency()]
+
+			var buyingRate *model.CurrencyRate
+			if rate != nil {
+				var rateErr error
+				if buyingRate, rateErr = that.pricesRepository.GetCurrencyRate(ctx, rate.Currency, alert.PurchaseDate); rateErr != nil {
+					log.Error("failed to get currency rate on purchase date", "error", rateErr, "chat_id", chat.SourceID)
 				}
+			}
+
+			parallelSend.Go(func() error {
+				textForAlert2 := that.tgIntegration.PricesWithGainToString(chat.GetLanguageCode(), chat.GetGainView(), prices, alert.BuyingPrices, rate, buyingRate)
+				that.deliverer.Deliver(parallelSendCtx, chat, model.Alert2DeliveryKind(alert.ID), date, textForAlert2)
+				return nil
+			})
+		}
+
+		if portfolio := model.NewPortfolio(chat.Holdings, prices, chat.GetGainView()); len(portfolio.Lines) > 0 {
+			parallelSend.Go(func() error {
+				that.deliverer.Deliver(parallelSendCtx, chat, model.AlertKindPortfolio, date, that.tgIntegration.PortfolioToString(chat.GetLanguageCode(), portfolio))
+				return nil
+			})
+		}
+
+		if moves := findPriceMoves(chat.MoveAlerts, latestPrices, baselines); len(moves) > 0 {
+			parallelSend.Go(func() error {
+				that.deliverer.Deliver(parallelSendCtx, chat, model.AlertKindMove, date, that.tgIntegration.MoveAlertsToString(chat.GetLanguageCode(), moves))
 				return nil
 			})
 		}
@@ -103,4 +190,43 @@ func (that *AlertUseCase) Run(ctx context.Context) {
 
 	// Wait for all parallel sends to finish
 	_ = parallelSend.Wait()
+
+	chatIDs := make([]int64, len(chats))
+	for i, chat := range chats {
+		chatIDs[i] = chat.ID
+	}
+
+	if err = that.chatsRepository.SetAlertsSent(ctx, chatIDs, date, now); err != nil {
+		log.Error("failed to mark alerts as sent", "error", err)
+	}
+
+	log.Info("daily alerts sent", "chats", len(chats), "date", date)
+}
+
+// notifyAlert2Crossings sends a notification about every level the alert2 position has crossed for the first time.
+// The crossing is remembered once the notification is recorded in the delivery ledger, so it's neither lost nor sent
+// again.
+func (that *AlertUseCase) notifyAlert2Crossings(ctx context.Context, parallelSend *errgroup.Group, chat *model.TgChat, alert *model.TgChatAlert2, prices []*model.GoldPrice, date time.Time, now time.Time) {
+	log := that.logger.With("method", "notifyAlert2Crossings", "chat_id", chat.SourceID, "subscription_id", alert.ID)
+
+	gain, ok := model.PositionGain(chat.GetGainView(), prices, alert.BuyingPrices)
+	if !ok {
+		return
+	}
+
+	for _, crossing := range alert.Crossings(gain) {
+		parallelSend.Go(func() error {
+			text := that.tgIntegration.Alert2CrossingToString(chat.GetLanguageCode(), crossing, alert, gain)
+			if !that.deliverer.Queue(ctx, chat, model.Alert2CrossingDeliveryKind(alert.ID, crossing), date, text) {
+				// The crossing isn't remembered, so it's notified by the next run
+				return nil
+			}
+
+			if err := that.chatsRepository.SetAlert2Crossed(ctx, alert.ID, crossing, now); err != nil {
+				log.Error("failed to remember alert2 crossing", "error", err, "crossing", crossing)
+			}
+
+			return nil
+		})
+	}
 }
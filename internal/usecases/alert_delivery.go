@@ -0,0 +1,144 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"goldie/internal/model"
+)
+
+const retryDeliveriesBatchSize = 100
+
+type AlertDeliveriesRepository interface {
+	CreateDelivery(ctx context.Context, delivery *model.AlertDelivery) (bool, error)
+	UpdateDelivery(ctx context.Context, delivery *model.AlertDelivery) error
+	ClaimDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.AlertDelivery, error)
+}
+
+type AlertDeliverySender interface {
+	SendMessage(ctx context.Context, chatID int64, text string) error
+}
+
+// AlertDeliveryOptions configures the retries of the failed deliveries.
+type AlertDeliveryOptions struct {
+	MaxAttempts  int
+	RetryBackoff time.Duration // doubled after every failed attempt
+}
+
+type AlertDeliveryUseCase struct {
+	logger     *slog.Logger
+	repository AlertDeliveriesRepository
+	sender     AlertDeliverySender
+	options    AlertDeliveryOptions
+}
+
+func NewAlertDeliveryUseCase(logger *slog.Logger, repository AlertDeliveriesRepository, sender AlertDeliverySender, options AlertDeliveryOptions) *AlertDeliveryUseCase {
+	return &AlertDeliveryUseCase{logger: logger.With("component", "alert_delivery"), repository: repository, sender: sender, options: options}
+}
+
+// Deliver sends the alert to the chat unless it's already sent for the price date. It reports whether the alert
+// is sent by this call, the failed one is retried later.
+func (that *AlertDeliveryUseCase) Deliver(ctx context.Context, chat *model.TgChat, kind string, date time.Time, text string) bool {
+	log := that.logger.With("method", "Deliver", "chat_id", chat.SourceID, "kind", kind, "date", date)
+
+	delivery, created := that.create(ctx, log, chat, kind, date, text)
+	if !created {
+		return false
+	}
+
+	return that.send(ctx, log, delivery)
+}
+
+// Queue sends the alert like Deliver, but it reports whether the alert is recorded for the price date, by this call
+// or before. The recorded alert isn't lost, the failed one is retried later.
+func (that *AlertDeliveryUseCase) Queue(ctx context.Context, chat *model.TgChat, kind string, date time.Time, text string) bool {
+	log := that.logger.With("method", "Queue", "chat_id", chat.SourceID, "kind", kind, "date", date)
+
+	delivery, created := that.create(ctx, log, chat, kind, date, text)
+	if created {
+		that.send(ctx, log, delivery)
+	}
+
+	return delivery != nil
+}
+
+// create records the pending delivery and reports whether it's new. The delivery is nil if it can't be recorded.
+func (that *AlertDeliveryUseCase) create(ctx context.Context, log *slog.Logger, chat *model.TgChat, kind string, date time.Time, text string) (*model.AlertDelivery, bool) {
+	delivery := &model.AlertDelivery{ChatID: chat.ID, Kind: kind, Date: date, Status: model.AlertDeliveryPending, Text: text}
+
+	// The alert isn't sent if the delivery can't be recorded, otherwise it may be sent twice
+	created, err := that.repository.CreateDelivery(ctx, delivery)
+	if err != nil {
+		log.Error("failed to create alert delivery", "error", err)
+		return nil, false
+	}
+
+	if !created {
+		log.Debug("alert is already delivered")
+		return delivery, false
+	}
+
+	delivery.Chat = *chat
+	return delivery, true
+}
+
+// RetryFailed sends the failed deliveries which are due again. The interrupted deliveries aren't retried,
+// the message may be already sent.
+func (that *AlertDeliveryUseCase) RetryFailed(ctx context.Context) {
+	log := that.logger.With("method", "RetryFailed")
+
+	deliveries, err := that.repository.ClaimDueDeliveries(ctx, time.Now(), retryDeliveriesBatchSize)
+	if err != nil {
+		log.Error("failed to claim due deliveries", "error", err)
+		return
+	}
+
+	var sent int
+	for _, delivery := range deliveries {
+		if that.send(ctx, log.With("chat_id", delivery.Chat.SourceID, "kind", delivery.Kind, "date", delivery.Date), delivery) {
+			sent++
+		}
+	}
+
+	if len(deliveries) > 0 {
+		log.Info("retried alert deliveries", "deliveries", len(deliveries), "sent", sent)
+	}
+}
+
+// send makes the delivery attempt and records its outcome, the failed delivery is scheduled for the retry.
+func (that *AlertDeliveryUseCase) send(ctx context.Context, log *slog.Logger, delivery *model.AlertDelivery) bool {
+	delivery.Attempts++
+
+	sendErr := that.sender.SendMessage(ctx, delivery.Chat.SourceID, delivery.Text)
+
+	now := time.Now()
+	delivery.NextAttemptAt = nil
+
+	if sendErr == nil {
+		delivery.Status = model.AlertDeliverySent
+		delivery.LastError = ""
+		delivery.SentAt = &now
+	} else {
+		delivery.Status = model.AlertDeliveryFailed
+		delivery.LastError = sendErr.Error()
+
+		switch {
+		case errors.Is(sendErr, model.ErrChatUnavailable):
+			log.Info("chat is unavailable, the alert won't be retried", "error", sendErr)
+		case delivery.Attempts < that.options.MaxAttempts:
+			nextAttemptAt := now.Add(that.options.RetryBackoff << (delivery.Attempts - 1))
+			delivery.NextAttemptAt = &nextAttemptAt
+			log.Warn("failed to send alert, it will be retried", "error", sendErr, "attempt", delivery.Attempts, "next_attempt_at", nextAttemptAt)
+		default:
+			log.Error("failed to send alert, giving up", "error", sendErr, "attempt", delivery.Attempts)
+		}
+	}
+
+	if err := that.repository.UpdateDelivery(ctx, delivery); err != nil {
+		log.Error("failed to update alert delivery", "error", err)
+	}
+
+	return sendErr == nil
+}
@@ -0,0 +1,104 @@
+package usecases
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"goldie/internal/model"
+)
+
+type AlertDispatchRepository interface {
+	GetPendingDispatchDate(ctx context.Context, source string) (*time.Time, error)
+	ClaimDispatch(ctx context.Context, source string, date time.Time) (bool, error)
+}
+
+// AlertDispatchJob sends the alerts for the latest prices. It's run again until it succeeds, so the alerts which are
+// already sent must not be sent twice.
+type AlertDispatchJob interface {
+	Run(ctx context.Context) error
+}
+
+// DeliveryWindow is the time of the day the price alerts are dispatched, it's counted from midnight.
+// The daily alerts and the signals don't use it, they are sent at the delivery time of every chat. The zero window dispatches
+// the alerts at any time.
+type DeliveryWindow struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// Delay returns how long to wait for the window to open.
+func (that DeliveryWindow) Delay(now time.Time) time.Duration {
+	if that.From == 0 && that.To == 0 {
+		return 0
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch {
+	case now.Before(midnight.Add(that.From)):
+		return midnight.Add(that.From).Sub(now)
+	case now.Before(midnight.Add(that.To)):
+		return 0
+	default:
+		return midnight.AddDate(0, 0, 1).Add(that.From).Sub(now)
+	}
+}
+
+type AlertDispatchUseCase struct {
+	logger     *slog.Logger
+	repository AlertDispatchRepository
+	window     DeliveryWindow
+	loc        *time.Location
+	jobs       []AlertDispatchJob
+}
+
+func NewAlertDispatchUseCase(logger *slog.Logger, repository AlertDispatchRepository, window DeliveryWindow, loc *time.Location, jobs ...AlertDispatchJob) *AlertDispatchUseCase {
+	return &AlertDispatchUseCase{logger: logger.With("component", "alert_dispatch"), repository: repository, window: window, loc: loc, jobs: jobs}
+}
+
+// Dispatch runs the alert jobs once the NBKR publishes the prices of a new date. The pending date is found in the
+// database, so it survives the restarts, and it's claimed only after every job succeeded, the failed jobs are run
+// again by the next call. The alerts are built from the NBKR prices only.
+func (that *AlertDispatchUseCase) Dispatch(ctx context.Context) {
+	log := that.logger.With("method", "Dispatch", "source", model.PriceSourceNBKR)
+
+	if that.window.Delay(time.Now().In(that.loc)) > 0 {
+		return
+	}
+
+	date, err := that.repository.GetPendingDispatchDate(ctx, model.PriceSourceNBKR)
+	if err != nil {
+		log.Error("failed to get pending dispatch date", "error", err)
+		return
+	}
+
+	if date == nil {
+		return
+	}
+
+	log = log.With("date", *date)
+	log.Info("dispatching alerts")
+
+	var failed int
+	for _, job := range that.jobs {
+		if err = job.Run(ctx); err != nil {
+			log.Error("failed to run alert job", "error", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return
+	}
+
+	claimed, err := that.repository.ClaimDispatch(ctx, model.PriceSourceNBKR, *date)
+	if err != nil {
+		log.Error("failed to claim alerts dispatch", "error", err)
+		return
+	}
+
+	if !claimed {
+		log.Info("alerts are already dispatched for the date")
+	}
+}
@@ -0,0 +1,112 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+	"goldie/internal/repository/deliveries"
+	"goldie/internal/repository/prices"
+	"goldie/internal/usecases"
+	"goldie/testing/suite"
+)
+
+type countingJob struct {
+	runs     int
+	failures int
+}
+
+func (that *countingJob) Run(context.Context) error {
+	that.runs++
+
+	if that.failures > 0 {
+		that.failures--
+		return errors.New("telegram is down")
+	}
+
+	return nil
+}
+
+func Test_DeliveryWindow_Delay(t *testing.T) {
+	window := usecases.DeliveryWindow{From: 9 * time.Hour, To: 21 * time.Hour}
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should wait for the window to open in the morning", func(t *testing.T) {
+		require.Equal(t, 90*time.Minute, window.Delay(day.Add(7*time.Hour+30*time.Minute)))
+	})
+
+	t.Run("shouldn't wait inside the window", func(t *testing.T) {
+		require.Zero(t, window.Delay(day.Add(12*time.Hour)))
+	})
+
+	t.Run("should wait for the next day after the window is closed", func(t *testing.T) {
+		require.Equal(t, 11*time.Hour, window.Delay(day.Add(22*time.Hour)))
+	})
+
+	t.Run("shouldn't wait for the zero window", func(t *testing.T) {
+		require.Zero(t, usecases.DeliveryWindow{}.Delay(day.Add(3*time.Hour)))
+	})
+}
+
+func Test_AlertDispatchUseCase_Dispatch(t *testing.T) {
+	t.Run("should run the alerts once per new price date", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		job := &countingJob{}
+		alertDispatchUC := usecases.NewAlertDispatchUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), usecases.DeliveryWindow{}, st.Loc, job)
+
+		date := time.Now().UTC().Truncate(24 * time.Hour)
+		savePrice := func(date time.Time) {
+			_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+				{Date: date, Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 9900, SellPrice: 10000},
+			})
+			require.NoError(t, err)
+		}
+
+		// Given: No prices yet
+		alertDispatchUC.Dispatch(ctx)
+		require.Equal(t, 0, job.runs)
+
+		// When: The prices of the date are saved and the dispatch is run twice, then an older date is filled
+		savePrice(date)
+		alertDispatchUC.Dispatch(ctx)
+		alertDispatchUC.Dispatch(ctx)
+		savePrice(date.AddDate(0, 0, -3))
+		alertDispatchUC.Dispatch(ctx)
+
+		// Then: The alerts should be sent once
+		require.Equal(t, 1, job.runs)
+
+		// When: The next date is saved
+		savePrice(date.AddDate(0, 0, 1))
+		alertDispatchUC.Dispatch(ctx)
+
+		// Then: The alerts should be sent again
+		require.Equal(t, 2, job.runs)
+	})
+
+	t.Run("should run the failed alerts again by the next dispatch", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		job := &countingJob{failures: 1}
+		alertDispatchUC := usecases.NewAlertDispatchUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), usecases.DeliveryWindow{}, st.Loc, job)
+
+		// Given: The prices of the new date
+		_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+			{Date: time.Now().UTC().Truncate(24 * time.Hour), Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 9900, SellPrice: 10000},
+		})
+		require.NoError(t, err)
+
+		// When: The first run fails and the dispatch is repeated
+		alertDispatchUC.Dispatch(ctx)
+		alertDispatchUC.Dispatch(ctx)
+		alertDispatchUC.Dispatch(ctx)
+
+		// Then: The date should be claimed only after the successful run
+		require.Equal(t, 2, job.runs)
+	})
+}
@@ -0,0 +1,373 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+	"goldie/internal/repository/chats"
+	"goldie/internal/repository/deliveries"
+	"goldie/internal/repository/prices"
+	"goldie/internal/usecases"
+	"goldie/testing/suite"
+)
+
+type stubAlertTG struct {
+	mu       sync.Mutex
+	messages []sentMessage
+	failures int // the number of the first sends to fail
+}
+
+func (that *stubAlertTG) SendMessage(_ context.Context, chatID int64, text string) error {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	if that.failures > 0 {
+		that.failures--
+		return errors.New("telegram is down")
+	}
+
+	that.messages = append(that.messages, sentMessage{chatID: chatID, text: text})
+	return nil
+}
+
+func (that *stubAlertTG) PricesToString(string, []*model.GoldPrice, *model.CurrencyRate) string {
+	return "prices"
+}
+
+func (that *stubAlertTG) PricesWithGainToString(string, string, []*model.GoldPrice, []*model.GoldPrice, *model.CurrencyRate, *model.CurrencyRate) string {
+	return "gain"
+}
+
+func (that *stubAlertTG) MoveAlertsToString(_ string, moves []*model.PriceMove) string {
+	lines := make([]string, 0, len(moves))
+	for _, move := range moves {
+		lines = append(lines, fmt.Sprintf("%g %.2f %.2f %.2f", move.Alert.Weight, move.MovedPercent, move.Price.SellPrice, move.BasePrice))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (that *stubAlertTG) PortfolioToString(_ string, portfolio *model.Portfolio) string {
+	return fmt.Sprintf("%.2f %.2f", portfolio.Value, portfolio.Cost)
+}
+
+func (that *stubAlertTG) Alert2CrossingToString(_ string, crossing string, _ *model.TgChatAlert2, gain float64) string {
+	return fmt.Sprintf("%s %.2f", crossing, gain)
+}
+
+func Test_AlertUseCase_Run(t *testing.T) {
+	t.Run("should send the move alerts only for the weights which moved more than the percent", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 1})
+		alertUC := usecases.NewAlertUseCase(st.Logger, nil, st.Loc, pricesRepository, chatsRepository, tgIntegration, alertDeliveryUC)
+
+		latestDate := time.Now().UTC().Truncate(24 * time.Hour)
+
+		// Given: The 1 g bar moved 1% and the 10 g bar moved 5% since the previous date,
+		// the 10 g bar is at the 2-day average
+		var stored []*model.GoldPrice
+		for i, sellPrices := range [][2]float64{{10000, 100000}, {10000, 110000}, {10100, 105000}} {
+			date := latestDate.AddDate(0, 0, i-2)
+			stored = append(stored,
+				&model.GoldPrice{Date: date, Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: sellPrices[0] - 100, SellPrice: sellPrices[0]},
+				&model.GoldPrice{Date: date, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: sellPrices[1] - 1000, SellPrice: sellPrices[1]},
+			)
+		}
+		_, err := pricesRepository.SavePrices(ctx, stored)
+		require.NoError(t, err)
+
+		// Given: The chats watching the moves
+		const previousChatID, averageChatID = int64(100), int64(200)
+		for _, alert := range []*model.TgChatMoveAlert{
+			{Weight: 1, Percent: 2, Baseline: model.MoveAlertBaselinePrevious},
+			{Weight: 10, Percent: 2, Baseline: model.MoveAlertBaselinePrevious},
+		} {
+			require.NoError(t, chatsRepository.CreateMoveAlert(ctx, previousChatID, alert))
+		}
+		require.NoError(t, chatsRepository.CreateMoveAlert(ctx, averageChatID, &model.TgChatMoveAlert{
+			Weight: 10, Percent: 2, Baseline: model.MoveAlertBaselineAverage, Days: 2,
+		}))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, previousChatID, "00:00"))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, averageChatID, "00:00"))
+
+		// When: We run the alerts
+		alertUC.Run(ctx)
+
+		// Then: Only the 10 g bar move vs the previous date should be sent
+		require.Equal(t, []sentMessage{{chatID: previousChatID, text: "10 -4.55 105000.00 110000.00"}}, tgIntegration.messages)
+	})
+
+	t.Run("should average the last published dates over the days without prices", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 1})
+		alertUC := usecases.NewAlertUseCase(st.Logger, nil, st.Loc, pricesRepository, chatsRepository, tgIntegration, alertDeliveryUC)
+
+		latestDate := time.Now().UTC().Truncate(24 * time.Hour)
+
+		// Given: The 10 g bar prices before the long holidays, the oldest one is out of the average
+		_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+			{Date: latestDate.AddDate(0, 0, -11), Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 50000, SellPrice: 51000},
+			{Date: latestDate.AddDate(0, 0, -10), Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 99000, SellPrice: 100000},
+			{Date: latestDate.AddDate(0, 0, -9), Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 101000, SellPrice: 102000},
+			{Date: latestDate, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 104000, SellPrice: 105000},
+		})
+		require.NoError(t, err)
+
+		// Given: The chat watching the move from the 2-day average
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.CreateMoveAlert(ctx, chatID, &model.TgChatMoveAlert{
+			Weight: 10, Percent: 2, Baseline: model.MoveAlertBaselineAverage, Days: 2,
+		}))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, chatID, "00:00"))
+
+		// When: We run the alerts
+		alertUC.Run(ctx)
+
+		// Then: The move should be compared with the average of the two published dates before the holidays
+		require.Equal(t, []sentMessage{{chatID: chatID, text: "10 3.96 105000.00 101000.00"}}, tgIntegration.messages)
+	})
+
+	t.Run("should send the alert1 once per date and retry the failed send", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{failures: 1}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 3})
+		alertUC := usecases.NewAlertUseCase(st.Logger, nil, st.Loc, pricesRepository, chatsRepository, tgIntegration, alertDeliveryUC)
+
+		// Given: The prices and the chat with alert1
+		_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+			{Date: time.Now().UTC().Truncate(24 * time.Hour), Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 9900, SellPrice: 10000},
+		})
+		require.NoError(t, err)
+
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.EnableAlert1(ctx, chatID))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, chatID, "00:00"))
+
+		// When: The first send fails and the alerts are run again for the same date
+		alertUC.Run(ctx)
+		alertUC.Run(ctx)
+
+		// Then: Nothing should be sent
+		require.Empty(t, tgIntegration.messages)
+
+		// When: The failed deliveries are retried and the alerts are run again
+		alertDeliveryUC.RetryFailed(ctx)
+		alertUC.Run(ctx)
+
+		// Then: The alert should be sent once
+		require.Equal(t, []sentMessage{{chatID: chatID, text: "prices"}}, tgIntegration.messages)
+	})
+
+	t.Run("should retry only the failed deliveries", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		deliveriesRepository := deliveries.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveriesRepository, tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 3})
+
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.EnableAlert1(ctx, chatID))
+		chat, err := chatsRepository.GetChat(ctx, chatID)
+		require.NoError(t, err)
+
+		// Given: The delivery interrupted in the middle of the sending and the failed one which is due
+		date := time.Now().UTC().Truncate(24 * time.Hour)
+		dueAt := time.Now().Add(-time.Minute)
+		for _, delivery := range []*model.AlertDelivery{
+			{ChatID: chat.ID, Kind: model.AlertKindAlert1, Date: date, Status: model.AlertDeliveryPending, Text: "interrupted"},
+			{ChatID: chat.ID, Kind: model.AlertKindMove, Date: date, Status: model.AlertDeliveryFailed, Text: "failed", Attempts: 1, NextAttemptAt: &dueAt},
+		} {
+			created, err := deliveriesRepository.CreateDelivery(ctx, delivery)
+			require.NoError(t, err)
+			require.True(t, created)
+		}
+		require.NoError(t, st.GetDB().WithContext(ctx).Model(&model.AlertDelivery{}).Where("kind = ?", model.AlertKindAlert1).
+			Update("updated_at", time.Now().Add(-time.Hour)).Error)
+
+		// When: The failed deliveries are retried twice
+		alertDeliveryUC.RetryFailed(ctx)
+		alertDeliveryUC.RetryFailed(ctx)
+
+		// Then: Only the failed delivery should be sent and only once, the interrupted one may be already sent
+		require.Equal(t, []sentMessage{{chatID: chatID, text: "failed"}}, tgIntegration.messages)
+	})
+
+	t.Run("shouldn't retry the deliveries of the paused chats", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		deliveriesRepository := deliveries.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveriesRepository, tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 3})
+
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.EnableAlert1(ctx, chatID))
+		chat, err := chatsRepository.GetChat(ctx, chatID)
+		require.NoError(t, err)
+
+		// Given: The failed delivery which is due and the chat paused after it
+		dueAt := time.Now().Add(-time.Minute)
+		created, err := deliveriesRepository.CreateDelivery(ctx, &model.AlertDelivery{
+			ChatID: chat.ID, Kind: model.AlertKindAlert1, Date: time.Now().UTC().Truncate(24 * time.Hour), Status: model.AlertDeliveryFailed, Text: "failed", Attempts: 1, NextAttemptAt: &dueAt,
+		})
+		require.NoError(t, err)
+		require.True(t, created)
+		require.NoError(t, chatsRepository.PauseChat(ctx, chatID, time.Now().AddDate(0, 0, 7)))
+
+		// When: The failed deliveries are retried
+		alertDeliveryUC.RetryFailed(ctx)
+
+		// Then: Nothing should be sent while the chat is paused
+		require.Empty(t, tgIntegration.messages)
+
+		// When: The pause is over and the failed deliveries are retried
+		require.NoError(t, chatsRepository.PauseChat(ctx, chatID, time.Now()))
+		alertDeliveryUC.RetryFailed(ctx)
+
+		// Then: The delivery should be sent
+		require.Equal(t, []sentMessage{{chatID: chatID, text: "failed"}}, tgIntegration.messages)
+	})
+
+	t.Run("should send the portfolio valued at the buyback prices", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 1})
+		alertUC := usecases.NewAlertUseCase(st.Logger, nil, st.Loc, pricesRepository, chatsRepository, tgIntegration, alertDeliveryUC)
+
+		// Given: The prices on the purchase date and today
+		latestDate := time.Now().UTC().Truncate(24 * time.Hour)
+		purchaseDate := latestDate.AddDate(0, 0, -30)
+		_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+			{Date: purchaseDate, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 95000, SellPrice: 100000},
+			{Date: latestDate, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 105000, SellPrice: 110000},
+		})
+		require.NoError(t, err)
+
+		// Given: Three bars bought at the NBKR price and one bar bought at a custom price
+		const chatID = int64(100)
+		pricePaid := 98000.0
+		require.NoError(t, chatsRepository.CreateHolding(ctx, chatID, &model.TgChatHolding{Weight: 10, Quantity: 3, PurchaseDate: purchaseDate}))
+		require.NoError(t, chatsRepository.CreateHolding(ctx, chatID, &model.TgChatHolding{Weight: 10, Quantity: 1, PurchaseDate: purchaseDate, PricePaid: &pricePaid}))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, chatID, "00:00"))
+
+		// When: We run the alerts
+		alertUC.Run(ctx)
+
+		// Then: The four bars should be valued at the buyback price
+		require.Equal(t, []sentMessage{{chatID: chatID, text: "420000.00 398000.00"}}, tgIntegration.messages)
+	})
+}
+
+func Test_AlertUseCase_Run_Alert2Crossing(t *testing.T) {
+	t.Run("should notify once about every level the position crosses instead of the daily table", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 1})
+		alertUC := usecases.NewAlertUseCase(st.Logger, nil, st.Loc, pricesRepository, chatsRepository, tgIntegration, alertDeliveryUC)
+
+		// Given: The bar bought at 100000 is bought back at 95000 the next day
+		purchaseDate := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -3)
+		_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+			{Date: purchaseDate, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 95000, SellPrice: 100000},
+			{Date: purchaseDate.AddDate(0, 0, 1), Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 95000, SellPrice: 100000},
+		})
+		require.NoError(t, err)
+
+		// Given: The alert2 subscription notifying on crossing with the target gain of 10%
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.CreateAlert2Subscription(ctx, chatID, purchaseDate))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, chatID, "00:00"))
+
+		alerts, err := chatsRepository.ListAlert2Subscriptions(ctx, chatID)
+		require.NoError(t, err)
+		require.Len(t, alerts, 1)
+
+		target := 10.0
+		require.NoError(t, chatsRepository.SetAlert2Mode(ctx, chatID, alerts[0].ID, model.Alert2ModeCrossing))
+		require.NoError(t, chatsRepository.SetAlert2Thresholds(ctx, chatID, alerts[0].ID, &target, nil))
+
+		// When: We run the alerts below the break-even
+		alertUC.Run(ctx)
+
+		// Then: Nothing should be sent
+		require.Empty(t, tgIntegration.messages)
+
+		// When: The position goes above the break-even and then above the target on the next dates
+		for i, purchasePrice := range []float64{101000, 112000, 113000} {
+			_, err = pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+				{Date: purchaseDate.AddDate(0, 0, i+2), Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: purchasePrice, SellPrice: purchasePrice + 5000},
+			})
+			require.NoError(t, err)
+			require.NoError(t, st.GetDB().Model(&model.TgChat{}).Where("source_id = ?", chatID).Update("alerts_sent_at", nil).Error)
+
+			alertUC.Run(ctx)
+		}
+
+		// Then: The break-even and the target should be notified once
+		require.Equal(t, []sentMessage{
+			{chatID: chatID, text: "break_even 1.00"},
+			{chatID: chatID, text: "target 12.00"},
+		}, tgIntegration.messages)
+	})
+	t.Run("should remember the crossing of the failed notification and retry it", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{failures: 1}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 2})
+		alertUC := usecases.NewAlertUseCase(st.Logger, nil, st.Loc, pricesRepository, chatsRepository, tgIntegration, alertDeliveryUC)
+
+		// Given: The bar bought at 100000 is bought back at 101000 the next day
+		purchaseDate := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -3)
+		_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+			{Date: purchaseDate, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 95000, SellPrice: 100000},
+			{Date: purchaseDate.AddDate(0, 0, 1), Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 101000, SellPrice: 106000},
+		})
+		require.NoError(t, err)
+
+		// Given: The alert2 subscription notifying on crossing
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.CreateAlert2Subscription(ctx, chatID, purchaseDate))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, chatID, "00:00"))
+
+		alerts, err := chatsRepository.ListAlert2Subscriptions(ctx, chatID)
+		require.NoError(t, err)
+		require.NoError(t, chatsRepository.SetAlert2Mode(ctx, chatID, alerts[0].ID, model.Alert2ModeCrossing))
+
+		// When: The notification about the break-even can't be sent
+		alertUC.Run(ctx)
+		require.Empty(t, tgIntegration.messages)
+
+		// Then: The crossing should be remembered
+		alerts, err = chatsRepository.ListAlert2Subscriptions(ctx, chatID)
+		require.NoError(t, err)
+		require.NotNil(t, alerts[0].BreakEvenCrossedAt)
+
+		// When: The alerts are run again and the failed deliveries are retried
+		require.NoError(t, st.GetDB().Model(&model.TgChat{}).Where("source_id = ?", chatID).Update("alerts_sent_at", nil).Error)
+		alertUC.Run(ctx)
+		alertDeliveryUC.RetryFailed(ctx)
+
+		// Then: The break-even should be notified once by the retry
+		require.Equal(t, []sentMessage{{chatID: chatID, text: "break_even 1.00"}}, tgIntegration.messages)
+	})
+}
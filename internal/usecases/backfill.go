@@ -0,0 +1,289 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"goldie/internal/model"
+	"goldie/internal/providers"
+)
+
+const FirstPriceDate = "2015-07-05"
+
+type BackfillPricesRepository interface {
+	GetWeightsByDate(ctx context.Context, source string) ([]*model.PriceDateWeights, error)
+}
+
+type BackfillChunksRepository interface {
+	EnsureChunks(ctx context.Context, chunks []*model.BackfillChunk) error
+	ListUnfinishedChunks(ctx context.Context, source string) ([]*model.BackfillChunk, error)
+	SaveChunk(ctx context.Context, chunk *model.BackfillChunk) error
+}
+
+type BackfillProviders interface {
+	All() []providers.Provider
+}
+
+// BackfillOptions configures how the history is split and fetched.
+type BackfillOptions struct {
+	Workers      int           // chunks fetched at the same time
+	ChunkMonths  int           // length of a chunk
+	MaxAttempts  int           // attempts per chunk during one run, the failed chunks are retried by the next run
+	RetryBackoff time.Duration // delay before the second attempt, doubled for every next one
+}
+
+type BackfillUseCase struct {
+	logger           *slog.Logger
+	pricesRepository BackfillPricesRepository
+	saver            PricesSaver
+	chunksRepository BackfillChunksRepository
+	providers        BackfillProviders
+	notifier         UpdatePricesNotifier
+	options          BackfillOptions
+	loc              *time.Location
+}
+
+func NewBackfillUseCase(logger *slog.Logger, pricesRepository BackfillPricesRepository, saver PricesSaver, chunksRepository BackfillChunksRepository, providers BackfillProviders, notifier UpdatePricesNotifier, options BackfillOptions, loc *time.Location) *BackfillUseCase {
+	options.Workers = max(options.Workers, 1)
+	options.ChunkMonths = max(options.ChunkMonths, 1)
+	options.MaxAttempts = max(options.MaxAttempts, 1)
+
+	return &BackfillUseCase{logger: logger.With("component", "backfill"), pricesRepository: pricesRepository, saver: saver, chunksRepository: chunksRepository, providers: providers, notifier: notifier, options: options, loc: loc}
+}
+
+// Run imports the whole history of every enabled provider. The progress is stored per chunk,
+// so the finished chunks are skipped and the interrupted or failed ones are fetched again.
+func (that *BackfillUseCase) Run(ctx context.Context) {
+	for _, provider := range that.providers.All() {
+		that.backfill(ctx, provider)
+	}
+}
+
+func (that *BackfillUseCase) backfill(ctx context.Context, provider providers.Provider) {
+	log := that.logger.With("method", "Run", "source", provider.Source())
+
+	// The complete chunks of the prices stored before the backfill are created finished, so they aren't fetched again
+	dates, err := that.pricesRepository.GetWeightsByDate(ctx, provider.Source())
+	if err != nil {
+		log.Error("failed to get stored price dates", "error", err)
+		return
+	}
+
+	now := time.Now().In(that.loc)
+	planned := that.planChunks(provider.Source(), now)
+	seedStoredChunks(planned, dates, now)
+
+	if err = that.chunksRepository.EnsureChunks(ctx, planned); err != nil {
+		log.Error("failed to plan backfill chunks", "error", err)
+		return
+	}
+
+	chunks, err := that.chunksRepository.ListUnfinishedChunks(ctx, provider.Source())
+	if err != nil {
+		log.Error("failed to get unfinished backfill chunks", "error", err)
+		return
+	}
+
+	if len(chunks) == 0 {
+		log.Debug("backfill is already finished")
+		return
+	}
+
+	total := len(planned)
+	log.Info("starting backfill", "chunks", len(chunks), "total", total)
+
+	var finished, failed atomic.Int64
+	finished.Store(int64(total - len(chunks)))
+
+	group := errgroup.Group{}
+	group.SetLimit(that.options.Workers)
+
+	for _, chunk := range chunks {
+		group.Go(func() error {
+			if that.importChunk(ctx, log, provider, chunk, now) {
+				finished.Add(1)
+			} else {
+				failed.Add(1)
+			}
+
+			log.Info("backfill progress",
+				"begin_date", chunk.BeginDate.Format(time.DateOnly),
+				"status", chunk.Status,
+				"prices", chunk.PricesCount,
+				"finished", finished.Load(),
+				"total", total,
+			)
+
+			return nil
+		})
+	}
+
+	_ = group.Wait()
+
+	if failed.Load() > 0 {
+		log.Error("backfill finished with failed chunks, they will be retried by the next run", "failed", failed.Load(), "finished", finished.Load(), "total", total)
+		return
+	}
+
+	log.Info("backfill finished", "total", total)
+}
+
+// planChunks splits the history from the beginning of the NBKR prices until now.
+func (that *BackfillUseCase) planChunks(source string, now time.Time) []*model.BackfillChunk {
+	var chunks []*model.BackfillChunk
+
+	// The first price from the NBKR is in 2015
+	for beginDate := time.Date(2015, 1, 1, 0, 0, 0, 0, that.loc); beginDate.Before(now); {
+		endDate := beginDate.AddDate(0, that.options.ChunkMonths, 0)
+		chunks = append(chunks, &model.BackfillChunk{
+			Source:    source,
+			BeginDate: beginDate,
+			EndDate:   endDate,
+			Status:    model.BackfillChunkPending,
+		})
+
+		beginDate = endDate
+	}
+
+	return chunks
+}
+
+// seedStoredChunks marks the past chunks whose every weekday has the full weight set stored as done. The chunks with
+// the gaps, e.g. the holidays, are fetched once more, the saving of the same prices is idempotent. Only the new chunks
+// are created with it, the progress of the existing ones is kept.
+func seedStoredChunks(chunks []*model.BackfillChunk, dates []*model.PriceDateWeights, now time.Time) {
+	for _, chunk := range chunks {
+		if chunk.EndDate.After(now) {
+			continue
+		}
+
+		weights := make(map[string]int)
+		var most, count int
+
+		for _, date := range dates {
+			if date.Date.Before(chunk.BeginDate) || !date.Date.Before(chunk.EndDate) {
+				continue
+			}
+
+			weights[dateKey(date.Date)] = date.Weights
+			most = max(most, date.Weights)
+			count += date.Weights
+		}
+
+		if isChunkStored(chunk, weights, most) {
+			chunk.Status = model.BackfillChunkDone
+			chunk.PricesCount = count
+		}
+	}
+}
+
+// isChunkStored reports whether every weekday of the chunk has the largest weight set of the chunk.
+func isChunkStored(chunk *model.BackfillChunk, weights map[string]int, most int) bool {
+	if most == 0 {
+		return false
+	}
+
+	end := time.Date(chunk.EndDate.Year(), chunk.EndDate.Month(), chunk.EndDate.Day(), 0, 0, 0, 0, time.UTC)
+	for date := time.Date(chunk.BeginDate.Year(), chunk.BeginDate.Month(), chunk.BeginDate.Day(), 0, 0, 0, 0, time.UTC); date.Before(end); date = date.AddDate(0, 0, 1) {
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			continue
+		}
+
+		if weights[dateKey(date)] < most {
+			return false
+		}
+	}
+
+	return true
+}
+
+// importChunk fetches and saves the chunk prices, and reports whether the chunk is imported. The chunk which isn't over
+// by now stays pending, so the next run fetches the later prices.
+func (that *BackfillUseCase) importChunk(ctx context.Context, log *slog.Logger, provider providers.Provider, chunk *model.BackfillChunk, now time.Time) bool {
+	log = log.With("begin_date", chunk.BeginDate.Format(time.DateOnly), "end_date", chunk.EndDate.Format(time.DateOnly))
+
+	for attempt := 1; ; attempt++ {
+		err := that.importChunkOnce(ctx, log, provider, chunk, now)
+		if err == nil {
+			return true
+		}
+
+		chunk.Status = model.BackfillChunkFailed
+		chunk.LastError = err.Error()
+		that.saveChunk(ctx, log, chunk)
+
+		if attempt >= that.options.MaxAttempts {
+			log.Error("failed to import backfill chunk", "attempts", attempt, "error", err)
+			return false
+		}
+
+		delay := that.options.RetryBackoff << (attempt - 1)
+		log.Warn("failed to import backfill chunk, retrying", "attempt", attempt, "delay", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (that *BackfillUseCase) importChunkOnce(ctx context.Context, log *slog.Logger, provider providers.Provider, chunk *model.BackfillChunk, now time.Time) error {
+	chunk.Status = model.BackfillChunkRunning
+	chunk.Attempts++
+	that.saveChunk(ctx, log, chunk)
+
+	prices, err := provider.GetPrices(ctx, chunk.BeginDate, chunk.EndDate)
+	if errors.Is(err, providers.ErrNoRows) {
+		// The provider has nothing for the period, e.g. before the first price
+		chunk.Status = finishedChunkStatus(chunk, now, model.BackfillChunkEmpty)
+		chunk.LastError = ""
+		that.saveChunk(ctx, log, chunk)
+
+		return nil
+	}
+
+	if err != nil {
+		if len(prices) == 0 {
+			return fmt.Errorf("get prices: %w", err)
+		}
+
+		// The valid rows are kept, the error is reported to the operators
+		reportFetchError(ctx, log, that.notifier, provider.Source(), err)
+	}
+
+	result, err := that.saver.SavePrices(ctx, prices)
+	if err != nil {
+		return fmt.Errorf("save prices: %w", err)
+	}
+
+	log.Debug("saved backfill prices", "inserted", result.Inserted, "updated", result.Updated, "unchanged", result.Unchanged, "quarantined", result.Quarantined)
+
+	chunk.Status = finishedChunkStatus(chunk, now, model.BackfillChunkDone)
+	chunk.PricesCount = len(prices) - result.Quarantined
+	chunk.LastError = ""
+	that.saveChunk(ctx, log, chunk)
+
+	return nil
+}
+
+// finishedChunkStatus returns the status of the imported chunk, the chunk which isn't over by now can get more prices.
+func finishedChunkStatus(chunk *model.BackfillChunk, now time.Time, status string) string {
+	if chunk.EndDate.After(now) {
+		return model.BackfillChunkPending
+	}
+
+	return status
+}
+
+func (that *BackfillUseCase) saveChunk(ctx context.Context, log *slog.Logger, chunk *model.BackfillChunk) {
+	if err := that.chunksRepository.SaveChunk(ctx, chunk); err != nil {
+		log.Error("failed to save backfill chunk", "error", err)
+	}
+}
@@ -0,0 +1,277 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/dnaeon/go-vcr.v4/pkg/recorder"
+
+	"goldie/internal/interaction/nbkr"
+	"goldie/internal/model"
+	"goldie/internal/providers"
+	"goldie/internal/repository/backfill"
+	"goldie/internal/repository/prices"
+	"goldie/internal/usecases"
+	"goldie/testing/suite"
+)
+
+func Test_BackfillUseCase_Run(t *testing.T) {
+	t.Run("should import prices for the first year", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+
+		r, err := recorder.New(filepath.Join("testdata", strings.ReplaceAll(t.Name(), "/", "_")))
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			// Make sure recorder is stopped once done with it.
+			require.NoError(t, r.Stop())
+		})
+
+		interaction := nbkr.NewInteraction(slog.Default(), r.GetDefaultClient())
+		backfillUC := usecases.NewBackfillUseCase(st.Logger, pricesRepository, usecases.NewPriceSaver(st.Logger, pricesRepository, nil, usecases.PriceValidationRules{}), backfill.NewRepository(st.GetDB()), providers.NewRegistry(interaction), nil, testBackfillOptions, st.Loc)
+
+		// When: We run the backfill
+		backfillUC.Run(ctx)
+
+		// Then: The first year chunk should be finished
+		var firstChunk model.BackfillChunk
+		require.NoError(t, st.GetDB().WithContext(ctx).Where("source = ? AND begin_date = ?", model.PriceSourceNBKR, time.Date(2015, 1, 1, 0, 0, 0, 0, st.Loc)).First(&firstChunk).Error)
+		require.Equal(t, model.BackfillChunkDone, firstChunk.Status)
+		require.Equal(t, 1, firstChunk.Attempts)
+		require.NotZero(t, firstChunk.PricesCount)
+
+		// Then: The prices should be created in the database
+		var createdPrices []*model.GoldPrice
+		require.NoError(t, st.GetDB().WithContext(ctx).Model(&createdPrices).Omit("ID", "CreatedAt", "UpdatedAt").Where("date = ?", suite.GetDateTime(t, usecases.FirstPriceDate)).Find(&createdPrices).Error)
+		require.Len(t, createdPrices, 6)
+
+		sort.SliceStable(createdPrices, func(i, j int) bool {
+			if createdPrices[i].Date.Equal(createdPrices[j].Date) {
+				return createdPrices[i].Weight < createdPrices[j].Weight
+			}
+			return createdPrices[i].Date.After(createdPrices[j].Date)
+		})
+
+		expectedPrices := []*model.GoldPrice{
+			{Date: suite.GetDateTime(t, usecases.FirstPriceDate).In(time.Local), Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 3822, SellPrice: 3841},
+			{Date: suite.GetDateTime(t, usecases.FirstPriceDate).In(time.Local), Weight: 2, Source: model.PriceSourceNBKR, PurchasePrice: 6542.5, SellPrice: 6568.5},
+			{Date: suite.GetDateTime(t, usecases.FirstPriceDate).In(time.Local), Weight: 5, Source: model.PriceSourceNBKR, PurchasePrice: 14969.5, SellPrice: 15014.5},
+			{Date: suite.GetDateTime(t, usecases.FirstPriceDate).In(time.Local), Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 29094, SellPrice: 29152},
+			{Date: suite.GetDateTime(t, usecases.FirstPriceDate).In(time.Local), Weight: 31.1035, Source: model.PriceSourceNBKR, PurchasePrice: 87745, SellPrice: 87833},
+			{Date: suite.GetDateTime(t, usecases.FirstPriceDate).In(time.Local), Weight: 100, Source: model.PriceSourceNBKR, PurchasePrice: 239802, SellPrice: 240041.5},
+		}
+		require.Equal(t, expectedPrices, createdPrices)
+	})
+
+	t.Run("shouldn't fetch the finished chunks again", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		backfillRepository := backfill.NewRepository(st.GetDB())
+
+		r, err := recorder.New(filepath.Join("testdata", strings.ReplaceAll(t.Name(), "/", "_")))
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			// Make sure recorder is stopped once done with it.
+			require.NoError(t, r.Stop())
+		})
+
+		// Given: The finished chunks for the whole history
+		var dbChunks []*model.BackfillChunk
+		for beginDate := time.Date(2015, 1, 1, 0, 0, 0, 0, st.Loc); beginDate.Before(time.Now()); beginDate = beginDate.AddDate(1, 0, 0) {
+			dbChunks = append(dbChunks, &model.BackfillChunk{Source: model.PriceSourceNBKR, BeginDate: beginDate, EndDate: beginDate.AddDate(1, 0, 0), Status: model.BackfillChunkDone, Attempts: 1})
+		}
+		require.NoError(t, backfillRepository.EnsureChunks(ctx, dbChunks))
+
+		interaction := nbkr.NewInteraction(slog.Default(), r.GetDefaultClient())
+		backfillUC := usecases.NewBackfillUseCase(st.Logger, pricesRepository, usecases.NewPriceSaver(st.Logger, pricesRepository, nil, usecases.PriceValidationRules{}), backfillRepository, providers.NewRegistry(interaction), nil, testBackfillOptions, st.Loc)
+
+		// When: We run the backfill
+		backfillUC.Run(ctx)
+
+		// Then: Nothing should be fetched, the cassette has no interactions
+		var unfinishedChunks []*model.BackfillChunk
+		unfinishedChunks, err = backfillRepository.ListUnfinishedChunks(ctx, model.PriceSourceNBKR)
+		require.NoError(t, err)
+		require.Len(t, unfinishedChunks, 0)
+
+		var createdPrices []*model.GoldPrice
+		require.NoError(t, st.GetDB().WithContext(ctx).Model(&createdPrices).Find(&createdPrices).Error)
+		require.Len(t, createdPrices, 0)
+	})
+
+	t.Run("should retry the failed chunk", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		backfillRepository := backfill.NewRepository(st.GetDB())
+
+		// Given: The provider fails once for the chunk with the price
+		date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+		provider := &flakyPriceProvider{stubPriceProvider: stubPriceProvider{prices: []*model.GoldPrice{
+			{Date: date, Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 3900, SellPrice: 3950},
+		}}, failures: 1}
+
+		options := testBackfillOptions
+		options.MaxAttempts = 2
+		options.RetryBackoff = time.Millisecond
+		backfillUC := usecases.NewBackfillUseCase(st.Logger, pricesRepository, usecases.NewPriceSaver(st.Logger, pricesRepository, nil, usecases.PriceValidationRules{}), backfillRepository, providers.NewRegistry(provider), nil, options, st.Loc)
+
+		// When: We run the backfill
+		backfillUC.Run(ctx)
+
+		// Then: The chunk should be finished by the second attempt
+		var chunk model.BackfillChunk
+		require.NoError(t, st.GetDB().WithContext(ctx).Where("source = ? AND begin_date = ?", model.PriceSourceNBKR, time.Date(2016, 1, 1, 0, 0, 0, 0, st.Loc)).First(&chunk).Error)
+		require.Equal(t, model.BackfillChunkDone, chunk.Status)
+		require.Equal(t, 2, chunk.Attempts)
+		require.Equal(t, 1, chunk.PricesCount)
+		require.Empty(t, chunk.LastError)
+	})
+
+	t.Run("should fetch the failed chunk again by the next run", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		backfillRepository := backfill.NewRepository(st.GetDB())
+
+		// Given: The provider fails once for the chunk with the price and the chunk is tried once per run
+		date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+		provider := &flakyPriceProvider{stubPriceProvider: stubPriceProvider{prices: []*model.GoldPrice{
+			{Date: date, Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 3900, SellPrice: 3950},
+		}}, failures: 1}
+		backfillUC := usecases.NewBackfillUseCase(st.Logger, pricesRepository, usecases.NewPriceSaver(st.Logger, pricesRepository, nil, usecases.PriceValidationRules{}), backfillRepository, providers.NewRegistry(provider), nil, testBackfillOptions, st.Loc)
+
+		// When: We run the backfill
+		backfillUC.Run(ctx)
+
+		// Then: The chunk should be failed, the chunk of the current year stays pending
+		currentBegin := time.Date(time.Now().In(st.Loc).Year(), 1, 1, 0, 0, 0, 0, st.Loc)
+
+		unfinishedChunks, err := backfillRepository.ListUnfinishedChunks(ctx, model.PriceSourceNBKR)
+		require.NoError(t, err)
+		require.Len(t, unfinishedChunks, 2)
+		require.Equal(t, model.BackfillChunkFailed, unfinishedChunks[0].Status)
+		require.Equal(t, "get prices: temporary failure", unfinishedChunks[0].LastError)
+		require.True(t, currentBegin.Equal(unfinishedChunks[1].BeginDate))
+		require.Equal(t, model.BackfillChunkPending, unfinishedChunks[1].Status)
+
+		// When: We run the backfill again
+		provider.requested = nil
+		backfillUC.Run(ctx)
+
+		// Then: Only the failed chunk and the current one should be fetched, the failed one is finished
+		unfinishedChunks, err = backfillRepository.ListUnfinishedChunks(ctx, model.PriceSourceNBKR)
+		require.NoError(t, err)
+		require.Len(t, unfinishedChunks, 1)
+		require.True(t, currentBegin.Equal(unfinishedChunks[0].BeginDate))
+		require.Len(t, provider.requested, 2)
+		require.ElementsMatch(t, []int64{time.Date(2016, 1, 1, 0, 0, 0, 0, st.Loc).Unix(), currentBegin.Unix()}, []int64{provider.requested[0].Unix(), provider.requested[1].Unix()})
+	})
+
+	t.Run("shouldn't fetch the complete chunks of the stored prices", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		backfillRepository := backfill.NewRepository(st.GetDB())
+
+		// Given: Every weekday of 2016 and one date of 2017 stored before the first backfill
+		var storedPrices []*model.GoldPrice
+		for date := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC); date.Year() == 2016; date = date.AddDate(0, 0, 1) {
+			if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+				continue
+			}
+
+			storedPrices = append(storedPrices,
+				&model.GoldPrice{Date: date, Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 3900, SellPrice: 3950},
+				&model.GoldPrice{Date: date, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 39000, SellPrice: 39500},
+			)
+		}
+
+		storedPrices = append(storedPrices, &model.GoldPrice{Date: time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 3900, SellPrice: 3950})
+
+		_, err := pricesRepository.SavePrices(ctx, storedPrices)
+		require.NoError(t, err)
+
+		provider := &flakyPriceProvider{}
+		backfillUC := usecases.NewBackfillUseCase(st.Logger, pricesRepository, usecases.NewPriceSaver(st.Logger, pricesRepository, nil, usecases.PriceValidationRules{}), backfillRepository, providers.NewRegistry(provider), nil, testBackfillOptions, st.Loc)
+
+		// When: We run the backfill
+		backfillUC.Run(ctx)
+
+		// Then: The complete chunk should be finished without fetching it
+		var chunk model.BackfillChunk
+		require.NoError(t, st.GetDB().WithContext(ctx).Where("source = ? AND begin_date = ?", model.PriceSourceNBKR, time.Date(2016, 1, 1, 0, 0, 0, 0, st.Loc)).First(&chunk).Error)
+		require.Equal(t, model.BackfillChunkDone, chunk.Status)
+		require.Equal(t, 0, chunk.Attempts)
+		require.Equal(t, len(storedPrices)-1, chunk.PricesCount)
+
+		// Then: The chunk with the single stored date should be fetched
+		var requested []int64
+		for _, begin := range provider.requested {
+			requested = append(requested, begin.Unix())
+		}
+
+		require.NotContains(t, requested, chunk.BeginDate.Unix())
+		require.Contains(t, requested, time.Date(2017, 1, 1, 0, 0, 0, 0, st.Loc).Unix())
+	})
+
+	t.Run("should keep the chunk of the current period pending", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		backfillRepository := backfill.NewRepository(st.GetDB())
+
+		// Given: The provider has a price of today only
+		now := time.Now().In(st.Loc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		provider := &flakyPriceProvider{stubPriceProvider: stubPriceProvider{prices: []*model.GoldPrice{
+			{Date: today, Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 3900, SellPrice: 3950},
+		}}}
+		backfillUC := usecases.NewBackfillUseCase(st.Logger, pricesRepository, usecases.NewPriceSaver(st.Logger, pricesRepository, nil, usecases.PriceValidationRules{}), backfillRepository, providers.NewRegistry(provider), nil, testBackfillOptions, st.Loc)
+
+		// When: We run the backfill
+		backfillUC.Run(ctx)
+
+		// Then: The past chunks should be finished, the current one should be fetched again by the next run
+		unfinishedChunks, err := backfillRepository.ListUnfinishedChunks(ctx, model.PriceSourceNBKR)
+		require.NoError(t, err)
+		require.Len(t, unfinishedChunks, 1)
+		require.True(t, time.Date(now.Year(), 1, 1, 0, 0, 0, 0, st.Loc).Equal(unfinishedChunks[0].BeginDate))
+		require.Equal(t, model.BackfillChunkPending, unfinishedChunks[0].Status)
+		require.Equal(t, 1, unfinishedChunks[0].Attempts)
+		require.Equal(t, 1, unfinishedChunks[0].PricesCount)
+	})
+}
+
+// flakyPriceProvider fails the requests of the ranges with prices until the failures run out.
+type flakyPriceProvider struct {
+	stubPriceProvider
+	failures int
+
+	mu        sync.Mutex
+	requested []time.Time // the begin dates of the requested ranges
+}
+
+func (that *flakyPriceProvider) GetPrices(ctx context.Context, begin, end time.Time) ([]*model.GoldPrice, error) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	that.requested = append(that.requested, begin)
+
+	prices, err := that.stubPriceProvider.GetPrices(ctx, begin, end)
+	if len(prices) > 0 && that.failures > 0 {
+		that.failures--
+		return nil, errors.New("temporary failure")
+	}
+
+	return prices, err
+}
+
+// testBackfillOptions splits the history by years like the recorded cassettes and doesn't retry the missing ones.
+var testBackfillOptions = usecases.BackfillOptions{Workers: 2, ChunkMonths: 12, MaxAttempts: 1}
@@ -0,0 +1,164 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"goldie/internal/model"
+)
+
+// latestTimezoneOffset is the offset of the earliest timezone, the chats there start the new period first.
+const latestTimezoneOffset = 14 * time.Hour
+
+type DigestPricesRepository interface {
+	GetPeriodStats(ctx context.Context, source string, begin, end time.Time) ([]*model.PeriodPriceStats, error)
+	GetDailyChanges(ctx context.Context, source string, begin, end time.Time) ([]*model.DailyChange, error)
+}
+
+type DigestChatsRepository interface {
+	FetchDigestChats(ctx context.Context, kind string, sentSince time.Time) ([]*model.TgChat, error)
+	LoadChatAlerts(ctx context.Context, chats []*model.TgChat) error
+}
+
+type DigestTGIntegration interface {
+	DigestToString(languageCode string, digest *model.PriceDigest, positions []*model.PositionMove) string
+	SendDigestChart(ctx context.Context, chat *model.TgChat) error
+}
+
+type DigestUseCase struct {
+	logger           *slog.Logger
+	pricesRepository DigestPricesRepository
+	chatsRepository  DigestChatsRepository
+	tgIntegration    DigestTGIntegration
+	deliverer        AlertDeliverer
+}
+
+func NewDigestUseCase(logger *slog.Logger, pricesRepository DigestPricesRepository, chatsRepository DigestChatsRepository, tgIntegration DigestTGIntegration, deliverer AlertDeliverer) *DigestUseCase {
+	return &DigestUseCase{logger: logger.With("component", "digest"), pricesRepository: pricesRepository, chatsRepository: chatsRepository, tgIntegration: tgIntegration, deliverer: deliverer}
+}
+
+// Run sends the digest of the completed period to the subscribed chats. Every chat gets it on the first day
+// of the next period at its delivery time, the weekly one is followed by the price chart if the chat opted in.
+// The chart is sent only right after the digest, it isn't sent with the retried one.
+func (that *DigestUseCase) Run(ctx context.Context, kind string, now time.Time) {
+	log := that.logger.With("method", "Run", "kind", kind)
+
+	// Every chat which is due now completes the same period, even the one in the earliest timezone
+	begin, end := model.DigestPeriod(kind, now.UTC().Add(latestTimezoneOffset))
+
+	candidates, err := that.chatsRepository.FetchDigestChats(ctx, kind, begin)
+	if err != nil {
+		log.Error("failed to get chats", "error", err)
+		return
+	}
+
+	chats := make([]*model.TgChat, 0, len(candidates))
+	for _, chat := range candidates {
+		if chat.IsDigestDue(kind, now) {
+			chats = append(chats, chat)
+		}
+	}
+
+	if len(chats) == 0 {
+		return
+	}
+
+	digest, err := that.loadDigest(ctx, kind, begin, end)
+	if err != nil {
+		log.Error("failed to get digest", "error", err)
+		return
+	}
+
+	if len(digest.Stats) == 0 {
+		log.Info("no prices found for the period", "begin", begin, "end", end)
+		return
+	}
+
+	if err = that.chatsRepository.LoadChatAlerts(ctx, chats); err != nil {
+		log.Error("failed to get chat alerts", "error", err)
+		return
+	}
+
+	for _, chat := range chats {
+		text := that.tgIntegration.DigestToString(chat.GetLanguageCode(), digest, findPositionMoves(chat.Alerts2, digest.Stats, chat.GetGainView()))
+		if !that.deliverer.Deliver(ctx, chat, model.DigestDeliveryKind(kind), begin, text) {
+			continue
+		}
+
+		if kind == model.DigestWeekly && chat.DigestChart {
+			if err = that.tgIntegration.SendDigestChart(ctx, chat); err != nil {
+				log.Error("failed to send digest chart", "error", err, "chat_id", chat.SourceID)
+			}
+		}
+	}
+
+	log.Info("digests sent", "chats", len(chats), "begin", begin)
+}
+
+func (that *DigestUseCase) loadDigest(ctx context.Context, kind string, begin, end time.Time) (*model.PriceDigest, error) {
+	stats, err := that.pricesRepository.GetPeriodStats(ctx, model.PriceSourceNBKR, begin, end)
+	if err != nil {
+		return nil, fmt.Errorf("get period stats: %w", err)
+	}
+
+	changes, err := that.pricesRepository.GetDailyChanges(ctx, model.PriceSourceNBKR, begin, end)
+	if err != nil {
+		return nil, fmt.Errorf("get daily changes: %w", err)
+	}
+
+	digest := &model.PriceDigest{Kind: kind, Begin: begin, End: end, Stats: stats}
+	for _, change := range changes {
+		if digest.BestDay == nil || change.Percent > digest.BestDay.Percent {
+			digest.BestDay = change
+		}
+
+		if digest.WorstDay == nil || change.Percent < digest.WorstDay.Percent {
+			digest.WorstDay = change
+		}
+	}
+
+	return digest, nil
+}
+
+// findPositionMoves returns how the gain of the alert2 subscriptions moved from the open to the close of the period.
+// The position holds one bar of every weight bought on the purchase date, the gain is calculated for the view.
+func findPositionMoves(alerts []*model.TgChatAlert2, stats []*model.PeriodPriceStats, view string) []*model.PositionMove {
+	statsLookup := make(map[float64]*model.PeriodPriceStats, len(stats))
+	for _, s := range stats {
+		statsLookup[s.Weight] = s
+	}
+
+	var moves []*model.PositionMove
+	for _, alert := range alerts {
+		var cost, open, closing float64
+		for _, bp := range alert.BuyingPrices {
+			s, ok := statsLookup[bp.Weight]
+			if !ok {
+				continue
+			}
+
+			cost += bp.SellPrice
+			if view == model.GainViewMarket {
+				open += s.Open
+				closing += s.Close
+			} else {
+				open += s.PurchaseOpen
+				closing += s.PurchaseClose
+			}
+		}
+
+		if cost == 0 {
+			continue
+		}
+
+		moves = append(moves, &model.PositionMove{
+			PurchaseDate: alert.PurchaseDate,
+			OpenGain:     (open - cost) / cost * 100,
+			CloseGain:    (closing - cost) / cost * 100,
+		})
+	}
+
+	return moves
+}
@@ -0,0 +1,227 @@
+package usecases_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+	"goldie/internal/repository/chats"
+	"goldie/internal/repository/deliveries"
+	"goldie/internal/repository/prices"
+	"goldie/internal/usecases"
+	"goldie/testing/suite"
+)
+
+type stubDigestTG struct {
+	mu     sync.Mutex
+	charts []int64
+}
+
+func (that *stubDigestTG) DigestToString(_ string, digest *model.PriceDigest, positions []*model.PositionMove) string {
+	lines := []string{fmt.Sprintf("%s %s %s", digest.Kind, digest.BestDay.Date.Format(time.DateOnly), digest.WorstDay.Date.Format(time.DateOnly))}
+	for _, position := range positions {
+		lines = append(lines, fmt.Sprintf("%s %.2f %.2f", position.PurchaseDate.Format(time.DateOnly), position.OpenGain, position.CloseGain))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (that *stubDigestTG) SendDigestChart(_ context.Context, chat *model.TgChat) error {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	that.charts = append(that.charts, chat.SourceID)
+	return nil
+}
+
+// saveDigestPrices saves the prices of the week from 3 to 9 March 2025 and the prices around it.
+func saveDigestPrices(ctx context.Context, t *testing.T, pricesRepository *prices.Repository) {
+	var goldPrices []*model.GoldPrice
+	for _, day := range []struct {
+		date                  string
+		sell1, sell10         float64
+		purchase1, purchase10 float64
+	}{
+		{date: "2025-02-20", sell1: 500, sell10: 5000, purchase1: 450, purchase10: 4500},
+		{date: "2025-02-28", sell1: 1000, sell10: 10000, purchase1: 950, purchase10: 9500},
+		{date: "2025-03-03", sell1: 1010, sell10: 10100, purchase1: 960, purchase10: 9600},
+		{date: "2025-03-04", sell1: 1030, sell10: 10200, purchase1: 980, purchase10: 9700},
+		{date: "2025-03-05", sell1: 1000, sell10: 10000, purchase1: 950, purchase10: 9500},
+		{date: "2025-03-07", sell1: 1020, sell10: 10300, purchase1: 970, purchase10: 9800},
+		{date: "2025-03-10", sell1: 2000, sell10: 20000, purchase1: 1900, purchase10: 19000},
+	} {
+		date, err := time.Parse(time.DateOnly, day.date)
+		require.NoError(t, err)
+
+		goldPrices = append(goldPrices,
+			&model.GoldPrice{Date: date, Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: day.purchase1, SellPrice: day.sell1},
+			&model.GoldPrice{Date: date, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: day.purchase10, SellPrice: day.sell10},
+		)
+	}
+
+	_, err := pricesRepository.SavePrices(ctx, goldPrices)
+	require.NoError(t, err)
+}
+
+func Test_PricesRepository_GetPeriodStats(t *testing.T) {
+	t.Run("should find the open, the close and the extremes of every weight", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+
+		// Given: The prices of the week and the prices around it
+		saveDigestPrices(ctx, t, pricesRepository)
+
+		// When: We get the stats of the week
+		begin := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+		stats, err := pricesRepository.GetPeriodStats(ctx, model.PriceSourceNBKR, begin, begin.AddDate(0, 0, 7))
+		require.NoError(t, err)
+
+		// Then: Only the prices of the week should be counted, the open and the close come from its first and last dates
+		require.Len(t, stats, 2)
+		for _, tc := range []struct {
+			stats                                         *model.PeriodPriceStats
+			weight, open, close, high, low, pOpen, pClose float64
+		}{
+			{stats: stats[0], weight: 1, open: 1010, close: 1020, high: 1030, low: 1000, pOpen: 960, pClose: 970},
+			{stats: stats[1], weight: 10, open: 10100, close: 10300, high: 10300, low: 10000, pOpen: 9600, pClose: 9800},
+		} {
+			require.Equal(t, tc.weight, tc.stats.Weight)
+			require.Equal(t, "2025-03-03", tc.stats.OpenDate.Format(time.DateOnly))
+			require.Equal(t, "2025-03-07", tc.stats.CloseDate.Format(time.DateOnly))
+			require.Equal(t, tc.open, tc.stats.Open)
+			require.Equal(t, tc.close, tc.stats.Close)
+			require.Equal(t, tc.high, tc.stats.High)
+			require.Equal(t, tc.low, tc.stats.Low)
+			require.Equal(t, tc.pOpen, tc.stats.PurchaseOpen)
+			require.Equal(t, tc.pClose, tc.stats.PurchaseClose)
+		}
+	})
+}
+
+func Test_PricesRepository_GetDailyChanges(t *testing.T) {
+	t.Run("should average the changes of the weights against the previous published date", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+
+		// Given: The prices of the week and the prices around it
+		saveDigestPrices(ctx, t, pricesRepository)
+
+		// When: We get the daily changes of the week
+		begin := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+		changes, err := pricesRepository.GetDailyChanges(ctx, model.PriceSourceNBKR, begin, begin.AddDate(0, 0, 7))
+		require.NoError(t, err)
+
+		// Then: The first date should be compared with the last date before the week, the gap on 6 March is skipped
+		require.Len(t, changes, 4)
+		for i, tc := range []struct {
+			date    string
+			percent float64
+		}{
+			{date: "2025-03-03", percent: 1},
+			{date: "2025-03-04", percent: 1.485149},
+			{date: "2025-03-05", percent: -2.436703},
+			{date: "2025-03-07", percent: 2.5},
+		} {
+			require.Equal(t, tc.date, changes[i].Date.Format(time.DateOnly))
+			require.InDelta(t, tc.percent, changes[i].Percent, 0.0001)
+		}
+	})
+}
+
+func Test_DigestUseCase_Run(t *testing.T) {
+	// Given: Monday 06:00 UTC is after the default delivery time in Bishkek
+	now := time.Date(2025, 3, 10, 6, 0, 0, 0, time.UTC)
+	purchaseDate := time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should send the weekly digest with the position moves once", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{}
+		digestTG := &stubDigestTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 1})
+		digestUC := usecases.NewDigestUseCase(st.Logger, pricesRepository, chatsRepository, digestTG, alertDeliveryUC)
+
+		// Given: The prices of the week and the chats with the positions bought before it
+		saveDigestPrices(ctx, t, pricesRepository)
+
+		const realizableChatID, marketChatID, monthlyChatID = int64(100), int64(200), int64(300)
+		require.NoError(t, chatsRepository.SetDigest(ctx, realizableChatID, model.DigestWeekly, true))
+		require.NoError(t, chatsRepository.SetDigestChart(ctx, realizableChatID, true))
+		require.NoError(t, chatsRepository.CreateAlert2Subscription(ctx, realizableChatID, purchaseDate))
+
+		require.NoError(t, chatsRepository.SetDigest(ctx, marketChatID, model.DigestWeekly, true))
+		require.NoError(t, chatsRepository.SetGainView(ctx, marketChatID, model.GainViewMarket))
+		require.NoError(t, chatsRepository.CreateAlert2Subscription(ctx, marketChatID, purchaseDate))
+
+		require.NoError(t, chatsRepository.SetDigest(ctx, monthlyChatID, model.DigestMonthly, true))
+
+		// When: We run the weekly digest twice
+		digestUC.Run(ctx, model.DigestWeekly, now)
+		digestUC.Run(ctx, model.DigestWeekly, now.Add(15*time.Minute))
+
+		// Then: The position moves should be counted for the view of every chat, the digest should be sent once
+		require.ElementsMatch(t, []sentMessage{
+			{chatID: realizableChatID, text: "weekly 2025-03-07 2025-03-05\n2025-02-28 -4.00 -2.09"},
+			{chatID: marketChatID, text: "weekly 2025-03-07 2025-03-05\n2025-02-28 1.00 2.91"},
+		}, tgIntegration.messages)
+
+		// Then: Only the chat which opted in should get the chart
+		require.Equal(t, []int64{realizableChatID}, digestTG.charts)
+	})
+
+	t.Run("shouldn't send the chart after the failed digest", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{failures: 1}
+		digestTG := &stubDigestTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 3})
+		digestUC := usecases.NewDigestUseCase(st.Logger, pricesRepository, chatsRepository, digestTG, alertDeliveryUC)
+
+		// Given: The prices of the week and the chat with the chart
+		saveDigestPrices(ctx, t, pricesRepository)
+
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.SetDigest(ctx, chatID, model.DigestWeekly, true))
+		require.NoError(t, chatsRepository.SetDigestChart(ctx, chatID, true))
+
+		// When: The digest can't be sent
+		digestUC.Run(ctx, model.DigestWeekly, now)
+
+		// Then: The chart shouldn't be sent without the digest
+		require.Empty(t, tgIntegration.messages)
+		require.Empty(t, digestTG.charts)
+	})
+
+	t.Run("shouldn't send the digest before the delivery time", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 1})
+		digestUC := usecases.NewDigestUseCase(st.Logger, pricesRepository, chatsRepository, &stubDigestTG{}, alertDeliveryUC)
+
+		// Given: The prices of the week and the chat which gets the digest at noon
+		saveDigestPrices(ctx, t, pricesRepository)
+
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.SetDigest(ctx, chatID, model.DigestWeekly, true))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, chatID, "12:00"))
+
+		// When: We run the weekly digest before and after noon in Bishkek
+		digestUC.Run(ctx, model.DigestWeekly, now)
+		require.Empty(t, tgIntegration.messages)
+
+		digestUC.Run(ctx, model.DigestWeekly, now.Add(time.Hour))
+
+		// Then: The digest should be sent after noon only
+		require.Equal(t, []sentMessage{{chatID: chatID, text: "weekly 2025-03-07 2025-03-05"}}, tgIntegration.messages)
+	})
+}
@@ -0,0 +1,132 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"goldie/internal/model"
+)
+
+// moveBaseline contains the sell prices by weight to compare the latest prices with.
+type moveBaseline struct {
+	date   time.Time // the previous published date, it's zero for the average
+	prices map[float64]float64
+}
+
+func moveBaselineKey(alert *model.TgChatMoveAlert) string {
+	if alert.Baseline == model.MoveAlertBaselineAverage {
+		return fmt.Sprintf("%s:%d", model.MoveAlertBaselineAverage, alert.Days)
+	}
+
+	return model.MoveAlertBaselinePrevious
+}
+
+// loadMoveBaselines returns the baselines used by the move alerts of the chats.
+func (that *AlertUseCase) loadMoveBaselines(ctx context.Context, chats []*model.TgChat, latestDate time.Time) (map[string]*moveBaseline, error) {
+	baselines := make(map[string]*moveBaseline)
+
+	for _, chat := range chats {
+		for _, alert := range chat.MoveAlerts {
+			key := moveBaselineKey(alert)
+			if _, exists := baselines[key]; exists {
+				continue
+			}
+
+			var (
+				baseline *moveBaseline
+				err      error
+			)
+
+			if alert.Baseline == model.MoveAlertBaselineAverage {
+				baseline, err = that.loadAverageBaseline(ctx, latestDate, alert.Days)
+			} else {
+				baseline, err = that.loadPreviousBaseline(ctx, latestDate)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			baselines[key] = baseline
+		}
+	}
+
+	return baselines, nil
+}
+
+func (that *AlertUseCase) loadPreviousBaseline(ctx context.Context, latestDate time.Time) (*moveBaseline, error) {
+	previous, err := that.pricesRepository.GetLatestPricesBefore(ctx, model.PriceSourceNBKR, latestDate)
+	if err != nil {
+		return nil, fmt.Errorf("get previous prices: %w", err)
+	}
+
+	baseline := &moveBaseline{prices: make(map[float64]float64, len(previous))}
+	for _, price := range previous {
+		baseline.date = price.Date
+		baseline.prices[price.Weight] = price.SellPrice
+	}
+
+	return baseline, nil
+}
+
+// loadAverageBaseline returns the average sell prices of the given number of the published dates before the latest date,
+// the weekends and the holidays aren't counted.
+func (that *AlertUseCase) loadAverageBaseline(ctx context.Context, latestDate time.Time, days int) (*moveBaseline, error) {
+	prices, err := that.pricesRepository.GetLastPricesBefore(ctx, model.PriceSourceNBKR, latestDate, days)
+	if err != nil {
+		return nil, fmt.Errorf("get prices of %d dates: %w", days, err)
+	}
+
+	sums := make(map[float64]float64)
+	counts := make(map[float64]int)
+	for _, price := range prices {
+		sums[price.Weight] += price.SellPrice
+		counts[price.Weight]++
+	}
+
+	baseline := &moveBaseline{prices: make(map[float64]float64, len(sums))}
+	for weight, sum := range sums {
+		baseline.prices[weight] = sum / float64(counts[weight])
+	}
+
+	return baseline, nil
+}
+
+// findPriceMoves returns the moves of the latest prices which exceed the alerts percent.
+func findPriceMoves(alerts []*model.TgChatMoveAlert, latestPrices map[float64]*model.GoldPrice, baselines map[string]*moveBaseline) []*model.PriceMove {
+	var moves []*model.PriceMove
+
+	for _, alert := range alerts {
+		price, ok := latestPrices[alert.Weight]
+		if !ok {
+			continue
+		}
+
+		baseline := baselines[moveBaselineKey(alert)]
+		if baseline == nil {
+			continue
+		}
+
+		basePrice := baseline.prices[alert.Weight]
+		if basePrice <= 0 {
+			continue
+		}
+
+		movedPercent := (price.SellPrice - basePrice) / basePrice * 100
+		if math.Abs(movedPercent) <= alert.Percent {
+			continue
+		}
+
+		moves = append(moves, &model.PriceMove{
+			Alert:        alert,
+			Price:        price,
+			BasePrice:    basePrice,
+			BaseDate:     baseline.date,
+			MovedPercent: movedPercent,
+		})
+	}
+
+	return moves
+}
@@ -0,0 +1,83 @@
+package usecases
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"goldie/internal/model"
+)
+
+type PausePricesRepository interface {
+	GetPeriodStats(ctx context.Context, source string, begin, end time.Time) ([]*model.PeriodPriceStats, error)
+}
+
+type PauseChatsRepository interface {
+	FetchResumedChats(ctx context.Context, now time.Time) ([]*model.TgChat, error)
+	ClearPause(ctx context.Context, chatIDs []int64) error
+}
+
+type PauseTGIntegration interface {
+	ResumedToString(languageCode string, pausedAt, pausedUntil time.Time, stats []*model.PeriodPriceStats) string
+}
+
+type PauseUseCase struct {
+	logger           *slog.Logger
+	pricesRepository PausePricesRepository
+	chatsRepository  PauseChatsRepository
+	tgIntegration    PauseTGIntegration
+	deliverer        AlertDeliverer
+}
+
+func NewPauseUseCase(logger *slog.Logger, pricesRepository PausePricesRepository, chatsRepository PauseChatsRepository, tgIntegration PauseTGIntegration, deliverer AlertDeliverer) *PauseUseCase {
+	return &PauseUseCase{logger: logger.With("component", "pause"), pricesRepository: pricesRepository, chatsRepository: chatsRepository, tgIntegration: tgIntegration, deliverer: deliverer}
+}
+
+// Run resumes the alerts of the chats whose pause is over and tells them how the prices moved meanwhile.
+// The summary is sent at the delivery time of the chat, the pause is kept until then.
+func (that *PauseUseCase) Run(ctx context.Context, now time.Time) {
+	log := that.logger.With("method", "Run")
+
+	chats, err := that.chatsRepository.FetchResumedChats(ctx, now)
+	if err != nil {
+		log.Error("failed to get resumed chats", "error", err)
+		return
+	}
+
+	chatIDs := make([]int64, 0, len(chats))
+	for _, chat := range chats {
+		if !chat.IsDeliveryTimeReached(now) {
+			continue
+		}
+
+		chatIDs = append(chatIDs, chat.ID)
+
+		pausedAt := *chat.PausedUntil
+		if chat.PausedAt != nil {
+			pausedAt = *chat.PausedAt
+		}
+
+		// The price dates are at midnight UTC, the dates of the pause and today are included
+		begin := time.Date(pausedAt.Year(), pausedAt.Month(), pausedAt.Day(), 0, 0, 0, 0, time.UTC)
+		end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+
+		stats, statsErr := that.pricesRepository.GetPeriodStats(ctx, model.PriceSourceNBKR, begin, end)
+		if statsErr != nil {
+			log.Error("failed to get prices of the pause", "error", statsErr, "chat_id", chat.SourceID)
+		}
+
+		text := that.tgIntegration.ResumedToString(chat.GetLanguageCode(), pausedAt.In(chat.GetLocation()), chat.PausedUntil.In(chat.GetLocation()), stats)
+		that.deliverer.Deliver(ctx, chat, model.AlertKindResumed, *chat.PausedUntil, text)
+	}
+
+	if len(chatIDs) == 0 {
+		return
+	}
+
+	if err = that.chatsRepository.ClearPause(ctx, chatIDs); err != nil {
+		log.Error("failed to clear pause", "error", err)
+		return
+	}
+
+	log.Info("alerts resumed", "chats", len(chatIDs))
+}
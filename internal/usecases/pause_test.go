@@ -0,0 +1,97 @@
+package usecases_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+	"goldie/internal/repository/chats"
+	"goldie/internal/repository/deliveries"
+	"goldie/internal/repository/prices"
+	"goldie/internal/usecases"
+	"goldie/testing/suite"
+)
+
+func (that *stubAlertTG) ResumedToString(_ string, _, _ time.Time, stats []*model.PeriodPriceStats) string {
+	return fmt.Sprintf("resumed %d", len(stats))
+}
+
+func Test_PauseUseCase_Run(t *testing.T) {
+	t.Run("should skip the alerts while paused and resume them with the summary", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 1})
+		alertUC := usecases.NewAlertUseCase(st.Logger, nil, st.Loc, pricesRepository, chatsRepository, tgIntegration, alertDeliveryUC)
+		pauseUC := usecases.NewPauseUseCase(st.Logger, pricesRepository, chatsRepository, tgIntegration, alertDeliveryUC)
+
+		// Given: The prices and the paused chat with alert1
+		_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+			{Date: time.Now().UTC().Truncate(24 * time.Hour), Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 9900, SellPrice: 10000},
+		})
+		require.NoError(t, err)
+
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.EnableAlert1(ctx, chatID))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, chatID, "00:00"))
+		require.NoError(t, chatsRepository.PauseChat(ctx, chatID, time.Now().AddDate(0, 0, 7)))
+
+		// When: We run the alerts and the resume
+		alertUC.Run(ctx)
+		pauseUC.Run(ctx, time.Now())
+
+		// Then: Nothing should be sent
+		require.Empty(t, tgIntegration.messages)
+
+		// When: The pause is over
+		require.NoError(t, chatsRepository.PauseChat(ctx, chatID, time.Now()))
+		pauseUC.Run(ctx, time.Now())
+		alertUC.Run(ctx)
+
+		// Then: The summary and the alert should be sent, the pause should be forgotten
+		require.Equal(t, []sentMessage{{chatID: chatID, text: "resumed 1"}, {chatID: chatID, text: "prices"}}, tgIntegration.messages)
+
+		chat, err := chatsRepository.GetChat(ctx, chatID)
+		require.NoError(t, err)
+		require.Nil(t, chat.PausedAt)
+		require.Nil(t, chat.PausedUntil)
+	})
+
+	t.Run("should send the summary at the delivery time", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubAlertTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 1})
+		pauseUC := usecases.NewPauseUseCase(st.Logger, prices.NewRepository(st.GetDB()), chatsRepository, tgIntegration, alertDeliveryUC)
+
+		// Given: The chat with the delivery time at noon whose pause is over at midnight in Bishkek
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.EnableAlert1(ctx, chatID))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, chatID, "12:00"))
+		require.NoError(t, chatsRepository.PauseChat(ctx, chatID, time.Date(2025, 3, 9, 18, 0, 0, 0, time.UTC)))
+
+		// When: We run the resume in the morning
+		pauseUC.Run(ctx, time.Date(2025, 3, 10, 5, 0, 0, 0, time.UTC))
+
+		// Then: Nothing should be sent and the chat should stay paused
+		require.Empty(t, tgIntegration.messages)
+
+		chat, err := chatsRepository.GetChat(ctx, chatID)
+		require.NoError(t, err)
+		require.NotNil(t, chat.PausedUntil)
+
+		// When: We run the resume after noon
+		pauseUC.Run(ctx, time.Date(2025, 3, 10, 6, 0, 0, 0, time.UTC))
+
+		// Then: The summary should be sent and the pause should be forgotten
+		require.Equal(t, []sentMessage{{chatID: chatID, text: "resumed 0"}}, tgIntegration.messages)
+
+		chat, err = chatsRepository.GetChat(ctx, chatID)
+		require.NoError(t, err)
+		require.Nil(t, chat.PausedUntil)
+	})
+}
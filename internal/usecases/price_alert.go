@@ -0,0 +1,96 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"goldie/internal/model"
+)
+
+type PriceAlertPricesRepository interface {
+	GetLatestPrices(ctx context.Context) ([]*model.GoldPrice, error)
+}
+
+type PriceAlertChatsRepository interface {
+	FetchPriceAlerts(ctx context.Context) ([]*model.TgChatPriceAlert, error)
+	SetPriceAlertCrossed(ctx context.Context, alertID int64, crossed bool) error
+}
+
+type PriceAlertTGIntegration interface {
+	PriceAlertToString(languageCode string, alert *model.TgChatPriceAlert, price *model.GoldPrice) string
+}
+
+type PriceAlertUseCase struct {
+	logger           *slog.Logger
+	pricesRepository PriceAlertPricesRepository
+	chatsRepository  PriceAlertChatsRepository
+	tgIntegration    PriceAlertTGIntegration
+	deliverer        AlertDeliverer
+}
+
+func NewPriceAlertUseCase(logger *slog.Logger, pricesRepository PriceAlertPricesRepository, chatsRepository PriceAlertChatsRepository, tgIntegration PriceAlertTGIntegration, deliverer AlertDeliverer) *PriceAlertUseCase {
+	return &PriceAlertUseCase{logger: logger.With("component", "price_alert"), pricesRepository: pricesRepository, chatsRepository: chatsRepository, tgIntegration: tgIntegration, deliverer: deliverer}
+}
+
+// Run checks the threshold alerts against the latest prices. The alert is fired once when the price crosses
+// the threshold and is armed again when the price comes back. The fired alert is delivered once for the price date,
+// so it isn't sent twice if its state isn't saved. It fails if some alerts aren't updated, they are checked again
+// by the next run.
+func (that *PriceAlertUseCase) Run(ctx context.Context) error {
+	log := that.logger.With("method", "Run")
+
+	prices, err := that.pricesRepository.GetLatestPrices(ctx)
+	if err != nil {
+		return fmt.Errorf("get prices: %w", err)
+	}
+
+	if len(prices) == 0 {
+		log.Info("no prices found")
+		return nil
+	}
+
+	alerts, err := that.chatsRepository.FetchPriceAlerts(ctx)
+	if err != nil {
+		return fmt.Errorf("get price alerts: %w", err)
+	}
+
+	weightLookup := make(map[float64]*model.GoldPrice, len(prices))
+	for _, price := range prices {
+		weightLookup[price.Weight] = price
+	}
+
+	var fired, failed int
+
+	for _, alert := range alerts {
+		price, ok := weightLookup[alert.Weight]
+		if !ok {
+			continue
+		}
+
+		crossed := alert.IsCrossedBy(price)
+		if crossed == alert.Crossed {
+			continue
+		}
+
+		if crossed {
+			// The failed delivery is retried by the deliverer
+			text := that.tgIntegration.PriceAlertToString(alert.Chat.GetLanguageCode(), alert, price)
+			that.deliverer.Deliver(ctx, &alert.Chat, model.PriceAlertDeliveryKind(alert.ID), price.Date, text)
+			fired++
+		}
+
+		if err = that.chatsRepository.SetPriceAlertCrossed(ctx, alert.ID, crossed); err != nil {
+			log.Error("failed to update price alert state", "error", err, "alert_id", alert.ID)
+			failed++
+		}
+	}
+
+	log.Info("price alerts checked", "alerts", len(alerts), "fired", fired, "failed", failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d price alerts failed", failed)
+	}
+
+	return nil
+}
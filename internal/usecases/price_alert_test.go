@@ -0,0 +1,109 @@
+package usecases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+	"goldie/internal/repository/chats"
+	"goldie/internal/repository/deliveries"
+	"goldie/internal/repository/prices"
+	"goldie/internal/usecases"
+	"goldie/testing/suite"
+)
+
+type sentMessage struct {
+	chatID int64
+	text   string
+}
+
+type stubPriceAlertTG struct {
+	messages []sentMessage
+}
+
+func (that *stubPriceAlertTG) SendMessage(_ context.Context, chatID int64, text string) error {
+	that.messages = append(that.messages, sentMessage{chatID: chatID, text: text})
+	return nil
+}
+
+func (that *stubPriceAlertTG) PriceAlertToString(_ string, alert *model.TgChatPriceAlert, price *model.GoldPrice) string {
+	return alert.Direction
+}
+
+func Test_PriceAlertUseCase_Run(t *testing.T) {
+	t.Run("should fire once when the price crosses the threshold and arm again when it comes back", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubPriceAlertTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 1})
+		priceAlertUC := usecases.NewPriceAlertUseCase(st.Logger, pricesRepository, chatsRepository, tgIntegration, alertDeliveryUC)
+
+		day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -4)
+		savePrice := func(sellPrice float64) {
+			day = day.AddDate(0, 0, 1)
+			_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+				{Date: day, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: sellPrice - 1000, SellPrice: sellPrice},
+			})
+			require.NoError(t, err)
+		}
+
+		// Given: The chat waits for the 10 g bar sell price below 85000
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.CreatePriceAlert(ctx, chatID, &model.TgChatPriceAlert{
+			Weight: 10, Field: model.PriceAlertFieldSell, Direction: model.PriceAlertBelow, Threshold: 85000,
+		}))
+
+		// When: The price drops below the threshold twice in a row
+		savePrice(84000)
+		require.NoError(t, priceAlertUC.Run(ctx))
+		savePrice(83000)
+		require.NoError(t, priceAlertUC.Run(ctx))
+
+		// Then: The alert should be fired only once
+		require.Equal(t, []sentMessage{{chatID: chatID, text: model.PriceAlertBelow}}, tgIntegration.messages)
+
+		// When: The price comes back and drops again
+		savePrice(86000)
+		require.NoError(t, priceAlertUC.Run(ctx))
+		require.Len(t, tgIntegration.messages, 1)
+
+		savePrice(84500)
+		require.NoError(t, priceAlertUC.Run(ctx))
+
+		// Then: The alert should be fired again
+		require.Len(t, tgIntegration.messages, 2)
+	})
+	t.Run("should retry the failed alert without firing it again", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		sender := &stubAlertTG{failures: 1}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), sender, usecases.AlertDeliveryOptions{MaxAttempts: 2})
+		priceAlertUC := usecases.NewPriceAlertUseCase(st.Logger, pricesRepository, chatsRepository, &stubPriceAlertTG{}, alertDeliveryUC)
+
+		// Given: The chat waits for the 10 g bar sell price below 85000 and the price is below it
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.CreatePriceAlert(ctx, chatID, &model.TgChatPriceAlert{
+			Weight: 10, Field: model.PriceAlertFieldSell, Direction: model.PriceAlertBelow, Threshold: 85000,
+		}))
+
+		_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+			{Date: time.Now().UTC().Truncate(24 * time.Hour), Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 83000, SellPrice: 84000},
+		})
+		require.NoError(t, err)
+
+		// When: The alert can't be sent and the checks and the retries are run
+		require.NoError(t, priceAlertUC.Run(ctx))
+		require.Empty(t, sender.messages)
+
+		require.NoError(t, priceAlertUC.Run(ctx))
+		alertDeliveryUC.RetryFailed(ctx)
+
+		// Then: The alert should be sent once by the retry
+		require.Equal(t, []sentMessage{{chatID: chatID, text: model.PriceAlertBelow}}, sender.messages)
+	})
+}
@@ -0,0 +1,116 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"goldie/internal/model"
+)
+
+type PriceSaverRepository interface {
+	SavePrices(ctx context.Context, prices []*model.GoldPrice) (*model.SavePricesResult, error)
+	GetLatestPricesBefore(ctx context.Context, source string, date time.Time) ([]*model.GoldPrice, error)
+	GetApprovedQuarantinedPrices(ctx context.Context, source string, begin, end time.Time) ([]*model.QuarantinedPrice, error)
+	QuarantinePrices(ctx context.Context, prices []*model.QuarantinedPrice) (int64, error)
+}
+
+// PricesSaver saves the fetched prices. Every import path saves through PriceSaver, so the prices are validated
+// the same way wherever they come from.
+type PricesSaver interface {
+	SavePrices(ctx context.Context, prices []*model.GoldPrice) (*model.SavePricesResult, error)
+}
+
+// PriceSaver validates the fetched prices before saving them. The suspicious prices and the weights which disappeared
+// from a date are quarantined until an admin reviews them.
+type PriceSaver struct {
+	logger     *slog.Logger
+	repository PriceSaverRepository
+	notifier   UpdatePricesNotifier
+	rules      PriceValidationRules
+}
+
+func NewPriceSaver(logger *slog.Logger, repository PriceSaverRepository, notifier UpdatePricesNotifier, rules PriceValidationRules) *PriceSaver {
+	return &PriceSaver{logger: logger.With("component", "price_saver"), repository: repository, notifier: notifier, rules: rules}
+}
+
+// SavePrices saves the valid prices of one source and quarantines the suspicious ones.
+func (that *PriceSaver) SavePrices(ctx context.Context, prices []*model.GoldPrice) (*model.SavePricesResult, error) {
+	if len(prices) == 0 {
+		return &model.SavePricesResult{}, nil
+	}
+
+	source := prices[0].Source
+	log := that.logger.With("method", "SavePrices", "source", source)
+
+	begin, end := prices[0].Date, prices[0].Date
+	for _, price := range prices {
+		if price.Date.Before(begin) {
+			begin = price.Date
+		}
+
+		if price.Date.After(end) {
+			end = price.Date
+		}
+	}
+
+	previous, err := that.repository.GetLatestPricesBefore(ctx, source, begin)
+	if err != nil {
+		return nil, fmt.Errorf("get previous prices: %w", err)
+	}
+
+	approved, err := that.repository.GetApprovedQuarantinedPrices(ctx, source, begin, end)
+	if err != nil {
+		return nil, fmt.Errorf("get approved prices: %w", err)
+	}
+
+	valid, quarantined := validatePrices(prices, previous, approved, that.rules)
+
+	if len(quarantined) > 0 {
+		created, err := that.repository.QuarantinePrices(ctx, quarantined)
+		if err != nil {
+			return nil, fmt.Errorf("quarantine prices: %w", err)
+		}
+
+		log.Warn("suspicious prices are quarantined", "quarantined", len(quarantined), "new", created)
+
+		if created > 0 {
+			notifyAdmins(ctx, that.notifier, formatQuarantinedPrices(source, created, quarantined))
+		}
+	}
+
+	result, err := that.repository.SavePrices(ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Quarantined = len(quarantined)
+
+	return result, nil
+}
+
+// formatQuarantinedPrices describes the quarantined prices for the admins.
+func formatQuarantinedPrices(source string, created int64, prices []*model.QuarantinedPrice) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("🚧 %s: %d new suspicious prices are quarantined, review them with /quarantine", source, created))
+
+	for _, price := range prices[:min(len(prices), revisionsReportLimit)] {
+		if price.IsMissing() {
+			sb.WriteString(fmt.Sprintf("\n%s %g g: missing (%s)", price.Date.Format(time.DateOnly), price.Weight, price.Reason))
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("\n%s %g g: purchase %.2f, sell %.2f (%s)",
+			price.Date.Format(time.DateOnly), price.Weight, price.PurchasePrice, price.SellPrice, price.Reason,
+		))
+	}
+
+	if len(prices) > revisionsReportLimit {
+		sb.WriteString(fmt.Sprintf("\n... and %d more", len(prices)-revisionsReportLimit))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,189 @@
+package usecases
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"goldie/internal/model"
+)
+
+// PriceValidationRules describes when a scraped price is suspicious. The zero value disables the check.
+type PriceValidationRules struct {
+	MaxDailyMove        float64 // the largest move of the price since the previous date, 0.1 is 10%
+	MaxPerGramDeviation float64 // the largest deviation of the per gram sell price from the median of the date, 0.5 is 50%
+}
+
+// validatePrices splits the prices into the valid and the suspicious ones. The prices are compared with the previous
+// valid prices of the same weight, starting from the stored ones. The approved prices are always valid.
+// The weight of the previous date which is missing from the next one is flagged by the entry without prices.
+func validatePrices(prices []*model.GoldPrice, previous []*model.GoldPrice, approved []*model.QuarantinedPrice, rules PriceValidationRules) ([]*model.GoldPrice, []*model.QuarantinedPrice) {
+	approvedKeys := make(map[string]bool, len(approved))
+	for _, price := range approved {
+		approvedKeys[quarantineKey(price.GoldPrice())] = true
+	}
+
+	reference := make(map[float64]*model.GoldPrice, len(previous))
+	for _, price := range previous {
+		reference[price.Weight] = price
+	}
+
+	sorted := slices.Clone(prices)
+	slices.SortStableFunc(sorted, func(a, b *model.GoldPrice) int {
+		if c := a.Date.Compare(b.Date); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.Weight, b.Weight)
+	})
+
+	valid := make([]*model.GoldPrice, 0, len(prices))
+	var quarantined []*model.QuarantinedPrice
+
+	for start := 0; start < len(sorted); {
+		end := start + 1
+		for end < len(sorted) && sorted[end].Date.Equal(sorted[start].Date) {
+			end++
+		}
+
+		group := sorted[start:end]
+		median := medianPerGramPrice(group)
+		accepted := make([]*model.GoldPrice, 0, len(group))
+
+		for _, price := range group {
+			reasons := checkPrice(price, median, reference, rules)
+			if len(reasons) > 0 && !approvedKeys[quarantineKey(price)] {
+				quarantined = append(quarantined, &model.QuarantinedPrice{
+					Date:          price.Date,
+					Weight:        price.Weight,
+					Source:        price.Source,
+					PurchasePrice: price.PurchasePrice,
+					SellPrice:     price.SellPrice,
+					Reason:        strings.Join(reasons, "; "),
+					Status:        model.QuarantinePending,
+				})
+
+				continue
+			}
+
+			accepted = append(accepted, price)
+		}
+
+		quarantined = append(quarantined, missingWeights(group, reference, approvedKeys)...)
+
+		// The next date is compared with the valid prices only
+		for _, price := range accepted {
+			reference[price.Weight] = price
+		}
+
+		valid = append(valid, accepted...)
+		start = end
+	}
+
+	return valid, quarantined
+}
+
+// missingWeights flags the weights of the reference which the date doesn't have and drops them from the reference,
+// so the weight is flagged once and its return is checked as a new weight.
+func missingWeights(group []*model.GoldPrice, reference map[float64]*model.GoldPrice, approvedKeys map[string]bool) []*model.QuarantinedPrice {
+	published := make(map[float64]bool, len(group))
+	for _, price := range group {
+		published[price.Weight] = true
+	}
+
+	var weights []float64
+	for weight := range reference {
+		if !published[weight] {
+			weights = append(weights, weight)
+		}
+	}
+
+	slices.Sort(weights)
+
+	var missing []*model.QuarantinedPrice
+	for _, weight := range weights {
+		previous := reference[weight]
+		delete(reference, weight)
+
+		price := &model.GoldPrice{Date: group[0].Date, Weight: weight, Source: group[0].Source}
+		if approvedKeys[quarantineKey(price)] {
+			continue
+		}
+
+		missing = append(missing, &model.QuarantinedPrice{
+			Date:   price.Date,
+			Weight: weight,
+			Source: price.Source,
+			Reason: fmt.Sprintf("weight %g g published on %s is missing", weight, previous.Date.Format(time.DateOnly)),
+			Status: model.QuarantinePending,
+		})
+	}
+
+	return missing
+}
+
+func checkPrice(price *model.GoldPrice, median float64, reference map[float64]*model.GoldPrice, rules PriceValidationRules) []string {
+	var reasons []string
+
+	if price.PurchasePrice <= 0 || price.SellPrice <= 0 {
+		return []string{"price isn't positive"}
+	}
+
+	if price.SellPrice < price.PurchasePrice {
+		reasons = append(reasons, fmt.Sprintf("sell price %.2f is lower than purchase price %.2f", price.SellPrice, price.PurchasePrice))
+	}
+
+	if rules.MaxPerGramDeviation > 0 && median > 0 {
+		if deviation := math.Abs(price.SellPrice/price.Weight/median - 1); deviation > rules.MaxPerGramDeviation {
+			reasons = append(reasons, fmt.Sprintf("per gram price deviates %.0f%% from the other weights", deviation*100))
+		}
+	}
+
+	previous, ok := reference[price.Weight]
+	if !ok {
+		if len(reference) > 0 {
+			reasons = append(reasons, fmt.Sprintf("weight %g g wasn't published before", price.Weight))
+		}
+
+		return reasons
+	}
+
+	if rules.MaxDailyMove > 0 {
+		purchaseMove := math.Abs(price.PurchasePrice/previous.PurchasePrice - 1)
+		sellMove := math.Abs(price.SellPrice/previous.SellPrice - 1)
+
+		if move := max(purchaseMove, sellMove); move > rules.MaxDailyMove {
+			reasons = append(reasons, fmt.Sprintf("price moved %.1f%% since %s", move*100, previous.Date.Format(time.DateOnly)))
+		}
+	}
+
+	return reasons
+}
+
+// medianPerGramPrice returns the median sell price of one gram among the weights.
+func medianPerGramPrice(prices []*model.GoldPrice) float64 {
+	perGram := make([]float64, 0, len(prices))
+	for _, price := range prices {
+		if price.Weight > 0 && price.SellPrice > 0 {
+			perGram = append(perGram, price.SellPrice/price.Weight)
+		}
+	}
+
+	if len(perGram) == 0 {
+		return 0
+	}
+
+	slices.Sort(perGram)
+	if len(perGram)%2 == 1 {
+		return perGram[len(perGram)/2]
+	}
+
+	return (perGram[len(perGram)/2-1] + perGram[len(perGram)/2]) / 2
+}
+
+func quarantineKey(price *model.GoldPrice) string {
+	return fmt.Sprintf("%s|%s|%g|%g|%g", price.Source, price.Date.UTC().Format(time.DateOnly), price.Weight, price.PurchasePrice, price.SellPrice)
+}
@@ -0,0 +1,358 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"goldie/internal/model"
+	"goldie/internal/providers"
+)
+
+// reconcileRangeGap is the number of days between the gaps which are still fetched by one request.
+const reconcileRangeGap = 7
+
+// reconcileReportDates limits the number of dates listed in the report.
+const reconcileReportDates = 20
+
+type ReconcilePricesRepository interface {
+	GetWeightsByDate(ctx context.Context, source string) ([]*model.PriceDateWeights, error)
+	GetPendingQuarantinedDates(ctx context.Context, source string) ([]time.Time, error)
+}
+
+type ReconcileGapsRepository interface {
+	GetGaps(ctx context.Context, source string) ([]*model.PriceGap, error)
+	SaveGaps(ctx context.Context, gaps []*model.PriceGap) error
+	DeleteGaps(ctx context.Context, source string, dates []time.Time) error
+}
+
+type ReconcileProviders interface {
+	All() []providers.Provider
+}
+
+// ReconcileReport describes the result of the reconciliation of one source.
+type ReconcileReport struct {
+	Source      string
+	BeginDate   time.Time
+	EndDate     time.Time
+	Missing     int
+	Incomplete  int
+	Filled      []time.Time
+	Remaining   []time.Time
+	Unpublished []time.Time // marked as never published by this run
+	Quarantined []time.Time // fetched by this run, but wait for the review
+}
+
+// HasGaps reports whether the reconciliation found something.
+func (that *ReconcileReport) HasGaps() bool {
+	return that.Missing+that.Incomplete > 0
+}
+
+func (that *ReconcileReport) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("🔍 %s: reconciled prices from %s to %s\n", that.Source, that.BeginDate.Format(time.DateOnly), that.EndDate.Format(time.DateOnly)))
+	sb.WriteString(fmt.Sprintf("Found %d missing and %d incomplete trading days\n", that.Missing, that.Incomplete))
+	sb.WriteString(fmt.Sprintf("Filled: %s\n", formatReportDates(that.Filled)))
+	sb.WriteString(fmt.Sprintf("Still missing: %s\n", formatReportDates(that.Remaining)))
+	sb.WriteString(fmt.Sprintf("Waiting for review: %s\n", formatReportDates(that.Quarantined)))
+	sb.WriteString(fmt.Sprintf("Never published: %s", formatReportDates(that.Unpublished)))
+
+	return sb.String()
+}
+
+type ReconcileUseCase struct {
+	logger           *slog.Logger
+	pricesRepository ReconcilePricesRepository
+	saver            PricesSaver
+	gapsRepository   ReconcileGapsRepository
+	providers        ReconcileProviders
+	notifier         UpdatePricesNotifier
+	maxAttempts      int
+}
+
+// NewReconcileUseCase creates the use case. The gap is remembered as never published after maxAttempts fetches
+// which didn't fill it.
+func NewReconcileUseCase(logger *slog.Logger, pricesRepository ReconcilePricesRepository, saver PricesSaver, gapsRepository ReconcileGapsRepository, providers ReconcileProviders, notifier UpdatePricesNotifier, maxAttempts int) *ReconcileUseCase {
+	return &ReconcileUseCase{logger: logger.With("component", "reconcile"), pricesRepository: pricesRepository, saver: saver, gapsRepository: gapsRepository, providers: providers, notifier: notifier, maxAttempts: max(maxAttempts, 1)}
+}
+
+// Run finds the trading days without prices or with incomplete weight sets, fetches them again
+// and sends the report to the admins.
+func (that *ReconcileUseCase) Run(ctx context.Context) {
+	for _, provider := range that.providers.All() {
+		log := that.logger.With("method", "Run", "source", provider.Source())
+
+		report, err := that.reconcile(ctx, log, provider)
+		if err != nil {
+			log.Error("failed to reconcile prices", "error", err)
+			continue
+		}
+
+		if report == nil || !report.HasGaps() {
+			log.Info("no price gaps found")
+			continue
+		}
+
+		log.Warn("price gaps found",
+			"missing", report.Missing,
+			"incomplete", report.Incomplete,
+			"filled", len(report.Filled),
+			"remaining", len(report.Remaining),
+			"unpublished", len(report.Unpublished),
+			"quarantined", len(report.Quarantined),
+		)
+		notifyAdmins(ctx, that.notifier, report.String())
+	}
+}
+
+func (that *ReconcileUseCase) reconcile(ctx context.Context, log *slog.Logger, provider providers.Provider) (*ReconcileReport, error) {
+	dates, err := that.pricesRepository.GetWeightsByDate(ctx, provider.Source())
+	if err != nil {
+		return nil, fmt.Errorf("get weights by date: %w", err)
+	}
+
+	if len(dates) == 0 {
+		// Nothing is imported yet, it's the backfill job
+		return nil, nil
+	}
+
+	knownGaps, err := that.gapsRepository.GetGaps(ctx, provider.Source())
+	if err != nil {
+		return nil, fmt.Errorf("get known gaps: %w", err)
+	}
+
+	known := make(map[string]*model.PriceGap, len(knownGaps))
+	for _, gap := range knownGaps {
+		known[dateKey(gap.Date)] = gap
+	}
+
+	// The dates with the prices waiting for the review aren't gaps, the admin decides about them
+	quarantined, err := that.getQuarantinedDates(ctx, provider.Source())
+	if err != nil {
+		return nil, err
+	}
+
+	gaps := findPriceGaps(provider.Source(), dates, known, quarantined)
+	report := &ReconcileReport{Source: provider.Source(), BeginDate: dateOnly(dates[0].Date), EndDate: dateOnly(dates[len(dates)-1].Date)}
+
+	for _, gap := range gaps {
+		if gap.Reason == model.PriceGapMissing {
+			report.Missing++
+		} else {
+			report.Incomplete++
+		}
+	}
+
+	// The attempt counts only if the source answered, the network errors don't mean the date isn't published
+	fetched := make(map[string]bool, len(gaps))
+
+	for _, dateRange := range groupPriceGaps(gaps) {
+		beginDate, endDate := dateRange[0].Date, dateRange[len(dateRange)-1].Date.AddDate(0, 0, 1)
+
+		prices, err := provider.GetPrices(ctx, beginDate, endDate)
+		if err != nil && !errors.Is(err, providers.ErrNoRows) {
+			reportFetchError(ctx, log, that.notifier, provider.Source(), err)
+			if len(prices) == 0 {
+				continue
+			}
+		}
+
+		if len(prices) > 0 {
+			if _, err = that.saver.SavePrices(ctx, prices); err != nil {
+				return nil, fmt.Errorf("save prices: %w", err)
+			}
+		}
+
+		for _, gap := range dateRange {
+			fetched[dateKey(gap.Date)] = true
+		}
+	}
+
+	// Check again what is still missing after the fetch
+	if dates, err = that.pricesRepository.GetWeightsByDate(ctx, provider.Source()); err != nil {
+		return nil, fmt.Errorf("get weights by date: %w", err)
+	}
+
+	if quarantined, err = that.getQuarantinedDates(ctx, provider.Source()); err != nil {
+		return nil, err
+	}
+
+	remaining := make(map[string]*model.PriceGap)
+	for _, gap := range findPriceGaps(provider.Source(), dates, known, quarantined) {
+		remaining[dateKey(gap.Date)] = gap
+	}
+
+	var openGaps []*model.PriceGap
+
+	for _, gap := range gaps {
+		openGap, ok := remaining[dateKey(gap.Date)]
+		if !ok && quarantined[dateKey(gap.Date)] {
+			report.Quarantined = append(report.Quarantined, gap.Date)
+			continue
+		}
+
+		if !ok {
+			report.Filled = append(report.Filled, gap.Date)
+			continue
+		}
+
+		if fetched[dateKey(gap.Date)] {
+			openGap.Attempts++
+		}
+
+		if openGap.Attempts >= that.maxAttempts {
+			openGap.Unpublished = true
+			report.Unpublished = append(report.Unpublished, openGap.Date)
+		} else {
+			report.Remaining = append(report.Remaining, openGap.Date)
+		}
+
+		openGaps = append(openGaps, openGap)
+	}
+
+	// The known gaps which are filled by this run or by another job are forgotten, the quarantined ones keep
+	// their attempts until the review
+	var filled []time.Time
+
+	for _, gap := range knownGaps {
+		if _, ok := remaining[dateKey(gap.Date)]; !ok && !gap.Unpublished && !quarantined[dateKey(gap.Date)] {
+			filled = append(filled, gap.Date)
+		}
+	}
+
+	if err = that.gapsRepository.SaveGaps(ctx, openGaps); err != nil {
+		return nil, fmt.Errorf("save gaps: %w", err)
+	}
+
+	if err = that.gapsRepository.DeleteGaps(ctx, provider.Source(), filled); err != nil {
+		return nil, fmt.Errorf("delete filled gaps: %w", err)
+	}
+
+	return report, nil
+}
+
+func (that *ReconcileUseCase) getQuarantinedDates(ctx context.Context, source string) (map[string]bool, error) {
+	dates, err := that.pricesRepository.GetPendingQuarantinedDates(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("get pending quarantined dates: %w", err)
+	}
+
+	quarantined := make(map[string]bool, len(dates))
+	for _, date := range dates {
+		quarantined[dateKey(date)] = true
+	}
+
+	return quarantined, nil
+}
+
+// findPriceGaps returns the weekdays between the first and the last published dates which have no prices,
+// or have fewer weights than both published neighbours. The never published dates and the dates with the prices
+// waiting for the review are skipped.
+func findPriceGaps(source string, dates []*model.PriceDateWeights, known map[string]*model.PriceGap, quarantined map[string]bool) []*model.PriceGap {
+	var gaps []*model.PriceGap
+
+	weights := make(map[string]int, len(dates))
+	for _, date := range dates {
+		weights[dateKey(date.Date)] = date.Weights
+	}
+
+	newGap := func(date time.Time, reason string) *model.PriceGap {
+		gap := &model.PriceGap{Source: source, Date: date, Reason: reason}
+		if knownGap, ok := known[dateKey(date)]; ok {
+			gap.Attempts = knownGap.Attempts
+		}
+
+		return gap
+	}
+
+	last := dateOnly(dates[len(dates)-1].Date)
+	for i, date := 0, dateOnly(dates[0].Date); !date.After(last); date = date.AddDate(0, 0, 1) {
+		count, published := weights[dateKey(date)]
+		if gap, ok := known[dateKey(date)]; (ok && gap.Unpublished) || quarantined[dateKey(date)] {
+			if published {
+				i++
+			}
+
+			continue
+		}
+
+		if published {
+			// The expected set is the smallest one of the neighbours, so the weights added or removed by the source
+			// aren't reported as gaps
+			expected := count
+			if i > 0 {
+				expected = dates[i-1].Weights
+			}
+
+			if i < len(dates)-1 {
+				expected = min(expected, dates[i+1].Weights)
+			}
+
+			if count < expected {
+				gaps = append(gaps, newGap(date, model.PriceGapIncomplete))
+			}
+
+			i++
+			continue
+		}
+
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			continue
+		}
+
+		gaps = append(gaps, newGap(date, model.PriceGapMissing))
+	}
+
+	return gaps
+}
+
+// groupPriceGaps groups the sorted gaps which are close to each other to fetch them by one request.
+func groupPriceGaps(gaps []*model.PriceGap) [][]*model.PriceGap {
+	var groups [][]*model.PriceGap
+
+	for _, gap := range gaps {
+		if len(groups) > 0 {
+			group := groups[len(groups)-1]
+			if gap.Date.Sub(group[len(group)-1].Date) <= reconcileRangeGap*24*time.Hour {
+				groups[len(groups)-1] = append(group, gap)
+				continue
+			}
+		}
+
+		groups = append(groups, []*model.PriceGap{gap})
+	}
+
+	return groups
+}
+
+// dateOnly drops the time of the price date, the dates are stored as midnight UTC.
+func dateOnly(date time.Time) time.Time {
+	date = date.UTC()
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func dateKey(date time.Time) string {
+	return date.UTC().Format(time.DateOnly)
+}
+
+func formatReportDates(dates []time.Time) string {
+	if len(dates) == 0 {
+		return "-"
+	}
+
+	formatted := make([]string, 0, min(len(dates), reconcileReportDates))
+	for _, date := range dates[:min(len(dates), reconcileReportDates)] {
+		formatted = append(formatted, date.Format(time.DateOnly))
+	}
+
+	text := strings.Join(formatted, ", ")
+	if len(dates) > reconcileReportDates {
+		text += fmt.Sprintf(" and %d more", len(dates)-reconcileReportDates)
+	}
+
+	return text
+}
@@ -0,0 +1,159 @@
+package usecases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+	"goldie/internal/providers"
+	"goldie/internal/repository/gaps"
+	"goldie/internal/repository/prices"
+	"goldie/internal/usecases"
+	"goldie/testing/suite"
+)
+
+// stubPriceProvider returns the prices of the requested range from the prepared ones.
+type stubPriceProvider struct {
+	prices   []*model.GoldPrice
+	requests int
+}
+
+func (that *stubPriceProvider) Source() string {
+	return model.PriceSourceNBKR
+}
+
+func (that *stubPriceProvider) GetPrices(_ context.Context, begin, end time.Time) ([]*model.GoldPrice, error) {
+	that.requests++
+
+	var result []*model.GoldPrice
+	for _, price := range that.prices {
+		if !price.Date.Before(begin) && price.Date.Before(end) {
+			result = append(result, price)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, providers.ErrNoRows
+	}
+
+	return result, nil
+}
+
+func Test_ReconcileUseCase_Run(t *testing.T) {
+	t.Run("should fill the missing days and remember the unpublished ones", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		gapsRepository := gaps.NewRepository(st.GetDB())
+
+		newPrice := func(date string, weight float64) *model.GoldPrice {
+			return &model.GoldPrice{Date: suite.GetDateTime(t, date), Weight: weight, Source: model.PriceSourceNBKR, PurchasePrice: 1000 * weight, SellPrice: 1010 * weight}
+		}
+
+		// Given: The prices without Wednesday and without the 10 g bar on the next Monday
+		dbPrices := []*model.GoldPrice{
+			newPrice("2025-03-03", 1), newPrice("2025-03-03", 10),
+			newPrice("2025-03-04", 1), newPrice("2025-03-04", 10),
+			newPrice("2025-03-06", 1), newPrice("2025-03-06", 10),
+			newPrice("2025-03-07", 1), newPrice("2025-03-07", 10),
+			newPrice("2025-03-10", 1),
+			newPrice("2025-03-11", 1), newPrice("2025-03-11", 10),
+		}
+		_, err := pricesRepository.SavePrices(ctx, dbPrices)
+		require.NoError(t, err)
+
+		// Given: The source has Wednesday, but it never published the 10 g bar on Monday
+		provider := &stubPriceProvider{prices: []*model.GoldPrice{newPrice("2025-03-05", 1), newPrice("2025-03-05", 10)}}
+		reconcileUC := usecases.NewReconcileUseCase(st.Logger, pricesRepository, usecases.NewPriceSaver(st.Logger, pricesRepository, nil, usecases.PriceValidationRules{}), gapsRepository, providers.NewRegistry(provider), nil, 1)
+
+		// When: We reconcile the prices
+		reconcileUC.Run(ctx)
+
+		// Then: Both gaps should be fetched by one request
+		require.Equal(t, 1, provider.requests)
+
+		// Then: Wednesday should be filled
+		var filledPrices []*model.GoldPrice
+		require.NoError(t, st.GetDB().WithContext(ctx).Where("date = ?", suite.GetDateTime(t, "2025-03-05")).Find(&filledPrices).Error)
+		require.Len(t, filledPrices, 2)
+
+		// Then: Monday should be remembered as never published
+		var knownGaps []*model.PriceGap
+		knownGaps, err = gapsRepository.GetGaps(ctx, model.PriceSourceNBKR)
+		require.NoError(t, err)
+		require.Len(t, knownGaps, 1)
+		require.True(t, knownGaps[0].Date.Equal(suite.GetDateTime(t, "2025-03-10")))
+		require.Equal(t, model.PriceGapIncomplete, knownGaps[0].Reason)
+		require.True(t, knownGaps[0].Unpublished)
+
+		// When: We reconcile the prices again
+		reconcileUC.Run(ctx)
+
+		// Then: The unpublished day shouldn't be fetched again
+		require.Equal(t, 1, provider.requests)
+	})
+
+	t.Run("should quarantine the suspicious prices of the filled days", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		gapsRepository := gaps.NewRepository(st.GetDB())
+
+		newPrice := func(date string, weight, perGram float64) *model.GoldPrice {
+			return &model.GoldPrice{Date: suite.GetDateTime(t, date), Weight: weight, Source: model.PriceSourceNBKR, PurchasePrice: perGram * weight, SellPrice: (perGram + 10) * weight}
+		}
+
+		// Given: The prices without Wednesday
+		_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+			newPrice("2025-03-03", 1, 1000), newPrice("2025-03-03", 10, 1000),
+			newPrice("2025-03-04", 1, 1000), newPrice("2025-03-04", 10, 1000),
+			newPrice("2025-03-06", 1, 1000), newPrice("2025-03-06", 10, 1000),
+		})
+		require.NoError(t, err)
+
+		// Given: The source has Wednesday with the 1 g bar twice as expensive and without the 10 g bar
+		provider := &stubPriceProvider{prices: []*model.GoldPrice{newPrice("2025-03-05", 1, 2000)}}
+		priceSaver := usecases.NewPriceSaver(st.Logger, pricesRepository, nil, usecases.PriceValidationRules{MaxDailyMove: 0.1})
+		reconcileUC := usecases.NewReconcileUseCase(st.Logger, pricesRepository, priceSaver, gapsRepository, providers.NewRegistry(provider), nil, 3)
+
+		// When: We reconcile the prices
+		reconcileUC.Run(ctx)
+
+		// Then: Wednesday shouldn't be filled
+		var filledPrices []*model.GoldPrice
+		require.NoError(t, st.GetDB().WithContext(ctx).Where("date = ?", suite.GetDateTime(t, "2025-03-05")).Find(&filledPrices).Error)
+		require.Len(t, filledPrices, 0)
+
+		// Then: The suspicious price and the missing weight should wait for the review
+		pending, total, err := pricesRepository.ListPendingQuarantinedPrices(ctx, 10)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, total)
+		require.Equal(t, 1.0, pending[0].Weight)
+		require.False(t, pending[0].IsMissing())
+		require.Equal(t, 10.0, pending[1].Weight)
+		require.True(t, pending[1].IsMissing())
+
+		// Then: Wednesday shouldn't be remembered as a gap while it waits for the review
+		knownGaps, err := gapsRepository.GetGaps(ctx, model.PriceSourceNBKR)
+		require.NoError(t, err)
+		require.Len(t, knownGaps, 0)
+
+		// When: An admin acknowledges the missing weight
+		_, err = pricesRepository.ApproveQuarantinedPrice(ctx, pending[1].ID)
+		require.NoError(t, err)
+
+		// Then: Nothing should be saved for it and it shouldn't be flagged again by the next run
+		reconcileUC.Run(ctx)
+
+		// Then: Wednesday shouldn't be fetched again while the 1 g bar waits for the review
+		require.Equal(t, 1, provider.requests)
+
+		require.NoError(t, st.GetDB().WithContext(ctx).Where("date = ?", suite.GetDateTime(t, "2025-03-05")).Find(&filledPrices).Error)
+		require.Len(t, filledPrices, 0)
+
+		_, total, err = pricesRepository.ListPendingQuarantinedPrices(ctx, 10)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, total)
+	})
+}
@@ -0,0 +1,71 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"goldie/internal/interaction/nbkr"
+	"goldie/internal/model"
+	"goldie/internal/providers"
+)
+
+const ReparseBatchSize = 20
+
+type ReparseArchive interface {
+	FindPagesInBatches(ctx context.Context, batchSize int, fn func(pages []*model.ArchivedPage) error) error
+}
+
+type ReparseUseCase struct {
+	logger  *slog.Logger
+	archive ReparseArchive
+	saver   PricesSaver
+}
+
+func NewReparseUseCase(logger *slog.Logger, archive ReparseArchive, saver PricesSaver) *ReparseUseCase {
+	return &ReparseUseCase{logger: logger.With("component", "reparse"), archive: archive, saver: saver}
+}
+
+// Run parses all the archived NBKR pages again from the oldest to the newest and upserts the valid prices.
+// The pages which can't be parsed are logged and skipped.
+func (that *ReparseUseCase) Run(ctx context.Context) error {
+	log := that.logger.With("method", "Run")
+
+	var pagesCount, failedCount, pricesCount, updatedCount, quarantinedCount int
+
+	err := that.archive.FindPagesInBatches(ctx, ReparseBatchSize, func(pages []*model.ArchivedPage) error {
+		for _, page := range pages {
+			pagesCount++
+
+			prices, err := nbkr.ParseGoldPrice(page.Body)
+			if err != nil {
+				failedCount++
+				if errors.Is(err, providers.ErrNoRows) {
+					continue
+				}
+
+				log.Warn("failed to parse archived page", "error", err, "hash", page.Hash, "url", page.URL, "fetched_at", page.FetchedAt)
+			}
+
+			if len(prices) == 0 {
+				continue
+			}
+
+			result, err := that.saver.SavePrices(ctx, nbkr.ToModelPrices(prices))
+			if err != nil {
+				return fmt.Errorf("save prices of page %s: %w", page.Hash, err)
+			}
+
+			pricesCount += len(prices)
+			updatedCount += result.Updated
+			quarantinedCount += result.Quarantined
+		}
+
+		return nil
+	})
+
+	log.Info("reparse finished", "pages", pagesCount, "failed_pages", failedCount, "prices", pricesCount, "updated_prices", updatedCount, "quarantined_prices", quarantinedCount)
+
+	return err
+}
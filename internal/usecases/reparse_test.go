@@ -0,0 +1,76 @@
+package usecases_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+	"goldie/internal/repository/archive"
+	"goldie/internal/repository/prices"
+	"goldie/internal/usecases"
+	"goldie/testing/suite"
+)
+
+const (
+	archivedPricesPage = `<table><tr><td>Дата</td><td>Мерные золотые слитки, г.</td><td>Цена обратного выкупа, сом</td><td>Цена продажи, сом</td></tr>
+<tr><td>07.11.2025</td><td>1.00</td><td>12 577.00</td><td>12 640.00</td></tr>
+<tr><td>07.11.2025</td><td>10.00</td><td>121 955.00</td><td>125 120.00</td></tr></table>`
+	archivedEmptyPage = `<table><tr><td>Дата</td><td>Мерные золотые слитки, г.</td><td>Цена обратного выкупа, сом</td><td>Цена продажи, сом</td></tr></table>`
+)
+
+func Test_ArchiveRepository_SavePage(t *testing.T) {
+	t.Run("should keep the first copy of the same content", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		archiveRepository := archive.NewRepository(st.GetDB())
+
+		fetchedAt := time.Date(2025, 11, 7, 10, 0, 0, 0, time.UTC)
+
+		// Given: The page is archived twice with the same content and once with another one
+		require.NoError(t, archiveRepository.SavePage(ctx, &model.ArchivedPage{URL: "first", FetchedAt: fetchedAt, Body: archivedPricesPage}))
+		require.NoError(t, archiveRepository.SavePage(ctx, &model.ArchivedPage{URL: "second", FetchedAt: fetchedAt.Add(time.Hour), Body: archivedPricesPage}))
+		require.NoError(t, archiveRepository.SavePage(ctx, &model.ArchivedPage{URL: "empty", FetchedAt: fetchedAt.Add(2 * time.Hour), Body: archivedEmptyPage}))
+
+		// When: The pages are read back one by one
+		var pages []*model.ArchivedPage
+		err := archiveRepository.FindPagesInBatches(ctx, 1, func(batch []*model.ArchivedPage) error {
+			pages = append(pages, batch...)
+			return nil
+		})
+		require.NoError(t, err)
+
+		// Then: The first copy should be kept untouched and the bodies decompressed
+		require.Len(t, pages, 2)
+		require.Equal(t, "first", pages[0].URL)
+		require.True(t, fetchedAt.Equal(pages[0].FetchedAt))
+		require.Equal(t, archivedPricesPage, pages[0].Body)
+		require.Equal(t, "empty", pages[1].URL)
+		require.Equal(t, archivedEmptyPage, pages[1].Body)
+	})
+}
+
+func Test_ReparseUseCase_Run(t *testing.T) {
+	t.Run("should save the prices of the archived pages", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		archiveRepository := archive.NewRepository(st.GetDB())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		reparseUC := usecases.NewReparseUseCase(st.Logger, archiveRepository, usecases.NewPriceSaver(st.Logger, pricesRepository, nil, usecases.PriceValidationRules{}))
+
+		// Given: The archived page with prices and the one without them
+		fetchedAt := time.Date(2025, 11, 7, 10, 0, 0, 0, time.UTC)
+		require.NoError(t, archiveRepository.SavePage(ctx, &model.ArchivedPage{URL: "prices", FetchedAt: fetchedAt, Body: archivedPricesPage}))
+		require.NoError(t, archiveRepository.SavePage(ctx, &model.ArchivedPage{URL: "empty", FetchedAt: fetchedAt.Add(time.Hour), Body: archivedEmptyPage}))
+
+		// When: The archive is parsed again
+		require.NoError(t, reparseUC.Run(ctx))
+
+		// Then: The prices of the first page should be stored, the empty page skipped
+		var storedPrices []*model.GoldPrice
+		require.NoError(t, st.GetDB().WithContext(ctx).Order("weight asc").Find(&storedPrices).Error)
+		require.Len(t, storedPrices, 2)
+		require.True(t, suite.GetDateTime(t, "2025-11-07").Equal(storedPrices[0].Date))
+		require.Equal(t, 12577.0, storedPrices[0].PurchasePrice)
+		require.Equal(t, 125120.0, storedPrices[1].SellPrice)
+	})
+}
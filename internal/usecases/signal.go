@@ -0,0 +1,135 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"goldie/internal/model"
+)
+
+type SignalPricesRepository interface {
+	GetLatestPrices(ctx context.Context) ([]*model.GoldPrice, error)
+	GetPricesBetween(ctx context.Context, source string, begin, end time.Time) ([]*model.GoldPrice, error)
+}
+
+type SignalChatsRepository interface {
+	FetchSignals(ctx context.Context) ([]*model.TgChatSignal, error)
+	SetSignalChecked(ctx context.Context, signalID int64, state string, date time.Time, fired bool) error
+}
+
+type SignalTGIntegration interface {
+	SignalToString(languageCode string, event *model.SignalEvent) string
+}
+
+type SignalUseCase struct {
+	logger           *slog.Logger
+	pricesRepository SignalPricesRepository
+	chatsRepository  SignalChatsRepository
+	tgIntegration    SignalTGIntegration
+	deliverer        AlertDeliverer
+}
+
+func NewSignalUseCase(logger *slog.Logger, pricesRepository SignalPricesRepository, chatsRepository SignalChatsRepository, tgIntegration SignalTGIntegration, deliverer AlertDeliverer) *SignalUseCase {
+	return &SignalUseCase{logger: logger.With("component", "signal"), pricesRepository: pricesRepository, chatsRepository: chatsRepository, tgIntegration: tgIntegration, deliverer: deliverer}
+}
+
+// Run checks the technical-indicator signals against the history of the latest prices at the delivery time of
+// every chat. Every signal is checked once per price date, the fired one is delivered once for the date even if
+// its state isn't saved and it's checked again by the next run.
+func (that *SignalUseCase) Run(ctx context.Context, now time.Time) {
+	log := that.logger.With("method", "Run")
+
+	prices, err := that.pricesRepository.GetLatestPrices(ctx)
+	if err != nil {
+		log.Error("failed to get prices", "error", err)
+		return
+	}
+
+	if len(prices) == 0 {
+		return
+	}
+
+	latestDate := prices[0].Date
+
+	signals, err := that.chatsRepository.FetchSignals(ctx)
+	if err != nil {
+		log.Error("failed to get signals", "error", err)
+		return
+	}
+
+	pending := make([]*model.TgChatSignal, 0, len(signals))
+	for _, signal := range signals {
+		if (signal.CheckedDate == nil || latestDate.After(*signal.CheckedDate)) && signal.Chat.IsDeliveryTimeReached(now) {
+			pending = append(pending, signal)
+		}
+	}
+
+	if len(pending) == 0 {
+		return
+	}
+
+	histories, err := that.loadHistories(ctx, pending, latestDate)
+	if err != nil {
+		log.Error("failed to load price history", "error", err)
+		return
+	}
+
+	var fired, failed int
+
+	for _, signal := range pending {
+		history := signalHistory(histories[signal.Weight], signal.HistoryBegin(latestDate))
+		if len(history) == 0 || !history[len(history)-1].Date.Equal(latestDate) {
+			continue
+		}
+
+		event, state := signal.Check(history)
+		if event != nil {
+			// The failed delivery is retried by the deliverer, the signal isn't checked again for it
+			text := that.tgIntegration.SignalToString(signal.Chat.GetLanguageCode(), event)
+			that.deliverer.Deliver(ctx, &signal.Chat, model.SignalDeliveryKind(signal.ID), latestDate, text)
+			fired++
+		}
+
+		if err = that.chatsRepository.SetSignalChecked(ctx, signal.ID, state, latestDate, event != nil); err != nil {
+			log.Error("failed to update signal state", "error", err, "signal_id", signal.ID)
+			failed++
+		}
+	}
+
+	log.Info("signals checked", "signals", len(pending), "fired", fired, "failed", failed)
+}
+
+// loadHistories returns the NBKR prices by weight from the earliest date the signals need up to the latest date.
+func (that *SignalUseCase) loadHistories(ctx context.Context, signals []*model.TgChatSignal, latestDate time.Time) (map[float64][]*model.GoldPrice, error) {
+	begin := latestDate
+	for _, signal := range signals {
+		if signalBegin := signal.HistoryBegin(latestDate); signalBegin.Before(begin) {
+			begin = signalBegin
+		}
+	}
+
+	prices, err := that.pricesRepository.GetPricesBetween(ctx, model.PriceSourceNBKR, begin, latestDate.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("get prices since %s: %w", begin.Format("2006-01-02"), err)
+	}
+
+	histories := make(map[float64][]*model.GoldPrice)
+	for _, price := range prices {
+		histories[price.Weight] = append(histories[price.Weight], price)
+	}
+
+	return histories, nil
+}
+
+// signalHistory returns the prices of the history since the begin date.
+func signalHistory(history []*model.GoldPrice, begin time.Time) []*model.GoldPrice {
+	for i, price := range history {
+		if !price.Date.Before(begin) {
+			return history[i:]
+		}
+	}
+
+	return nil
+}
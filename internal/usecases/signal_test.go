@@ -0,0 +1,99 @@
+package usecases_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goldie/internal/model"
+	"goldie/internal/repository/chats"
+	"goldie/internal/repository/deliveries"
+	"goldie/internal/repository/prices"
+	"goldie/internal/usecases"
+	"goldie/testing/suite"
+)
+
+func (that *stubPriceAlertTG) SignalToString(_ string, event *model.SignalEvent) string {
+	return event.Signal.Kind + event.Direction
+}
+
+func Test_SignalUseCase_Run(t *testing.T) {
+	t.Run("should fire the signals once per price date", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubPriceAlertTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 1})
+		signalUC := usecases.NewSignalUseCase(st.Logger, pricesRepository, chatsRepository, tgIntegration, alertDeliveryUC)
+
+		day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -5)
+		savePrice := func(sellPrice float64) {
+			day = day.AddDate(0, 0, 1)
+			_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+				{Date: day, Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: sellPrice - 10, SellPrice: sellPrice},
+			})
+			require.NoError(t, err)
+		}
+
+		// Given: The chat watches the 3-day SMA and the 52-week high of the 1 g bar
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.CreateSignal(ctx, chatID, &model.TgChatSignal{Weight: 1, Kind: model.SignalKindSMA, Period: 3}))
+		require.NoError(t, chatsRepository.CreateSignal(ctx, chatID, &model.TgChatSignal{Weight: 1, Kind: model.SignalKindHigh, Period: 52}))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, chatID, "00:00"))
+
+		// When: The price stays flat and drops below the average
+		for _, price := range []float64{100, 100, 100, 90} {
+			savePrice(price)
+			signalUC.Run(ctx, time.Now())
+		}
+
+		// Then: Nothing should be fired, the first side of the average isn't a crossing
+		require.Empty(t, tgIntegration.messages)
+
+		// When: The price jumps above the average and the previous high, the run is repeated
+		savePrice(120)
+		signalUC.Run(ctx, time.Now())
+		signalUC.Run(ctx, time.Now())
+
+		// Then: Both signals should be fired once
+		require.Equal(t, []sentMessage{
+			{chatID: chatID, text: model.SignalKindSMA + model.SignalStateAbove},
+			{chatID: chatID, text: model.SignalKindHigh},
+		}, tgIntegration.messages)
+	})
+
+	t.Run("should deliver the signal once at the delivery time", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
+		chatsRepository := chats.NewRepository(st.GetDB())
+		tgIntegration := &stubPriceAlertTG{}
+		alertDeliveryUC := usecases.NewAlertDeliveryUseCase(st.Logger, deliveries.NewRepository(st.GetDB()), tgIntegration, usecases.AlertDeliveryOptions{MaxAttempts: 1})
+		signalUC := usecases.NewSignalUseCase(st.Logger, pricesRepository, chatsRepository, tgIntegration, alertDeliveryUC)
+
+		// Given: The price of the 1 g bar hits the new high and the chat watches it with the delivery time at noon
+		_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+			{Date: time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC), Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 90, SellPrice: 100},
+			{Date: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 110, SellPrice: 120},
+		})
+		require.NoError(t, err)
+
+		const chatID = int64(100)
+		require.NoError(t, chatsRepository.CreateSignal(ctx, chatID, &model.TgChatSignal{Weight: 1, Kind: model.SignalKindHigh, Period: 52}))
+		require.NoError(t, chatsRepository.SetDeliveryTime(ctx, chatID, "12:00"))
+
+		// When: We run the signals in the morning in Bishkek
+		signalUC.Run(ctx, time.Date(2025, 3, 10, 5, 0, 0, 0, time.UTC))
+
+		// Then: Nothing should be fired before the delivery time
+		require.Empty(t, tgIntegration.messages)
+
+		// When: We run the signals after noon, then the state of the signal is lost and they are run again
+		signalUC.Run(ctx, time.Date(2025, 3, 10, 6, 0, 0, 0, time.UTC))
+		require.NoError(t, st.GetDB().WithContext(ctx).Model(&model.TgChatSignal{}).Where("1 = 1").Update("checked_date", nil).Error)
+		signalUC.Run(ctx, time.Date(2025, 3, 10, 6, 15, 0, 0, time.UTC))
+
+		// Then: The signal should be delivered once for the price date
+		require.Equal(t, []sentMessage{{chatID: chatID, text: model.SignalKindHigh}}, tgIntegration.messages)
+	})
+}
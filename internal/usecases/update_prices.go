@@ -2,111 +2,162 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"goldie/internal/interaction/nbkr"
 	"goldie/internal/model"
+	"goldie/internal/providers"
 )
 
-const FirstPriceDate = "2015-07-05"
+// updatePricesDays is the number of the recent days fetched by every update, it covers the long holidays.
+// The older missing dates are filled by the reconciliation.
+const updatePricesDays = 14
+
+// revisionsReportLimit limits the number of corrected or quarantined prices listed for the admins.
+const revisionsReportLimit = 20
 
 type UpdatePricesRepository interface {
-	SavePrices(ctx context.Context, prices []*model.GoldPrice) error
-	ExistsFirstPrice(ctx context.Context, date time.Time) (bool, error)
+	SaveCurrencyRates(ctx context.Context, rates []*model.CurrencyRate) error
+}
+
+type UpdatePricesRatesInteraction interface {
+	GetCurrencyRates(ctx context.Context) ([]nbkr.CurrencyRate, error)
+}
+
+type UpdatePricesNotifier interface {
+	NotifyAdmins(ctx context.Context, text string)
+}
+
+type UpdatePricesProviders interface {
+	All() []providers.Provider
 }
 
-type UpdatePricesInteraction interface {
-	GetGoldPrices(ctx context.Context, beginDate time.Time, endDate time.Time) ([]nbkr.GoldPrice, error)
+// UpdatePricesOptions configures the reports of the fetched prices.
+type UpdatePricesOptions struct {
+	NotifyRevisions bool // tell the admins when a source corrects the published prices
 }
 
 type UpdatePricesUseCase struct {
-	logger      *slog.Logger
-	repository  UpdatePricesRepository
-	interaction UpdatePricesInteraction
-	loc         *time.Location
+	logger           *slog.Logger
+	repository       UpdatePricesRepository
+	saver            PricesSaver
+	providers        UpdatePricesProviders
+	ratesInteraction UpdatePricesRatesInteraction
+	notifier         UpdatePricesNotifier
+	options          UpdatePricesOptions
+	loc              *time.Location
 }
 
-func NewUpdatePricesUseCase(logger *slog.Logger, repository UpdatePricesRepository, interaction UpdatePricesInteraction, loc *time.Location) *UpdatePricesUseCase {
-	return &UpdatePricesUseCase{logger: logger.With("component", "update_prices"), repository: repository, interaction: interaction, loc: loc}
+func NewUpdatePricesUseCase(logger *slog.Logger, repository UpdatePricesRepository, saver PricesSaver, providers UpdatePricesProviders, ratesInteraction UpdatePricesRatesInteraction, notifier UpdatePricesNotifier, options UpdatePricesOptions, loc *time.Location) *UpdatePricesUseCase {
+	return &UpdatePricesUseCase{logger: logger.With("component", "update_prices"), repository: repository, saver: saver, providers: providers, ratesInteraction: ratesInteraction, notifier: notifier, options: options, loc: loc}
 }
 
+// UpdatePrices fetches the recent prices from every enabled provider and the current exchange rates.
 func (that *UpdatePricesUseCase) UpdatePrices(ctx context.Context) {
-	log := that.logger.With("method", "UpdatePrices")
+	that.updatePrices(ctx)
+	that.updateCurrencyRates(ctx)
+}
 
+func (that *UpdatePricesUseCase) updatePrices(ctx context.Context) {
 	endDate := time.Now()
-	startDate := time.Date(endDate.Year()-1, endDate.Month(), endDate.Day(), 0, 0, 0, 0, that.loc)
+	startDate := time.Date(endDate.Year(), endDate.Month(), endDate.Day()-updatePricesDays, 0, 0, 0, 0, that.loc)
 
-	prices, err := that.interaction.GetGoldPrices(ctx, startDate, endDate)
-	if err != nil {
-		log.Error("failed to get gold prices", "error", err)
-		return
-	}
+	for _, provider := range that.providers.All() {
+		log := that.logger.With("method", "UpdatePrices", "source", provider.Source())
 
-	dbPrices := make([]*model.GoldPrice, len(prices))
-	for i, price := range prices {
-		dbPrices[i] = &model.GoldPrice{
-			Date:          price.Date,
-			Weight:        price.Weight,
-			PurchasePrice: price.PurchasePrice,
-			SellPrice:     price.SellPrice,
+		prices, err := provider.GetPrices(ctx, startDate, endDate)
+		if err != nil {
+			// There are always prices for the recent days, so even ErrNoRows is reported
+			reportFetchError(ctx, log, that.notifier, provider.Source(), err)
+			if len(prices) == 0 {
+				continue
+			}
 		}
-	}
 
-	if err = that.repository.SavePrices(ctx, dbPrices); err != nil {
-		log.Error("failed to save prices", "error", err)
-		return
+		result, err := that.saver.SavePrices(ctx, prices)
+		if err != nil {
+			log.Error("failed to save prices", "error", err)
+			continue
+		}
+
+		log.Info("saved prices", "inserted", result.Inserted, "updated", result.Updated, "unchanged", result.Unchanged, "quarantined", result.Quarantined)
+
+		if len(result.Revisions) > 0 {
+			log.Warn("source corrected the published prices", "revisions", len(result.Revisions))
+			if that.options.NotifyRevisions {
+				notifyAdmins(ctx, that.notifier, formatRevisions(provider.Source(), result.Revisions))
+			}
+		}
 	}
 }
 
-func (that *UpdatePricesUseCase) FirstImport(ctx context.Context) {
-	log := that.logger.With("method", "UpdatePrices")
+func (that *UpdatePricesUseCase) updateCurrencyRates(ctx context.Context) {
+	log := that.logger.With("method", "updateCurrencyRates")
 
-	// The start date is the first date of the first price from the NBKR
-	startDate := time.Date(2015, 1, 1, 0, 0, 0, 0, that.loc)
-	endDate := startDate.AddDate(1, 0, 0)
-
-	// Need to check existing data from the first date
-	firstPriceDate, _ := time.Parse("2006-01-02", FirstPriceDate)
-	exists, err := that.repository.ExistsFirstPrice(ctx, firstPriceDate)
+	rates, err := that.ratesInteraction.GetCurrencyRates(ctx)
 	if err != nil {
-		log.Error("failed to check if first price exists", "error", err)
+		log.Error("failed to get currency rates", "error", err)
 		return
 	}
 
-	if exists {
-		// The first price already exists, we don't need to import it
+	dbRates := make([]*model.CurrencyRate, len(rates))
+	for i, rate := range rates {
+		dbRates[i] = &model.CurrencyRate{
+			Date:     rate.Date,
+			Currency: rate.Currency,
+			Rate:     rate.Rate,
+		}
+	}
+
+	if err = that.repository.SaveCurrencyRates(ctx, dbRates); err != nil {
+		log.Error("failed to save currency rates", "error", err)
 		return
 	}
+}
 
-	for {
-		prices, err := that.interaction.GetGoldPrices(ctx, startDate, endDate)
-		if err != nil {
-			log.Error("failed to get gold prices", "error", err)
-			return
-		}
+// reportFetchError logs the error and notifies the admins if the provider page can't be parsed.
+func reportFetchError(ctx context.Context, log *slog.Logger, notifier UpdatePricesNotifier, source string, err error) {
+	switch {
+	case errors.Is(err, providers.ErrLayoutChanged), errors.Is(err, providers.ErrNoRows):
+		log.Error("price page parsed to nothing that looks like a price", "error", err)
+		notifyAdmins(ctx, notifier, fmt.Sprintf("⚠️ %s: the price page parsed to nothing that looks like a price, check the parser.\n\n%s", source, err))
+	case errors.Is(err, providers.ErrInvalidRow):
+		log.Error("price page has invalid rows, saving the valid ones", "error", err)
+		notifyAdmins(ctx, notifier, fmt.Sprintf("⚠️ %s: some rows of the price page can't be parsed, the valid ones are saved.\n\n%s", source, err))
+	default:
+		log.Error("failed to get gold prices", "error", err)
+	}
+}
 
-		if len(prices) == 0 {
-			// Imported all the data
-			break
-		}
+// formatRevisions describes the corrected prices for the admins.
+func formatRevisions(source string, revisions []*model.GoldPriceRevision) string {
+	var sb strings.Builder
 
-		dbPrices := make([]*model.GoldPrice, len(prices))
-		for i, price := range prices {
-			dbPrices[i] = &model.GoldPrice{
-				Date:          price.Date,
-				Weight:        price.Weight,
-				PurchasePrice: price.PurchasePrice,
-				SellPrice:     price.SellPrice,
-			}
-		}
+	sb.WriteString(fmt.Sprintf("✏️ %s: %d published prices are corrected", source, len(revisions)))
 
-		if err = that.repository.SavePrices(ctx, dbPrices); err != nil {
-			log.Error("failed to save prices", "error", err)
-			return
-		}
+	for _, revision := range revisions[:min(len(revisions), revisionsReportLimit)] {
+		sb.WriteString(fmt.Sprintf("\n%s %g g: purchase %.2f → %.2f, sell %.2f → %.2f",
+			revision.Date.Format(time.DateOnly), revision.Weight,
+			revision.OldPurchasePrice, revision.NewPurchasePrice,
+			revision.OldSellPrice, revision.NewSellPrice,
+		))
+	}
 
-		startDate = endDate
-		endDate = startDate.AddDate(1, 0, 0)
+	if len(revisions) > revisionsReportLimit {
+		sb.WriteString(fmt.Sprintf("\n... and %d more", len(revisions)-revisionsReportLimit))
 	}
+
+	return sb.String()
+}
+
+func notifyAdmins(ctx context.Context, notifier UpdatePricesNotifier, text string) {
+	if notifier == nil {
+		return
+	}
+
+	notifier.NotifyAdmins(ctx, text)
 }
@@ -1,92 +1,121 @@
 package usecases_test
 
 import (
-	"log/slog"
-	"path/filepath"
-	"sort"
-	"strings"
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
-	"gopkg.in/dnaeon/go-vcr.v4/pkg/recorder"
 
 	"goldie/internal/interaction/nbkr"
 	"goldie/internal/model"
+	"goldie/internal/providers"
 	"goldie/internal/repository/prices"
 	"goldie/internal/usecases"
 	"goldie/testing/suite"
 )
 
-func Test_UpdatePricesUseCase_FirstImport(t *testing.T) {
-	t.Run("should import prices for the first year", func(t *testing.T) {
-		ctx, st := suite.New(t, suite.WithPostgres())
-		pricesRepository := prices.NewRepository(st.GetDB())
-
-		r, err := recorder.New(filepath.Join("testdata", strings.ReplaceAll(t.Name(), "/", "_")))
-		require.NoError(t, err)
+type stubRatesInteraction struct{}
 
-		t.Cleanup(func() {
-			// Make sure recorder is stopped once done with it.
-			require.NoError(t, r.Stop())
-		})
-
-		interaction := nbkr.NewInteraction(slog.Default(), r.GetDefaultClient())
-		updatePriceUC := usecases.NewUpdatePricesUseCase(st.Logger, pricesRepository, interaction, st.Loc)
+func (stubRatesInteraction) GetCurrencyRates(context.Context) ([]nbkr.CurrencyRate, error) {
+	return nil, nil
+}
 
-		// When: We import the prices for the first year
-		updatePriceUC.FirstImport(ctx)
+func Test_UpdatePricesUseCase_UpdatePrices(t *testing.T) {
+	t.Run("should record the corrected prices in the revision history", func(t *testing.T) {
+		ctx, st := suite.New(t, suite.WithPostgres())
+		pricesRepository := prices.NewRepository(st.GetDB())
 
-		// Then: The prices should be created in the database
-		var createdPrices []*model.GoldPrice
-		require.NoError(t, st.GetDB().WithContext(ctx).Model(&createdPrices).Omit("ID", "CreatedAt", "UpdatedAt").Where("date = ?", suite.GetDateTime(t, usecases.FirstPriceDate)).Find(&createdPrices).Error)
-		require.Len(t, createdPrices, 6)
+		date := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
 
-		sort.SliceStable(createdPrices, func(i, j int) bool {
-			if createdPrices[i].Date.Equal(createdPrices[j].Date) {
-				return createdPrices[i].Weight < createdPrices[j].Weight
-			}
-			return createdPrices[i].Date.After(createdPrices[j].Date)
+		// Given: The stored prices
+		_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+			{Date: date, Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 9000, SellPrice: 9100},
+			{Date: date, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 88000, SellPrice: 89000},
 		})
+		require.NoError(t, err)
 
-		expectedPrices := []*model.GoldPrice{
-			{Date: suite.GetDateTime(t, usecases.FirstPriceDate).In(time.Local), Weight: 1, PurchasePrice: 3822, SellPrice: 3841},
-			{Date: suite.GetDateTime(t, usecases.FirstPriceDate).In(time.Local), Weight: 2, PurchasePrice: 6542.5, SellPrice: 6568.5},
-			{Date: suite.GetDateTime(t, usecases.FirstPriceDate).In(time.Local), Weight: 5, PurchasePrice: 14969.5, SellPrice: 15014.5},
-			{Date: suite.GetDateTime(t, usecases.FirstPriceDate).In(time.Local), Weight: 10, PurchasePrice: 29094, SellPrice: 29152},
-			{Date: suite.GetDateTime(t, usecases.FirstPriceDate).In(time.Local), Weight: 31.1035, PurchasePrice: 87745, SellPrice: 87833},
-			{Date: suite.GetDateTime(t, usecases.FirstPriceDate).In(time.Local), Weight: 100, PurchasePrice: 239802, SellPrice: 240041.5},
-		}
-		require.Equal(t, expectedPrices, createdPrices)
+		// Given: The source corrected the 10 g bar and published the 100 g bar
+		provider := &stubPriceProvider{prices: []*model.GoldPrice{
+			{Date: date, Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 9000, SellPrice: 9100},
+			{Date: date, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 88500, SellPrice: 89500},
+			{Date: date, Weight: 100, Source: model.PriceSourceNBKR, PurchasePrice: 870000, SellPrice: 880000},
+		}}
+		updatePriceUC := usecases.NewUpdatePricesUseCase(st.Logger, pricesRepository, usecases.NewPriceSaver(st.Logger, pricesRepository, nil, usecases.PriceValidationRules{}), providers.NewRegistry(provider), stubRatesInteraction{}, nil, usecases.UpdatePricesOptions{}, st.Loc)
+
+		// When: We update the prices
+		updatePriceUC.UpdatePrices(ctx)
+
+		// Then: The 10 g bar should be updated and its old value kept in the history
+		var revisions []*model.GoldPriceRevision
+		require.NoError(t, st.GetDB().WithContext(ctx).Find(&revisions).Error)
+		require.Len(t, revisions, 1)
+		require.Equal(t, 10.0, revisions[0].Weight)
+		require.Equal(t, 88000.0, revisions[0].OldPurchasePrice)
+		require.Equal(t, 88500.0, revisions[0].NewPurchasePrice)
+		require.Equal(t, 89000.0, revisions[0].OldSellPrice)
+		require.Equal(t, 89500.0, revisions[0].NewSellPrice)
+
+		var storedPrices []*model.GoldPrice
+		require.NoError(t, st.GetDB().WithContext(ctx).Where("date = ?", date).Order("weight asc").Find(&storedPrices).Error)
+		require.Len(t, storedPrices, 3)
+		require.Equal(t, 89500.0, storedPrices[1].SellPrice)
 	})
 
-	t.Run("shouldn't import prices for the first year if they already exist", func(t *testing.T) {
+	t.Run("should quarantine the suspicious prices", func(t *testing.T) {
 		ctx, st := suite.New(t, suite.WithPostgres())
 		pricesRepository := prices.NewRepository(st.GetDB())
 
-		r, err := recorder.New(filepath.Join("testdata", strings.ReplaceAll(t.Name(), "/", "_")))
-		require.NoError(t, err)
+		yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+		previousDay := yesterday.AddDate(0, 0, -1)
 
-		t.Cleanup(func() {
-			// Make sure recorder is stopped once done with it.
-			require.NoError(t, r.Stop())
+		// Given: The stored prices of the previous day
+		_, err := pricesRepository.SavePrices(ctx, []*model.GoldPrice{
+			{Date: previousDay, Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 9000, SellPrice: 9100},
+			{Date: previousDay, Weight: 2, Source: model.PriceSourceNBKR, PurchasePrice: 17900, SellPrice: 18100},
+			{Date: previousDay, Weight: 5, Source: model.PriceSourceNBKR, PurchasePrice: 44600, SellPrice: 45000},
+			{Date: previousDay, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 88000, SellPrice: 89000},
 		})
+		require.NoError(t, err)
 
-		// Given: Prepared prices for the first year and for some other years
-		dbPrices := []*model.GoldPrice{
-			{Date: suite.GetDateTime(t, usecases.FirstPriceDate), Weight: 3, PurchasePrice: 34567, SellPrice: 34901},
-		}
-		require.NoError(t, st.GetDB().WithContext(ctx).Create(&dbPrices).Error)
+		// Given: The source published the 10 g bar 10 times higher than the other weights
+		provider := &stubPriceProvider{prices: []*model.GoldPrice{
+			{Date: yesterday, Weight: 1, Source: model.PriceSourceNBKR, PurchasePrice: 9050, SellPrice: 9150},
+			{Date: yesterday, Weight: 2, Source: model.PriceSourceNBKR, PurchasePrice: 18000, SellPrice: 18200},
+			{Date: yesterday, Weight: 5, Source: model.PriceSourceNBKR, PurchasePrice: 44800, SellPrice: 45200},
+			{Date: yesterday, Weight: 10, Source: model.PriceSourceNBKR, PurchasePrice: 885000, SellPrice: 895000},
+		}}
+		priceSaver := usecases.NewPriceSaver(st.Logger, pricesRepository, nil, usecases.PriceValidationRules{MaxDailyMove: 0.1, MaxPerGramDeviation: 0.5})
+		updatePriceUC := usecases.NewUpdatePricesUseCase(st.Logger, pricesRepository, priceSaver, providers.NewRegistry(provider), stubRatesInteraction{}, nil, usecases.UpdatePricesOptions{}, st.Loc)
+
+		// When: We update the prices
+		updatePriceUC.UpdatePrices(ctx)
+
+		// Then: Only the valid prices should be saved
+		var storedPrices []*model.GoldPrice
+		require.NoError(t, st.GetDB().WithContext(ctx).Where("date = ? AND weight = ?", yesterday, 10).Find(&storedPrices).Error)
+		require.Len(t, storedPrices, 0)
+		require.NoError(t, st.GetDB().WithContext(ctx).Where("date = ?", yesterday).Find(&storedPrices).Error)
+		require.Len(t, storedPrices, 3)
+
+		// Then: The suspicious price should wait for the review
+		pending, total, err := pricesRepository.ListPendingQuarantinedPrices(ctx, 10)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, total)
+		require.Equal(t, 10.0, pending[0].Weight)
+
+		// When: An admin approves it
+		_, err = pricesRepository.ApproveQuarantinedPrice(ctx, pending[0].ID)
+		require.NoError(t, err)
 
-		interaction := nbkr.NewInteraction(slog.Default(), r.GetDefaultClient())
-		updatePriceUC := usecases.NewUpdatePricesUseCase(st.Logger, pricesRepository, interaction, st.Loc)
+		// Then: The price should be saved and not quarantined again by the next update
+		updatePriceUC.UpdatePrices(ctx)
 
-		// When: We import the prices for the first year
-		updatePriceUC.FirstImport(ctx)
+		require.NoError(t, st.GetDB().WithContext(ctx).Where("date = ?", yesterday).Find(&storedPrices).Error)
+		require.Len(t, storedPrices, 4)
 
-		// Then: The prices shouldn't be created in the database
-		var createdPrices []*model.GoldPrice
-		require.NoError(t, st.GetDB().WithContext(ctx).Model(&createdPrices).Omit("ID", "CreatedAt", "UpdatedAt").Where("date != ?", suite.GetDateTime(t, usecases.FirstPriceDate)).Find(&createdPrices).Error)
-		require.Len(t, createdPrices, 0)
+		_, total, err = pricesRepository.ListPendingQuarantinedPrices(ctx, 10)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, total)
 	})
 }